@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/compare"
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/reporter"
+	"github.com/LarsArtmann/templates/repo-validation/internal/scan"
+)
+
+// RunDiff parses the arguments of the diff subcommand and compares two result files or two
+// repositories requirement by requirement
+func RunDiff(args []string) (err error) {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	format := fs.String("format", reporter.FormatText, "Output format: text, json or markdown")
+	jsonOutput := fs.Bool("json", false, "Output results in JSON format, same as --format json")
+	unchanged := fs.Bool("unchanged", false, "Also list unchanged requirements in text and Markdown output")
+	var templateDirs StringList
+	fs.Var(&templateDirs, "template-dir", "Organisation template directory, when comparing repositories (repeatable)")
+	fileGroups := addFileGroupFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+	if *jsonOutput {
+		*format = reporter.FormatJSON
+	}
+	defer func() {
+		err = withJSON(err, *format == reporter.FormatJSON)
+	}()
+	switch *format {
+	case reporter.FormatText, reporter.FormatJSON, reporter.FormatMarkdown:
+	default:
+		return errors.NewInvalidConfigError(fmt.Sprintf("unknown format %q, expected text, json or markdown", *format))
+	}
+	if fs.NArg() != 2 {
+		return errors.NewInvalidConfigError("usage: repo-validate diff [flags] <before> <after>, each a result file written by --json or a repository path")
+	}
+
+	options := append(fileGroups(), config.WithTemplateDirs(append(templateDirs, TemplateDirsFromEnv()...)...))
+	scanner := scan.NewScanner(1, options...)
+
+	before, err := loadSide(scanner, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	after, err := loadSide(scanner, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	result := compare.Compare(before, after)
+	if err := reporter.WriteComparison(os.Stdout, *format, result, *unchanged); err != nil {
+		return fmt.Errorf("error reporting comparison: %w", err)
+	}
+
+	if regressed := result.Count(compare.Regressed); regressed > 0 {
+		return fmt.Errorf("%d requirements regressed", regressed)
+	}
+
+	return nil
+}
+
+// loadSide reads a side of a comparison from a result file, or validates the repository at path
+func loadSide(scanner *scan.Scanner, path string) (compare.Side, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return compare.Side{}, errors.NewFileAccessError(path, err)
+	}
+
+	if !stat.IsDir() {
+		side, err := compare.Load(path)
+		if err != nil {
+			return compare.Side{}, errors.NewInvalidConfigError(err.Error())
+		}
+		return side, nil
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return compare.Side{}, errors.NewPathError(path, err)
+	}
+	result := scanner.Check(absPath)
+	if result.Error != nil {
+		return compare.Side{}, errors.NewPathError(absPath, result.Error)
+	}
+
+	return compare.FromResults(absPath, result.Results), nil
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"flag"
+	"path/filepath"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/journal"
+	"github.com/LarsArtmann/templates/repo-validation/internal/reporter"
+)
+
+// RunFix parses the arguments of the fix subcommand and executes it
+func RunFix(args []string) (err error) {
+	fs := flag.NewFlagSet("fix", flag.ContinueOnError)
+	repoPath := fs.String("path", ".", "Path to the repository")
+	undo := fs.Bool("undo", false, "Revert the files written by the last --fix")
+	force := fs.Bool("force", false, "Undo even if files were changed after they were written")
+	jsonOutput := fs.Bool("json", false, "Output results in JSON format")
+
+	if err := fs.Parse(args); err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+	defer func() {
+		err = withJSON(err, *jsonOutput)
+	}()
+	if !*undo {
+		return errors.NewInvalidConfigError("usage: repo-validate fix --undo [--force] [--path <repo>]")
+	}
+
+	absPath, err := filepath.Abs(*repoPath)
+	if err != nil {
+		return errors.NewPathError(*repoPath, err)
+	}
+
+	j, err := journal.Undo(absPath, *force)
+	if err != nil {
+		if stderrors.Is(err, journal.ErrNoJournal) {
+			return errors.NewInvalidConfigError(err.Error())
+		}
+		return errors.NewFileAccessError(absPath, err)
+	}
+
+	cfg := &config.Config{RepoPath: absPath, JSONOutput: *jsonOutput}
+	return reporter.NewReporter(cfg).ReportUndo(j)
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/history"
+)
+
+// TemplatePathEnv is the environment variable listing organisation template directories,
+// separated by the OS path list separator
+const TemplatePathEnv = "REPO_VALIDATION_TEMPLATE_PATH"
+
+// HistoryFileEnv is the environment variable overriding the location of the history file
+const HistoryFileEnv = "REPO_VALIDATION_HISTORY"
+
+// StringList is a flag.Value that collects repeated string flags
+type StringList []string
+
+// String returns the flag value as a comma-separated list
+func (l *StringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set appends a value to the list
+func (l *StringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// jsonError marks an error of a subcommand asked for JSON output, so it is reported as JSON too
+type jsonError struct {
+	error
+}
+
+// withJSON marks err to be reported as JSON if the subcommand was asked for JSON output
+func withJSON(err error, jsonOutput bool) error {
+	if err == nil || !jsonOutput {
+		return err
+	}
+	return &jsonError{err}
+}
+
+// JSONOutput reports whether the error of a subcommand should be reported as JSON
+func JSONOutput(err error) bool {
+	_, ok := err.(*jsonError)
+	return ok
+}
+
+// Cause returns the error of a subcommand without its JSON output mark
+func Cause(err error) error {
+	if e, ok := err.(*jsonError); ok {
+		return e.error
+	}
+	return err
+}
+
+// TemplateDirsFromEnv returns the organisation template directories configured in the environment
+func TemplateDirsFromEnv() []string {
+	value := os.Getenv(TemplatePathEnv)
+	if value == "" {
+		return nil
+	}
+	return filepath.SplitList(value)
+}
+
+// HistoryFile returns the history file to record to and read from: file if set, otherwise the
+// file configured in the environment, otherwise the file in the user config directory
+func HistoryFile(file string) (string, error) {
+	if file == "" {
+		file = os.Getenv(HistoryFileEnv)
+	}
+	if file == "" {
+		return history.DefaultPath()
+	}
+	return filepath.Abs(file)
+}
+
+// fileGroupFlags are the optional file groups that can be enabled with a flag of the same name
+var fileGroupFlags = []struct {
+	name  string
+	usage string
+}{
+	{"augment", "Check Augment AI related files (.augment-guidelines, .augmentignore)"},
+	{"docker", "Check Docker related files (Dockerfile, docker-compose.yaml, .dockerignore)"},
+	{"typescript", "Check TypeScript/JavaScript related files (package.json, tsconfig.json)"},
+	{"devcontainer", "Check DevContainer related files (.devcontainer.json)"},
+	{"devenv", "Check DevEnv related files (devenv.nix)"},
+	{"github", "Check GitHub issue and pull request templates (.github/)"},
+	{"all", "Check all optional file groups"},
+}
+
+// addFileGroupFlags registers a flag for every optional file group on a subcommand flag set and
+// returns a function that converts the parsed flags into config options
+func addFileGroupFlags(fs *flag.FlagSet) func() []config.ConfigOption {
+	enabled := make([]*bool, len(fileGroupFlags))
+	for i, group := range fileGroupFlags {
+		enabled[i] = fs.Bool(group.name, false, group.usage)
+	}
+
+	return func() []config.ConfigOption {
+		var options []config.ConfigOption
+		for i, group := range fileGroupFlags {
+			if *enabled[i] {
+				options = append(options, config.WithFileGroup(group.name, true))
+			}
+		}
+		return options
+	}
+}
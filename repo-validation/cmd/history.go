@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/gitrepo"
+	"github.com/LarsArtmann/templates/repo-validation/internal/history"
+	"github.com/LarsArtmann/templates/repo-validation/internal/reporter"
+	"github.com/LarsArtmann/templates/repo-validation/internal/scan"
+	"github.com/charmbracelet/log"
+)
+
+// RunHistory parses the arguments of the history subcommand and reports trends, regressions and
+// time-to-fix from the recorded results
+func RunHistory(args []string) (err error) {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	historyFile := fs.String("history-file", "", "History file, defaults to $"+HistoryFileEnv+" or the user config directory")
+	repo := fs.String("repo", "", "Only report the repository with this name or path")
+	since := fs.String("since", "", "Only report records since a date (2006-01-02) or a duration ago (720h)")
+	format := fs.String("format", reporter.FormatTable, "Output format: table or json")
+	jsonOutput := fs.Bool("json", false, "Output results in JSON format, same as --format json")
+
+	if err := fs.Parse(args); err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+	if *jsonOutput {
+		*format = reporter.FormatJSON
+	}
+	defer func() {
+		err = withJSON(err, *format == reporter.FormatJSON)
+	}()
+	switch *format {
+	case reporter.FormatTable, reporter.FormatJSON:
+	default:
+		return errors.NewInvalidConfigError(fmt.Sprintf("unknown format %q, expected table or json", *format))
+	}
+	from, err := history.ParseSince(*since, time.Now())
+	if err != nil {
+		return errors.NewInvalidConfigError(fmt.Sprintf("invalid --since %q, expected a date like 2006-01-02 or a duration like 720h", *since))
+	}
+
+	file, err := HistoryFile(*historyFile)
+	if err != nil {
+		return errors.NewPathError(*historyFile, err)
+	}
+
+	records, err := history.Load(file)
+	if err != nil {
+		if stderrors.Is(err, history.ErrNoHistory) {
+			return errors.NewInvalidConfigError(err.Error())
+		}
+		return errors.NewFileAccessError(file, err)
+	}
+
+	records = history.Filter(records, *repo, from)
+	if len(records) == 0 {
+		return errors.NewInvalidConfigError("no records match --repo and --since")
+	}
+
+	return reporter.WriteHistory(os.Stdout, *format, history.Analyze(records))
+}
+
+// recordHistory appends the results of repositories that could be validated to the history
+// file, keyed by the commit HEAD points at in each repository
+func recordHistory(file string, results []scan.Result) error {
+	now := time.Now()
+	records := make([]history.Record, 0, len(results))
+	for _, r := range results {
+		if r.Error != nil {
+			continue
+		}
+		commit, err := gitrepo.Head(r.Path)
+		if err != nil {
+			log.Debug("Recording without commit", "repository", r.Path, "error", err)
+		}
+		records = append(records, history.NewRecord(r, commit, now))
+	}
+
+	if err := history.Append(file, records...); err != nil {
+		return errors.NewFileAccessError(file, err)
+	}
+	return nil
+}
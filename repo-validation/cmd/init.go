@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+	"github.com/LarsArtmann/templates/repo-validation/internal/setup"
+	"github.com/LarsArtmann/templates/repo-validation/internal/templates"
+	"github.com/charmbracelet/huh"
+)
+
+// groupTitles describe the optional file groups in the init form
+var groupTitles = map[string]string{
+	"augment":      "Augment AI (.augment-guidelines, .augmentignore)",
+	"docker":       "Docker (Dockerfile, docker-compose.yaml, .dockerignore)",
+	"typescript":   "TypeScript/JavaScript (package.json, tsconfig.json)",
+	"devcontainer": "DevContainer (.devcontainer.json)",
+	"devenv":       "DevEnv (devenv.nix)",
+	"github":       "GitHub issue and pull request templates (.github/)",
+}
+
+// RunInit parses the arguments of the init subcommand and bootstraps the policy file of a
+// repository with an interactive form
+func RunInit(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	path := fs.String("path", ".", "Path to the repository to create the policy file in")
+	accessible := fs.Bool("accessible", false, "Use plain prompts that work with screen readers")
+	var templateDirs StringList
+	fs.Var(&templateDirs, "template-dir", "Organisation template directory, used when fixing (repeatable)")
+
+	if err := fs.Parse(args); err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+	if fs.NArg() > 0 {
+		return errors.NewInvalidConfigError("usage: repo-validate init [--path <repo>]")
+	}
+
+	// The form needs a terminal to read from
+	fileInfo, _ := os.Stdin.Stat()
+	if (fileInfo.Mode() & os.ModeCharDevice) == 0 {
+		return errors.NewInvalidConfigError("init needs an interactive terminal, write .repo-validation.yaml by hand instead")
+	}
+
+	absPath, err := filepath.Abs(*path)
+	if err != nil {
+		return errors.NewPathError(*path, err)
+	}
+	stat, err := os.Stat(absPath)
+	if err != nil {
+		return errors.NewFileAccessError(absPath, err)
+	}
+	if !stat.IsDir() {
+		return errors.NewPathError(absPath, fmt.Errorf("path is not a directory"))
+	}
+
+	existing, err := policy.Load(absPath)
+	if err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+
+	answers := setup.Defaults(os.DirFS(absPath), existing)
+	write, fix := true, false
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title("Which optional file groups should be checked?").
+				Description("Groups found in the repository are preselected").
+				Options(groupOptions()...).
+				Value(&answers.Groups),
+			huh.NewSelect[string]().
+				Title("Which license does the repository use?").
+				Description("Used by the LICENSE.md and README.md templates").
+				Options(licenseOptions()...).
+				Height(8).
+				Value(&answers.License),
+		),
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title("Which files must be present?").
+				Description("Missing must-have files fail validation, the others are reported").
+				OptionsFunc(func() []huh.Option[string] {
+					return mustHaveOptions(existing, answers)
+				}, &answers.Groups).
+				Value(&answers.MustHave),
+		),
+		huh.NewGroup(
+			huh.NewNote().
+				Title("Requirements").
+				DescriptionFunc(func() string {
+					return previewRequirements(setup.Apply(existing, answers))
+				}, &answers),
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Write %s?", policy.FileName)).
+				Description("Comments in an existing policy file are not kept").
+				Value(&write),
+			huh.NewConfirm().
+				Title("Generate the missing files now?").
+				Value(&fix),
+		),
+	).WithAccessible(*accessible)
+
+	if err := form.Run(); err != nil {
+		if stderrors.Is(err, huh.ErrUserAborted) {
+			fmt.Println("Aborted, policy file not written")
+			return nil
+		}
+		return fmt.Errorf("error running form: %w", err)
+	}
+	if form.State != huh.StateCompleted || !write {
+		fmt.Println("Policy file not written")
+		return nil
+	}
+
+	pol := setup.Apply(existing, answers)
+	if err := setup.Write(absPath, pol); err != nil {
+		return errors.NewFileAccessError(filepath.Join(absPath, policy.FileName), err)
+	}
+	fmt.Printf("Wrote %s\n", filepath.Join(absPath, policy.FileName))
+
+	if !fix {
+		fmt.Println("Run repo-validate --fix to generate the missing files")
+		return nil
+	}
+	return Run(
+		config.WithRepoPath(absPath),
+		config.WithFix(true),
+		config.WithPolicy(pol),
+		config.WithTemplateDirs(append(templateDirs, TemplateDirsFromEnv()...)...),
+	)
+}
+
+// groupOptions returns the optional file groups as form options
+func groupOptions() []huh.Option[string] {
+	options := make([]huh.Option[string], 0, len(policy.GroupNames))
+	for _, group := range policy.GroupNames {
+		options = append(options, huh.NewOption(groupTitles[group], group))
+	}
+	return options
+}
+
+// licenseOptions returns the known SPDX licenses as form options
+func licenseOptions() []huh.Option[string] {
+	licenses := templates.Licenses()
+	options := make([]huh.Option[string], 0, len(licenses))
+	for _, license := range licenses {
+		options = append(options, huh.NewOption(fmt.Sprintf("%s (%s)", license.Name, license.ID), license.ID))
+	}
+	return options
+}
+
+// mustHaveOptions returns the requirements of the selected groups as form options, the
+// must-have requirements selected
+func mustHaveOptions(existing *policy.Policy, answers setup.Answers) []huh.Option[string] {
+	pol := setup.Apply(existing, setup.Answers{Groups: answers.Groups, License: answers.License})
+	var options []huh.Option[string]
+	for _, req := range setup.Requirements(pol) {
+		options = append(options, huh.NewOption(req.Path, req.Path).Selected(req.Priority == config.PriorityMustHave))
+	}
+	return options
+}
+
+// previewRequirements lists the requirements checked with a policy, by priority
+func previewRequirements(pol *policy.Policy) string {
+	reqs := setup.Requirements(pol)
+	var b strings.Builder
+	for _, priority := range policy.Priorities {
+		var paths []string
+		for _, req := range reqs {
+			if req.Priority != priority {
+				continue
+			}
+			if pol.Waiver(req.Path) != nil {
+				paths = append(paths, req.Path+" (waived)")
+			} else {
+				paths = append(paths, req.Path)
+			}
+		}
+		if len(paths) > 0 {
+			fmt.Fprintf(&b, "%s: %s\n", priority, strings.Join(paths, ", "))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/mcp"
+	"github.com/LarsArtmann/templates/repo-validation/internal/server"
+	"github.com/charmbracelet/log"
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// RunMCP parses the arguments of the mcp subcommand and offers validation and fixing as tools of
+// the Model Context Protocol over stdin and stdout, until the client disconnects. The version is
+// reported to clients.
+func RunMCP(args []string, version string) error {
+	fs := flag.NewFlagSet("mcp", flag.ContinueOnError)
+	var allow StringList
+	fs.Var(&allow, "allow", "Base directory tools may read and fix repositories below (repeatable), defaults to the current directory")
+	var templateDirs StringList
+	fs.Var(&templateDirs, "template-dir", "Organisation template directory (repeatable)")
+	fileGroups := addFileGroupFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+	if fs.NArg() > 0 {
+		return errors.NewInvalidConfigError("usage: repo-validate mcp [--allow <dir>]...")
+	}
+	if len(allow) == 0 {
+		allow = StringList{"."}
+	}
+
+	options := append(fileGroups(), config.WithTemplateDirs(append(templateDirs, TemplateDirsFromEnv()...)...))
+	api, err := server.NewServer(allow, server.WithConfigOptions(options...))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Stdout carries the protocol, the log goes to stderr
+	log.Debug("Serving validation tools over stdio", "allow", api.Roots)
+	if err := mcp.NewServer(api, version).Run(ctx, &sdk.StdioTransport{}); err != nil && !stderrors.Is(err, context.Canceled) {
+		return fmt.Errorf("error serving MCP: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/charmbracelet/huh"
+)
+
+// previewLines is the number of lines of each file shown in the preview
+const previewLines = 12
+
+// fixChoice is a fixable requirement with a preview of the files it generates
+type fixChoice struct {
+	result    checker.ValidationResult
+	changes   []checker.Change
+	variables []string
+	err       error
+}
+
+// PickFixes lets the user choose which fixable requirements to generate with a form, showing
+// what each one writes, and asks for the template variables the chosen files need but the
+// policy does not set. It returns the chosen results and sets the variables on the checker.
+// If the user aborts the form, the error wraps huh.ErrUserAborted.
+func PickFixes(cfg *config.Config, chk *checker.Checker, results []checker.ValidationResult) ([]checker.ValidationResult, error) {
+	if err := requireTerminal(cfg, "pick files to fix"); err != nil {
+		return nil, err
+	}
+
+	var choices []fixChoice
+	for _, result := range chk.Fixable(results) {
+		changes, variables, err := chk.Preview(result)
+		choices = append(choices, fixChoice{result: result, changes: changes, variables: variables, err: err})
+	}
+	if len(choices) == 0 {
+		return nil, nil
+	}
+
+	options := make([]huh.Option[string], 0, len(choices))
+	var selected []string
+	for _, choice := range choices {
+		req := choice.result.Requirement
+		options = append(options, huh.NewOption(fmt.Sprintf("%s (%s) %s", req.Path, req.Priority, req.Description), req.Path))
+		selected = append(selected, req.Path)
+	}
+
+	groups := []*huh.Group{
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title("Which files should be generated?").
+				Description("Missing and outdated files that have a template").
+				Options(options...).
+				Value(&selected),
+		),
+		huh.NewGroup(
+			huh.NewNote().
+				Title("Preview").
+				DescriptionFunc(func() string {
+					return previewChoices(choices, selected)
+				}, &selected),
+		),
+	}
+
+	// Ask for each variable only if a chosen file needs it
+	values := map[string]*string{}
+	for _, choice := range choices {
+		for _, name := range choice.variables {
+			if _, ok := values[name]; ok {
+				continue
+			}
+			value := new(string)
+			values[name] = value
+			groups = append(groups, huh.NewGroup(
+				huh.NewInput().
+					Title(fmt.Sprintf("Value of the template variable %s", name)).
+					Description("Set it in the variables of .repo-validation.yaml to skip this question").
+					Validate(func(s string) error {
+						if strings.TrimSpace(s) == "" {
+							return fmt.Errorf("%s is required by the chosen templates", name)
+						}
+						return nil
+					}).
+					Value(value),
+			).WithHideFunc(func() bool {
+				return !needsVariable(choices, selected, name)
+			}))
+		}
+	}
+
+	form := huh.NewForm(groups...)
+	if err := form.Run(); err != nil {
+		return nil, fmt.Errorf("error running form: %w", err)
+	}
+	if form.State != huh.StateCompleted {
+		return nil, fmt.Errorf("form was not completed: %w", huh.ErrUserAborted)
+	}
+
+	if chk.Variables == nil {
+		chk.Variables = map[string]string{}
+	}
+	for name, value := range values {
+		if needsVariable(choices, selected, name) {
+			chk.Variables[name] = *value
+		}
+	}
+
+	var picked []checker.ValidationResult
+	for _, choice := range choices {
+		if slices.Contains(selected, choice.result.Requirement.Path) {
+			picked = append(picked, choice.result)
+		}
+	}
+	return picked, nil
+}
+
+// needsVariable reports whether a chosen file needs a template variable
+func needsVariable(choices []fixChoice, selected []string, name string) bool {
+	for _, choice := range choices {
+		if slices.Contains(selected, choice.result.Requirement.Path) && slices.Contains(choice.variables, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// previewChoices shows the beginning of every file the chosen requirements generate
+func previewChoices(choices []fixChoice, selected []string) string {
+	var b strings.Builder
+	for _, choice := range choices {
+		if !slices.Contains(selected, choice.result.Requirement.Path) {
+			continue
+		}
+		if choice.err != nil {
+			fmt.Fprintf(&b, "%s: %v\n\n", choice.result.Requirement.Path, choice.err)
+			continue
+		}
+		for _, change := range choice.changes {
+			action := "create"
+			if !change.IsNew {
+				action = "update"
+			}
+			fmt.Fprintf(&b, "%s (%s)\n", change.Path, action)
+
+			lines := strings.Split(strings.TrimRight(string(change.New), "\n"), "\n")
+			for _, line := range lines[:min(len(lines), previewLines)] {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+			if len(lines) > previewLines {
+				fmt.Fprintf(&b, "  ... %d more lines\n", len(lines)-previewLines)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if b.Len() == 0 {
+		return "No files chosen"
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/learn"
+	"github.com/LarsArtmann/templates/repo-validation/internal/reporter"
+)
+
+// RunPolicy parses the arguments of the policy subcommand and executes it
+func RunPolicy(args []string) (err error) {
+	if len(args) == 0 || args[0] != "learn" {
+		return errors.NewInvalidConfigError("usage: repo-validate policy learn [--out <dir>] [--force] <golden-repo>")
+	}
+
+	fs := flag.NewFlagSet("policy learn", flag.ContinueOnError)
+	out := fs.String("out", ".", "Directory to write the policy file and templates to, laid out like a repository root")
+	force := fs.Bool("force", false, "Overwrite an existing policy file and templates")
+	jsonOutput := fs.Bool("json", false, "Output results in JSON format")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+	defer func() {
+		err = withJSON(err, *jsonOutput)
+	}()
+	if fs.NArg() != 1 {
+		return errors.NewInvalidConfigError("usage: repo-validate policy learn [--out <dir>] [--force] <golden-repo>")
+	}
+
+	golden, err := filepath.Abs(fs.Arg(0))
+	if err != nil {
+		return errors.NewPathError(fs.Arg(0), err)
+	}
+	stat, err := os.Stat(golden)
+	if err != nil {
+		return errors.NewFileAccessError(golden, err)
+	}
+	if !stat.IsDir() {
+		return errors.NewPathError(golden, fmt.Errorf("path is not a directory"))
+	}
+
+	result, err := learn.Learn(golden)
+	if err != nil {
+		return errors.NewFileAccessError(golden, err)
+	}
+
+	written, err := result.Write(*out, *force)
+	if err != nil {
+		if stderrors.Is(err, learn.ErrExists) {
+			return errors.NewInvalidConfigError(err.Error() + ", use --force to overwrite it")
+		}
+		return errors.NewFileAccessError(*out, err)
+	}
+
+	format := reporter.FormatTable
+	if *jsonOutput {
+		format = reporter.FormatJSON
+	}
+	return reporter.WriteLearned(os.Stdout, format, result, written)
+}
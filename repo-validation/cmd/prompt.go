@@ -9,17 +9,27 @@ import (
 	"github.com/LarsArtmann/templates/repo-validation/internal/config"
 )
 
-// PromptForMissingParameters prompts the user for missing parameters
-func PromptForMissingParameters(cfg *config.Config) error {
+// requireTerminal returns an error if the user cannot be prompted to do something, because
+// the output is JSON or stdin is not an interactive terminal
+func requireTerminal(cfg *config.Config, action string) error {
 	// Don't prompt in JSON mode
 	if cfg.JSONOutput {
-		return fmt.Errorf("cannot prompt for parameters in JSON output mode")
+		return fmt.Errorf("cannot %s in JSON output mode", action)
 	}
 
 	// Verify we're running in an interactive terminal
 	fileInfo, _ := os.Stdin.Stat()
 	if (fileInfo.Mode() & os.ModeCharDevice) == 0 {
-		return fmt.Errorf("cannot prompt for parameters in non-interactive mode")
+		return fmt.Errorf("cannot %s in non-interactive mode", action)
+	}
+
+	return nil
+}
+
+// PromptForMissingParameters prompts the user for missing parameters
+func PromptForMissingParameters(cfg *config.Config) error {
+	if err := requireTerminal(cfg, "prompt for parameters"); err != nil {
+		return err
 	}
 
 	reader := bufio.NewReader(os.Stdin)
@@ -75,15 +85,16 @@ func PromptForMissingParameters(cfg *config.Config) error {
 	}
 
 	// If no file groups are selected, prompt for which ones to check
-	if !cfg.CheckAugment && !cfg.CheckDocker && !cfg.CheckTypeScript && !cfg.CheckDevContainer && !cfg.CheckDevEnv {
+	if !cfg.CheckAugment && !cfg.CheckDocker && !cfg.CheckTypeScript && !cfg.CheckDevContainer && !cfg.CheckDevEnv && !cfg.CheckGitHub {
 		fmt.Println("Which file groups do you want to check?")
 		fmt.Println("1. Augment AI files (.augment-guidelines, .augmentignore)")
 		fmt.Println("2. Docker files (Dockerfile, docker-compose.yaml, .dockerignore)")
 		fmt.Println("3. TypeScript/JavaScript files (package.json, tsconfig.json)")
 		fmt.Println("4. DevContainer files (.devcontainer.json)")
 		fmt.Println("5. DevEnv files (devenv.nix)")
-		fmt.Println("6. All file groups")
-		fmt.Println("7. None (only check core files)")
+		fmt.Println("6. GitHub issue and pull request templates (.github/)")
+		fmt.Println("7. All file groups")
+		fmt.Println("8. None (only check core files)")
 		fmt.Print("Enter your choices (comma-separated, e.g., 1,3,5): ")
 
 		choice, err := reader.ReadString('\n')
@@ -99,7 +110,7 @@ func PromptForMissingParameters(cfg *config.Config) error {
 		}
 
 		choices := strings.Split(choice, ",")
-		validChoices := map[string]bool{"1": true, "2": true, "3": true, "4": true, "5": true, "6": true, "7": true}
+		validChoices := map[string]bool{"1": true, "2": true, "3": true, "4": true, "5": true, "6": true, "7": true, "8": true}
 		hasInvalidChoice := false
 		invalidChoices := []string{}
 
@@ -115,14 +126,15 @@ func PromptForMissingParameters(cfg *config.Config) error {
 				continue
 			}
 
-			// Check for option 6 (All) or 7 (None) first
-			if c == "6" {
+			// Check for option 7 (All) or 8 (None) first
+			if c == "7" {
 				// All file groups - reset any previously set flags
 				cfg.CheckAugment = false
 				cfg.CheckDocker = false
 				cfg.CheckTypeScript = false
 				cfg.CheckDevContainer = false
 				cfg.CheckDevEnv = false
+				cfg.CheckGitHub = false
 
 				// Set all flags to true
 				cfg.CheckAugment = true
@@ -130,10 +142,11 @@ func PromptForMissingParameters(cfg *config.Config) error {
 				cfg.CheckTypeScript = true
 				cfg.CheckDevContainer = true
 				cfg.CheckDevEnv = true
+				cfg.CheckGitHub = true
 
 				// Skip processing other options since we've selected ALL
 				break
-			} else if c == "7" {
+			} else if c == "8" {
 				// None (only check core files)
 				// Reset all flags to false
 				cfg.CheckAugment = false
@@ -141,6 +154,7 @@ func PromptForMissingParameters(cfg *config.Config) error {
 				cfg.CheckTypeScript = false
 				cfg.CheckDevContainer = false
 				cfg.CheckDevEnv = false
+				cfg.CheckGitHub = false
 
 				// Skip processing other options
 				break
@@ -157,12 +171,14 @@ func PromptForMissingParameters(cfg *config.Config) error {
 					cfg.CheckDevContainer = true
 				case "5":
 					cfg.CheckDevEnv = true
+				case "6":
+					cfg.CheckGitHub = true
 				}
 			}
 		}
 
 		if hasInvalidChoice {
-			return fmt.Errorf("invalid choices: %s (must be numbers between 1-7)", strings.Join(invalidChoices, ", "))
+			return fmt.Errorf("invalid choices: %s (must be numbers between 1-8)", strings.Join(invalidChoices, ", "))
 		}
 	}
 
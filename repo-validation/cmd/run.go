@@ -1,15 +1,26 @@
 package cmd
 
 import (
+	stderrors "errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/LarsArtmann/templates/repo-validation/internal/archivefs"
 	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
 	"github.com/LarsArtmann/templates/repo-validation/internal/config"
 	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
 	"github.com/LarsArtmann/templates/repo-validation/internal/exitcode"
+	"github.com/LarsArtmann/templates/repo-validation/internal/gitrepo"
+	"github.com/LarsArtmann/templates/repo-validation/internal/history"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
 	"github.com/LarsArtmann/templates/repo-validation/internal/reporter"
+	"github.com/LarsArtmann/templates/repo-validation/internal/scan"
+	"github.com/LarsArtmann/templates/repo-validation/internal/tui"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
 )
 
 // Run executes the main application logic
@@ -42,18 +53,54 @@ func Run(opts ...config.ConfigOption) error {
 		return errors.NewPathError(cfg.RepoPath, err)
 	}
 
-	// Check if the path exists and is a directory
+	// Check if the path exists and is a directory, or an archive
 	stat, err := os.Stat(absPath)
 	if err != nil {
 		return errors.NewFileAccessError(absPath, err)
 	}
-	if !stat.IsDir() {
-		return errors.NewPathError(absPath, fmt.Errorf("path is not a directory"))
+	if !stat.IsDir() && !cfg.IsArchive() {
+		return errors.NewPathError(absPath, fmt.Errorf("path is not a directory or a .tar, .tar.gz or .zip archive"))
 	}
 	cfg.RepoPath = absPath
 
+	// Read the repository from the working tree, an archive, or the tree of a revision
+	var repoFS fs.FS = os.DirFS(cfg.RepoPath)
+	var commit string
+	if cfg.IsArchive() {
+		archive, err := archivefs.Open(cfg.RepoPath)
+		if err != nil {
+			return errors.NewFileAccessError(cfg.RepoPath, err)
+		}
+		repoFS = archive
+	} else if cfg.Ref != "" {
+		tree, hash, err := gitrepo.OpenTree(cfg.RepoPath, cfg.Ref)
+		if err != nil {
+			return errors.NewPathError(cfg.RepoPath, err)
+		}
+		log.Debug("Validating revision", "ref", cfg.Ref, "commit", hash)
+		repoFS, commit = tree, hash
+	} else if cfg.HistoryFile != "" {
+		if commit, err = gitrepo.Head(cfg.RepoPath); err != nil {
+			log.Debug("Recording without commit", "error", err)
+		}
+	}
+
+	// Load the repository policy
+	if cfg.Policy == nil {
+		pol, err := policy.LoadFS(repoFS)
+		if err != nil {
+			return errors.NewInvalidConfigError(err.Error())
+		}
+		cfg.Policy = pol
+	}
+
 	// Create a checker
-	chk := checker.NewChecker(cfg)
+	chk := checker.NewCheckerFS(cfg, repoFS)
+
+	// Load the template packs referenced by the policy
+	if err := chk.LoadPacks(); err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
 
 	// Check the repository
 	results, err := chk.CheckRepository()
@@ -61,6 +108,16 @@ func Run(opts ...config.ConfigOption) error {
 		return fmt.Errorf("error checking repository: %w", err)
 	}
 
+	// Browse and fix the results in the dashboard, the results it last checked are reported
+	if cfg.TUI {
+		if err := requireTerminal(cfg, "show the dashboard"); err != nil {
+			return errors.NewInvalidConfigError(err.Error())
+		}
+		if results, err = tui.Run(chk, results); err != nil {
+			return fmt.Errorf("error running dashboard: %w", err)
+		}
+	}
+
 	// Create a reporter
 	rep := reporter.NewReporter(cfg)
 
@@ -69,12 +126,40 @@ func Run(opts ...config.ConfigOption) error {
 		return fmt.Errorf("error reporting results: %w", err)
 	}
 
-	// Fix missing files if requested
-	if cfg.Fix {
-		if err := chk.FixMissingFiles(results); err != nil {
+	// Check again whenever files change, until interrupted
+	if cfg.Watch {
+		return watchRepository(cfg, chk, rep, results)
+	}
+
+	// Let the user choose which files to fix
+	toFix := results
+	if cfg.Fix && cfg.Pick {
+		if toFix, err = PickFixes(cfg, chk, results); err != nil {
+			if stderrors.Is(err, huh.ErrUserAborted) {
+				fmt.Println("Aborted, no files fixed")
+				return nil
+			}
+			return errors.NewInvalidConfigError(err.Error())
+		}
+	}
+
+	// Preview the changes --fix would make instead of writing them
+	if cfg.Fix && (cfg.Diff || cfg.PatchFile != "") {
+		if err := previewFixes(cfg, chk, rep, toFix); err != nil {
+			return err
+		}
+	} else if cfg.Fix {
+		// Fix missing files
+		created, modified, err := chk.FixMissingFiles(toFix)
+		if err != nil {
 			return fmt.Errorf("error fixing missing files: %w", err)
 		}
 
+		// Report the files that were created or modified
+		if err := rep.ReportFixedFiles(created, modified); err != nil {
+			return fmt.Errorf("error reporting fixed files: %w", err)
+		}
+
 		// Check the repository again after fixing
 		results, err = chk.CheckRepository()
 		if err != nil {
@@ -90,6 +175,15 @@ func Run(opts ...config.ConfigOption) error {
 		}
 	}
 
+	// Record the final results in the history file
+	if cfg.HistoryFile != "" {
+		result := scan.NewResult(cfg.RepoPath, results)
+		result.Name = cfg.RepoName()
+		if err := history.Append(cfg.HistoryFile, history.NewRecord(result, commit, time.Now())); err != nil {
+			return errors.NewFileAccessError(cfg.HistoryFile, err)
+		}
+	}
+
 	// Use the reporter to determine if we should exit with an error
 	exitCode := rep.GetExitCode(results)
 	if exitCode != exitcode.Success {
@@ -104,3 +198,38 @@ func Run(opts ...config.ConfigOption) error {
 
 	return nil
 }
+
+// previewFixes renders the changes --fix would make and prints them as diffs, writes them to a
+// patch file, or both, without touching the working tree
+func previewFixes(cfg *config.Config, chk *checker.Checker, rep *reporter.Reporter, results []checker.ValidationResult) error {
+	changes, err := chk.PlanFixes(results)
+	if err != nil {
+		return fmt.Errorf("error planning fixes: %w", err)
+	}
+
+	// The lock file stamps are written along with the files, so they are previewed too
+	stamps, err := chk.PlanLock(changes)
+	if err != nil {
+		return fmt.Errorf("error planning fixes: %w", err)
+	}
+	if stamps != nil {
+		changes = append(changes, *stamps)
+	}
+
+	if cfg.Diff {
+		if err := rep.ReportChanges(changes); err != nil {
+			return fmt.Errorf("error reporting changes: %w", err)
+		}
+	}
+
+	if cfg.PatchFile != "" {
+		if err := os.WriteFile(cfg.PatchFile, []byte(checker.Patch(changes)), 0644); err != nil {
+			return errors.NewFileAccessError(cfg.PatchFile, err)
+		}
+		if !cfg.JSONOutput {
+			log.Info("Wrote patch, apply it with git apply", "path", cfg.PatchFile, "files", len(changes))
+		}
+	}
+
+	return nil
+}
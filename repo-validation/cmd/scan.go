@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/mail"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/gitrepo"
+	"github.com/LarsArtmann/templates/repo-validation/internal/metrics"
+	"github.com/LarsArtmann/templates/repo-validation/internal/reporter"
+	"github.com/LarsArtmann/templates/repo-validation/internal/scan"
+	"github.com/charmbracelet/log"
+)
+
+// RunScan parses the arguments of the scan subcommand and validates every repository below the
+// given roots, or listed in a file
+func RunScan(args []string) (err error) {
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	jobs := fs.Int("jobs", runtime.NumCPU(), "Number of repositories validated concurrently")
+	list := fs.String("list", "", "File listing repository paths, one per line, validated in addition to the roots")
+	format := fs.String("format", reporter.FormatTable, "Output format: table, json or csv")
+	jsonOutput := fs.Bool("json", false, "Output results in JSON format, same as --format json")
+	matrix := fs.String("matrix", "", "Write a compliance matrix instead of the report: markdown, html or csv")
+	sortBy := fs.String("sort", scan.SortPath, "Order repositories by path, name or score")
+	category := fs.String("category", "", "With --matrix, only show requirements of this category")
+	priority := fs.String("priority", "", "With --matrix, only show requirements of this priority")
+	fix := fs.Bool("fix", false, "Generate missing files and commit them to a new local branch in each repository, requires --branch")
+	branch := fs.String("branch", "", "With --fix, the local branch to commit to, repositories where it exists are skipped")
+	message := fs.String("message", scan.DefaultMessage, "With --fix, the commit message")
+	author := fs.String("author", "", "With --fix, the commit author as \"Name <email>\", defaults to the git user of each repository")
+	record := fs.Bool("record", false, "Append the results to the history file")
+	historyFile := fs.String("history-file", "", "With --record, the history file, defaults to $"+HistoryFileEnv+" or the user config directory")
+	metricsFile := fs.String("metrics-file", "", "Write Prometheus metrics to this file for the node exporter textfile collector, e.g. repo_validation.prom")
+	var templateDirs StringList
+	fs.Var(&templateDirs, "template-dir", "Organisation template directory (repeatable)")
+	fileGroups := addFileGroupFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+	if *jsonOutput {
+		*format = reporter.FormatJSON
+	}
+	defer func() {
+		err = withJSON(err, *format == reporter.FormatJSON)
+	}()
+	switch *format {
+	case reporter.FormatTable, reporter.FormatJSON, reporter.FormatCSV:
+	default:
+		return errors.NewInvalidConfigError(fmt.Sprintf("unknown format %q, expected table, json or csv", *format))
+	}
+	switch *matrix {
+	case "", reporter.FormatMarkdown, reporter.FormatHTML, reporter.FormatCSV:
+	default:
+		return errors.NewInvalidConfigError(fmt.Sprintf("unknown matrix format %q, expected markdown, html or csv", *matrix))
+	}
+	if err := scan.Sort(nil, *sortBy); err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+	if (*category != "" || *priority != "") && *matrix == "" {
+		return errors.NewInvalidConfigError("--category and --priority can only be used together with --matrix")
+	}
+	if *fix && *branch == "" {
+		return errors.NewInvalidConfigError("--fix needs --branch, scan never fixes the checked out branch")
+	}
+	if *fix && *matrix != "" {
+		return errors.NewInvalidConfigError("--fix and --matrix cannot be used together")
+	}
+	if *historyFile != "" && !*record {
+		return errors.NewInvalidConfigError("--history-file can only be used together with --record")
+	}
+	if *record && *fix {
+		return errors.NewInvalidConfigError("--record and --fix cannot be used together")
+	}
+	if *metricsFile != "" && *fix {
+		return errors.NewInvalidConfigError("--metrics-file and --fix cannot be used together")
+	}
+	remediation := scan.Remediation{Branch: *branch, Message: *message}
+	if *author != "" {
+		addr, err := mail.ParseAddress(*author)
+		if err != nil {
+			return errors.NewInvalidConfigError(fmt.Sprintf("invalid --author %q, expected \"Name <email>\"", *author))
+		}
+		remediation.Author = gitrepo.Author{Name: addr.Name, Email: addr.Address}
+	}
+	if *jobs < 1 {
+		return errors.NewInvalidConfigError("--jobs must be at least 1")
+	}
+	if fs.NArg() == 0 && *list == "" {
+		return errors.NewInvalidConfigError("scan needs at least one root directory or --list")
+	}
+
+	// Stop starting new repositories on Ctrl-C, those in progress finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var repos []string
+	if *list != "" {
+		listed, err := scan.ReadList(*list)
+		if err != nil {
+			return errors.NewFileAccessError(*list, err)
+		}
+		repos = append(repos, listed...)
+	}
+	for _, root := range fs.Args() {
+		found, err := scan.Discover(ctx, root)
+		if err != nil {
+			return errors.NewPathError(root, err)
+		}
+		repos = append(repos, found...)
+	}
+	repos = scan.Dedupe(repos)
+
+	options := append(fileGroups(), config.WithTemplateDirs(append(templateDirs, TemplateDirsFromEnv()...)...))
+	log.Debug("Scanning repositories", "repositories", len(repos), "jobs", *jobs)
+
+	scanner := scan.NewScanner(*jobs, options...)
+	if *fix {
+		return fixRepositories(ctx, scanner, repos, remediation, *format)
+	}
+
+	start := time.Now()
+	results, scanErr := scanner.Scan(ctx, repos)
+	duration := time.Since(start)
+	if err := scan.Sort(results, *sortBy); err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+
+	if *matrix != "" {
+		m := reporter.NewMatrix(results, reporter.WithCategory(*category), reporter.WithPriority(*priority))
+		if err := reporter.WriteMatrix(os.Stdout, *matrix, m); err != nil {
+			return fmt.Errorf("error reporting compliance matrix: %w", err)
+		}
+	} else if err := reporter.WriteScan(os.Stdout, *format, results); err != nil {
+		return fmt.Errorf("error reporting scan: %w", err)
+	}
+	if *record {
+		file, err := HistoryFile(*historyFile)
+		if err != nil {
+			return errors.NewPathError(*historyFile, err)
+		}
+		if err := recordHistory(file, results); err != nil {
+			return err
+		}
+	}
+	if *metricsFile != "" {
+		registry := metrics.NewRegistry()
+		registry.ObserveScan(results, duration, time.Now())
+		if err := registry.WriteFile(*metricsFile); err != nil {
+			return errors.NewFileAccessError(*metricsFile, err)
+		}
+	}
+	if scanErr != nil {
+		return fmt.Errorf("scan interrupted after %d of %d repositories: %w", len(results), len(repos), scanErr)
+	}
+
+	if _, failed, _ := scan.Summary(results); failed > 0 {
+		return errors.NewMissingMustHaveFilesError(fmt.Sprintf("%d of %d repositories failed", failed, len(results)))
+	}
+
+	return nil
+}
+
+// fixRepositories commits the fixes of every repository to a new branch and reports which
+// repositories changed and which were skipped
+func fixRepositories(ctx context.Context, scanner *scan.Scanner, repos []string, remediation scan.Remediation, format string) error {
+	results, fixErr := scanner.Fix(ctx, repos, remediation)
+	if err := reporter.WriteScanFixes(os.Stdout, format, results); err != nil {
+		return fmt.Errorf("error reporting fixes: %w", err)
+	}
+	if fixErr != nil {
+		return fmt.Errorf("scan interrupted after %d of %d repositories: %w", len(results), len(repos), fixErr)
+	}
+
+	if _, _, failed := scan.FixSummary(results); failed > 0 {
+		return fmt.Errorf("%d of %d repositories could not be fixed", failed, len(results))
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/server"
+	"github.com/charmbracelet/log"
+)
+
+// shutdownTimeout is how long requests in progress may take to finish when the server stops
+const shutdownTimeout = 10 * time.Second
+
+// RunServe parses the arguments of the serve subcommand and answers validation requests over
+// HTTP until interrupted
+func RunServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := fs.String("listen", "localhost:8080", "Address to listen on, e.g. :8080 for all interfaces")
+	timeout := fs.Duration("timeout", server.DefaultTimeout, "How long a request may take before it is answered with 503")
+	jobs := fs.Int("jobs", runtime.NumCPU(), "Number of repositories a scan validates concurrently")
+	var allow StringList
+	fs.Var(&allow, "allow", "Base directory requests may validate repositories below (repeatable), defaults to the current directory")
+	var templateDirs StringList
+	fs.Var(&templateDirs, "template-dir", "Organisation template directory (repeatable)")
+	fileGroups := addFileGroupFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+	if fs.NArg() > 0 {
+		return errors.NewInvalidConfigError("usage: repo-validate serve [--listen <addr>] [--allow <dir>]...")
+	}
+	if *timeout <= 0 {
+		return errors.NewInvalidConfigError("--timeout must be positive")
+	}
+	if *jobs < 1 {
+		return errors.NewInvalidConfigError("--jobs must be at least 1")
+	}
+	if len(allow) == 0 {
+		allow = StringList{"."}
+	}
+
+	options := append(fileGroups(), config.WithTemplateDirs(append(templateDirs, TemplateDirsFromEnv()...)...))
+	srv, err := server.NewServer(allow, server.WithTimeout(*timeout), server.WithJobs(*jobs), server.WithConfigOptions(options...))
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return errors.NewInvalidConfigError(fmt.Sprintf("cannot listen on %s: %v", *listen, err))
+	}
+
+	// Reading a request must not take longer than answering it
+	httpServer := &http.Server{
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       *timeout,
+		WriteTimeout:      *timeout + 5*time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- httpServer.Serve(listener)
+	}()
+	log.Info("Serving the validation API", "address", listener.Addr().String(), "allow", srv.Roots)
+
+	select {
+	case err := <-served:
+		return fmt.Errorf("error serving: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Info("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !stderrors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error shutting down: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+	"github.com/LarsArtmann/templates/repo-validation/internal/templates"
+)
+
+// TemplatesCommand represents the templates command
+type TemplatesCommand struct {
+	// Config is the configuration for the command
+	Config *config.Config
+	// Out is where the command writes its output
+	Out io.Writer
+}
+
+// NewTemplatesCommand creates a new TemplatesCommand
+func NewTemplatesCommand(cfg *config.Config, out io.Writer) *TemplatesCommand {
+	return &TemplatesCommand{
+		Config: cfg,
+		Out:    out,
+	}
+}
+
+// List prints every known template and the source that wins for it
+func (c *TemplatesCommand) List() error {
+	resolver := templates.NewResolver(templates.DefaultSources(c.Config.RepoPath, c.Config.TemplateDirs)...)
+
+	if c.Config.Policy != nil {
+		packs, err := templates.LoadPacks(c.Config.RepoPath, c.Config.Policy.Packs)
+		if err != nil {
+			return errors.NewInvalidConfigError(err.Error())
+		}
+		for _, pack := range packs {
+			resolver.AddPack(pack)
+		}
+	}
+
+	resolutions, err := resolver.List()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TEMPLATE\tSOURCE\tLOCATION\tOVERRIDES")
+	for _, res := range resolutions {
+		var shadowed []string
+		for _, src := range res.Shadowed {
+			shadowed = append(shadowed, src.Kind)
+		}
+		overrides := strings.Join(shadowed, ", ")
+		if overrides == "" {
+			overrides = "-"
+		}
+		name := res.Name
+		if res.Dir {
+			name += "/"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, res.Source.Kind, res.Source.Location, overrides)
+	}
+
+	return w.Flush()
+}
+
+// Eject copies a built-in template into the repository or user override directory
+func (c *TemplatesCommand) Eject(name string, user, force bool) error {
+	destDir := filepath.Join(c.Config.RepoPath, templates.RepoTemplateDir)
+	if user {
+		dir, err := templates.UserTemplateDir()
+		if err != nil {
+			return errors.NewPathError("user config directory", err)
+		}
+		destDir = dir
+	}
+
+	dest, err := templates.Eject(name, destDir, force)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Out, "Ejected %s to %s\n", templates.Normalize(name), dest)
+	return nil
+}
+
+// RunTemplates parses the arguments of the templates subcommand and executes it
+func RunTemplates(args []string) error {
+	if len(args) == 0 {
+		return errors.NewInvalidConfigError("usage: repo-validate templates <list|eject> [flags]")
+	}
+
+	fs := flag.NewFlagSet("templates "+args[0], flag.ContinueOnError)
+	repoPath := fs.String("path", ".", "Path to the repository")
+	var templateDirs StringList
+	fs.Var(&templateDirs, "template-dir", "Organisation template directory (repeatable)")
+	user := fs.Bool("user", false, "Eject into the user config directory instead of the repository")
+	force := fs.Bool("force", false, "Overwrite an existing template when ejecting")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+
+	absPath, err := filepath.Abs(*repoPath)
+	if err != nil {
+		return errors.NewPathError(*repoPath, err)
+	}
+
+	pol, err := policy.Load(absPath)
+	if err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+
+	cfg := &config.Config{RepoPath: absPath, Policy: pol}
+	config.WithTemplateDirs(append(templateDirs, TemplateDirsFromEnv()...)...)(cfg)
+
+	command := NewTemplatesCommand(cfg, os.Stdout)
+
+	switch args[0] {
+	case "list":
+		return command.List()
+	case "eject":
+		if fs.NArg() != 1 {
+			return errors.NewInvalidConfigError("usage: repo-validate templates eject [--user] [--force] <name>")
+		}
+		return command.Eject(fs.Arg(0), *user, *force)
+	default:
+		return errors.NewInvalidConfigError(fmt.Sprintf("unknown templates subcommand %q", args[0]))
+	}
+}
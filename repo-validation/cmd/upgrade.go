@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+	"github.com/LarsArtmann/templates/repo-validation/internal/reporter"
+)
+
+// RunUpgrade parses the arguments of the upgrade subcommand and executes it
+func RunUpgrade(args []string) (err error) {
+	fs := flag.NewFlagSet("upgrade", flag.ContinueOnError)
+	repoPath := fs.String("path", ".", "Path to the repository")
+	var templateDirs StringList
+	fs.Var(&templateDirs, "template-dir", "Organisation template directory (repeatable)")
+	dryRun := fs.Bool("dry-run", false, "Print the upgrades as a patch without writing them")
+	jsonOutput := fs.Bool("json", false, "Output results in JSON format")
+
+	if err := fs.Parse(args); err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+	defer func() {
+		err = withJSON(err, *jsonOutput)
+	}()
+
+	absPath, err := filepath.Abs(*repoPath)
+	if err != nil {
+		return errors.NewPathError(*repoPath, err)
+	}
+
+	pol, err := policy.Load(absPath)
+	if err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+
+	cfg := &config.Config{RepoPath: absPath, Policy: pol, JSONOutput: *jsonOutput, DryRun: *dryRun}
+	config.WithTemplateDirs(append(templateDirs, TemplateDirsFromEnv()...)...)(cfg)
+
+	chk := checker.NewChecker(cfg)
+	if err := chk.LoadPacks(); err != nil {
+		return errors.NewInvalidConfigError(err.Error())
+	}
+
+	upgrades, err := chk.PlanUpgrades()
+	if err != nil {
+		return fmt.Errorf("error planning upgrades: %w", err)
+	}
+
+	if *dryRun {
+		changes := make([]checker.Change, 0, len(upgrades))
+		for _, upgrade := range upgrades {
+			changes = append(changes, upgrade.Change)
+		}
+		if !*jsonOutput {
+			fmt.Print(checker.Patch(changes))
+		}
+	} else if err := chk.ApplyUpgrades(upgrades); err != nil {
+		return errors.NewFileAccessError(absPath, err)
+	}
+
+	if err := reporter.NewReporter(cfg).ReportUpgrades(upgrades); err != nil {
+		return err
+	}
+
+	conflicts := 0
+	for _, upgrade := range upgrades {
+		if upgrade.Conflicts > 0 {
+			conflicts++
+		}
+	}
+	if conflicts > 0 && !*dryRun {
+		return fmt.Errorf("%d files have merge conflicts, resolve the conflict markers and commit", conflicts)
+	}
+
+	return nil
+}
@@ -44,10 +44,16 @@ func (c *ValidateCommand) Execute() error {
 
 	// Fix missing files if requested
 	if c.Config.Fix && !c.Config.DryRun {
-		if err := chk.FixMissingFiles(results); err != nil {
+		created, modified, err := chk.FixMissingFiles(results)
+		if err != nil {
 			return fmt.Errorf("error fixing missing files: %w", err)
 		}
 
+		// Report the files that were created or modified
+		if err := rep.ReportFixedFiles(created, modified); err != nil {
+			return fmt.Errorf("error reporting fixed files: %w", err)
+		}
+
 		// Check the repository again after fixing
 		results, err = chk.CheckRepository()
 		if err != nil {
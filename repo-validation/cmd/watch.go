@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+	"github.com/LarsArtmann/templates/repo-validation/internal/reporter"
+	"github.com/LarsArtmann/templates/repo-validation/internal/watch"
+	"github.com/charmbracelet/log"
+)
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// maxChangedShown is the number of changed files listed below the results
+const maxChangedShown = 5
+
+// watchRepository checks the requirements affected by changed files again whenever files
+// below the repository change and reports the results in place, until interrupted
+func watchRepository(cfg *config.Config, chk *checker.Checker, rep *reporter.Reporter, results []checker.ValidationResult) error {
+	w, err := watch.New(cfg.RepoPath, watch.DefaultDebounce)
+	if err != nil {
+		return fmt.Errorf("error watching repository: %w", err)
+	}
+	defer w.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Only clear the screen when the results are shown in a terminal
+	fileInfo, _ := os.Stdout.Stat()
+	inPlace := (fileInfo.Mode() & os.ModeCharDevice) != 0
+
+	printWatching(cfg, nil)
+	err = w.Run(ctx, func(changed []string) error {
+		// A changed policy file changes the requirements and templates themselves
+		if slices.Contains(changed, policy.FileName) {
+			pol, err := policy.Load(cfg.RepoPath)
+			if err != nil {
+				log.Error("Keeping the previous policy", "error", err)
+			} else {
+				cfg.Policy = pol
+				chk = checker.NewChecker(cfg)
+				if err := chk.LoadPacks(); err != nil {
+					log.Error("Error loading template packs", "error", err)
+				}
+			}
+		}
+
+		rechecked, err := chk.Recheck(results, changed)
+		if err != nil {
+			log.Error("Error checking repository", "error", err)
+			return nil
+		}
+		results = rechecked
+
+		if inPlace {
+			fmt.Print(clearScreen)
+		} else {
+			fmt.Println()
+		}
+		if err := rep.ReportResults(results); err != nil {
+			return fmt.Errorf("error reporting results: %w", err)
+		}
+		printWatching(cfg, changed)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error watching repository: %w", err)
+	}
+
+	fmt.Println("\nStopped watching")
+	return nil
+}
+
+// printWatching prints what is watched and which files changed last
+func printWatching(cfg *config.Config, changed []string) {
+	fmt.Printf("\nWatching %s for changes, press Ctrl+C to stop\n", cfg.RepoPath)
+	if len(changed) == 0 {
+		return
+	}
+
+	shown := changed[:min(len(changed), maxChangedShown)]
+	more := ""
+	if len(changed) > maxChangedShown {
+		more = fmt.Sprintf(" and %d more", len(changed)-maxChangedShown)
+	}
+	fmt.Printf("Checked again at %s after changes to %s%s\n", time.Now().Format(time.TimeOnly), strings.Join(shown, ", "), more)
+}
@@ -0,0 +1,505 @@
+package archivefs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Archive formats
+const (
+	FormatTar   = "tar"
+	FormatTarGz = "tar.gz"
+	FormatZip   = "zip"
+)
+
+// maxFileSize is the largest archive member that will be loaded into memory
+var maxFileSize int64 = 64 << 20
+
+// maxTotalSize is the largest total size of the members of an archive
+var maxTotalSize int64 = 256 << 20
+
+// maxLinks is how many symbolic links are followed resolving a path, like the limit of the OS
+const maxLinks = 40
+
+// errOutside is the error of symbolic links that lead out of the archive
+var errOutside = errors.New("symbolic link points outside the archive")
+
+// Format returns the archive format of a path based on its extension, or "" if it is not an archive
+func Format(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return FormatTarGz
+	case strings.HasSuffix(lower, ".tar"):
+		return FormatTar
+	case strings.HasSuffix(lower, ".zip"):
+		return FormatZip
+	}
+	return ""
+}
+
+// IsArchive returns true if the path has a supported archive extension
+func IsArchive(name string) bool {
+	return Format(name) != ""
+}
+
+// TrimExt returns name without its archive extension
+func TrimExt(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(lower, ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// Open returns a read-only filesystem for a directory or a supported archive. Archives are
+// loaded into memory, and a single top-level directory shared by every member is stripped.
+func Open(name string) (fs.FS, error) {
+	stat, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if stat.IsDir() {
+		return os.DirFS(name), nil
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return Read(name, data)
+}
+
+// OpenFS is like Open, but reads the directory or archive from fsys
+func OpenFS(fsys fs.FS, name string) (fs.FS, error) {
+	stat, err := fs.Stat(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if stat.IsDir() {
+		return fs.Sub(fsys, name)
+	}
+
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return Read(name, data)
+}
+
+// Read loads an in-memory archive, using name to determine its format
+func Read(name string, data []byte) (fs.FS, error) {
+	var (
+		mfs *FS
+		err error
+	)
+
+	switch Format(name) {
+	case FormatTarGz:
+		var gz *gzip.Reader
+		gz, err = gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", name, err)
+		}
+		defer gz.Close()
+		mfs, err = readTar(gz)
+	case FormatTar:
+		mfs, err = readTar(bytes.NewReader(data))
+	case FormatZip:
+		mfs, err = readZip(data)
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", name, err)
+	}
+
+	return mfs.stripPrefix(), nil
+}
+
+func readTar(r io.Reader) (*FS, error) {
+	mfs := New()
+	tr := tar.NewReader(r)
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return mfs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			mfs.AddDir(hdr.Name, hdr.ModTime)
+		case tar.TypeReg:
+			if hdr.Size > maxFileSize {
+				return nil, fmt.Errorf("%s exceeds the maximum file size", hdr.Name)
+			}
+			data, err := readMember(tr, hdr.Name)
+			if err != nil {
+				return nil, err
+			}
+			if total += int64(len(data)); total > maxTotalSize {
+				return nil, fmt.Errorf("archive exceeds the maximum total size")
+			}
+			mfs.AddFile(hdr.Name, data, fs.FileMode(hdr.Mode).Perm(), hdr.ModTime)
+		case tar.TypeSymlink:
+			mfs.AddSymlink(hdr.Name, hdr.Linkname, hdr.ModTime)
+		case tar.TypeLink:
+			mfs.addHardLink(hdr.Name, hdr.Linkname, hdr.ModTime)
+		}
+	}
+}
+
+func readZip(data []byte) (*FS, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	mfs := New()
+	var total int64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			mfs.AddDir(f.Name, f.Modified)
+			continue
+		}
+		if f.UncompressedSize64 > uint64(maxFileSize) {
+			return nil, fmt.Errorf("%s exceeds the maximum file size", f.Name)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := readMember(rc, f.Name)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		if total += int64(len(content)); total > maxTotalSize {
+			return nil, fmt.Errorf("archive exceeds the maximum total size")
+		}
+		// The content of a symbolic link is its target
+		if f.Mode()&fs.ModeSymlink != 0 {
+			mfs.AddSymlink(f.Name, string(content), f.Modified)
+			continue
+		}
+		mfs.AddFile(f.Name, content, f.Mode().Perm(), f.Modified)
+	}
+
+	return mfs, nil
+}
+
+// readMember reads an archive member, failing once it exceeds the maximum file size whatever
+// size its header claims
+func readMember(r io.Reader, name string) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxFileSize {
+		return nil, fmt.Errorf("%s exceeds the maximum file size", name)
+	}
+	return data, nil
+}
+
+// entry is a file, directory or symbolic link held in memory
+type entry struct {
+	name    string
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+	// link is the target of a symbolic link, relative to the root
+	link string
+	// err is why a symbolic link cannot be followed
+	err error
+}
+
+// FS is a read-only in-memory filesystem
+type FS struct {
+	entries map[string]*entry
+}
+
+// New creates an empty in-memory filesystem
+func New() *FS {
+	return &FS{
+		entries: map[string]*entry{".": {name: ".", mode: fs.ModeDir | 0755}},
+	}
+}
+
+// clean normalises an archive member name, rejecting names that escape the root
+func clean(name string) (string, bool) {
+	name = path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimPrefix(name, "/")
+	if name == "" {
+		return ".", true
+	}
+	return name, fs.ValidPath(name)
+}
+
+// AddDir adds a directory, along with any missing parents
+func (m *FS) AddDir(name string, modTime time.Time) {
+	name, ok := clean(name)
+	if !ok {
+		return
+	}
+	for dir := name; dir != "."; dir = path.Dir(dir) {
+		if _, exists := m.entries[dir]; exists {
+			break
+		}
+		m.entries[dir] = &entry{name: dir, mode: fs.ModeDir | 0755, modTime: modTime}
+	}
+}
+
+// AddFile adds a regular file, along with any missing parent directories
+func (m *FS) AddFile(name string, data []byte, perm fs.FileMode, modTime time.Time) {
+	name, ok := clean(name)
+	if !ok || name == "." {
+		return
+	}
+	if perm == 0 {
+		perm = 0644
+	}
+	m.AddDir(path.Dir(name), modTime)
+	m.entries[name] = &entry{name: name, data: data, mode: perm, modTime: modTime}
+}
+
+// AddSymlink adds a symbolic link to target, which is relative to the directory of the link as
+// in an archive. Links that lead out of the filesystem cannot be followed.
+func (m *FS) AddSymlink(name, target string, modTime time.Time) {
+	name, ok := clean(name)
+	if !ok || name == "." {
+		return
+	}
+
+	e := &entry{name: name, mode: fs.ModeSymlink | 0777, modTime: modTime}
+	target = strings.ReplaceAll(target, "\\", "/")
+	if !path.IsAbs(target) {
+		target = path.Join(path.Dir(name), target)
+	}
+	if path.IsAbs(target) || target == ".." || strings.HasPrefix(target, "../") {
+		e.err = errOutside
+	} else {
+		e.link = target
+	}
+	m.AddDir(path.Dir(name), modTime)
+	m.entries[name] = e
+}
+
+// addHardLink adds a file sharing the content of an earlier member, as in a tar archive
+func (m *FS) addHardLink(name, target string, modTime time.Time) {
+	target, ok := clean(target)
+	if !ok {
+		return
+	}
+	if e, err := m.lookup("link", target); err == nil && e.mode.IsRegular() {
+		m.AddFile(name, e.data, e.mode.Perm(), modTime)
+	}
+}
+
+// stripPrefix removes a single top-level directory shared by every entry
+func (m *FS) stripPrefix() *FS {
+	var top []string
+	for name := range m.entries {
+		if name != "." && !strings.Contains(name, "/") {
+			top = append(top, name)
+		}
+	}
+	if len(top) != 1 || !m.entries[top[0]].mode.IsDir() {
+		return m
+	}
+
+	prefix := top[0] + "/"
+	stripped := New()
+	for name, e := range m.entries {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rel := strings.TrimPrefix(name, prefix)
+		switch {
+		case e.mode.IsDir():
+			stripped.AddDir(rel, e.modTime)
+		case e.mode&fs.ModeSymlink != 0:
+			link := *e
+			link.name = rel
+			// Links to the stripped directory itself lead to the new root
+			if link.link == top[0] {
+				link.link = "."
+			} else if link.err == nil {
+				link.link = strings.TrimPrefix(link.link, prefix)
+			}
+			stripped.AddDir(path.Dir(rel), e.modTime)
+			stripped.entries[rel] = &link
+		default:
+			stripped.AddFile(rel, e.data, e.mode, e.modTime)
+		}
+	}
+	return stripped
+}
+
+// lookup returns the entry of a path, following symbolic links
+func (m *FS) lookup(op, name string) (*entry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	resolved, err := m.resolve(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	e, ok := m.entries[resolved]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return e, nil
+}
+
+// resolve replaces the symbolic links in a path by their targets
+func (m *FS) resolve(name string) (string, error) {
+	for links := 0; ; links++ {
+		if links > maxLinks {
+			return "", errors.New("too many levels of symbolic links")
+		}
+		parts := strings.Split(name, "/")
+		followed := false
+		for i := range parts {
+			e, ok := m.entries[path.Join(parts[:i+1]...)]
+			if !ok || e.mode&fs.ModeSymlink == 0 {
+				continue
+			}
+			if e.err != nil {
+				return "", e.err
+			}
+			name = path.Join(append([]string{e.link}, parts[i+1:]...)...)
+			followed = true
+			break
+		}
+		if !followed {
+			return name, nil
+		}
+	}
+}
+
+// Open implements fs.FS
+func (m *FS) Open(name string) (fs.File, error) {
+	e, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if e.mode.IsDir() {
+		entries, _ := m.ReadDir(name)
+		return &dirFile{info: fileInfo{e, name}, entries: entries}, nil
+	}
+	return &file{info: fileInfo{e, name}, Reader: bytes.NewReader(e.data)}, nil
+}
+
+// ReadFile implements fs.ReadFileFS
+func (m *FS) ReadFile(name string) ([]byte, error) {
+	e, err := m.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if e.mode.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fmt.Errorf("is a directory")}
+	}
+	return bytes.Clone(e.data), nil
+}
+
+// Stat implements fs.StatFS
+func (m *FS) Stat(name string) (fs.FileInfo, error) {
+	e, err := m.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{e, name}, nil
+}
+
+// ReadDir implements fs.ReadDirFS
+func (m *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	e, err := m.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !e.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fmt.Errorf("not a directory")}
+	}
+
+	var entries []fs.DirEntry
+	for child, ce := range m.entries {
+		if child != "." && path.Dir(child) == e.name {
+			entries = append(entries, fs.FileInfoToDirEntry(fileInfo{ce, child}))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// fileInfo implements fs.FileInfo for an entry, named after the path it was looked up by
+type fileInfo struct {
+	e    *entry
+	name string
+}
+
+func (fi fileInfo) Name() string       { return path.Base(fi.name) }
+func (fi fileInfo) Size() int64        { return int64(len(fi.e.data)) }
+func (fi fileInfo) Mode() fs.FileMode  { return fi.e.mode }
+func (fi fileInfo) ModTime() time.Time { return fi.e.modTime }
+func (fi fileInfo) IsDir() bool        { return fi.e.mode.IsDir() }
+func (fi fileInfo) Sys() any           { return nil }
+
+// file is an open regular file
+type file struct {
+	*bytes.Reader
+	info fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return nil }
+
+// dirFile is an open directory
+type dirFile struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dirFile) Close() error               { return nil }
+
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.e.name, Err: fmt.Errorf("is a directory")}
+}
+
+// ReadDir implements fs.ReadDirFile
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
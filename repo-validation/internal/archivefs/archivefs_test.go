@@ -0,0 +1,342 @@
+package archivefs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// buildTar creates a tar archive with the given files, optionally gzip-compressed
+func buildTar(t *testing.T, files map[string]string, compress bool) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	}
+
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write tar content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatalf("Failed to close gzip writer: %v", err)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// buildZip creates a zip archive with the given files
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create zip entry: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write zip content: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close zip writer: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestFormat(t *testing.T) {
+	tests := map[string]string{
+		"release.tar":    FormatTar,
+		"release.tar.gz": FormatTarGz,
+		"release.TGZ":    FormatTarGz,
+		"release.zip":    FormatZip,
+		"README.md":      "",
+	}
+
+	for name, want := range tests {
+		if got := Format(name); got != want {
+			t.Errorf("Format(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestTrimExt(t *testing.T) {
+	tests := map[string]string{
+		"project-1.0.tar.gz": "project-1.0",
+		"project-1.0.TGZ":    "project-1.0",
+		"project.tar":        "project",
+		"project.zip":        "project",
+		"project":            "project",
+	}
+
+	for name, want := range tests {
+		if got := TrimExt(name); got != want {
+			t.Errorf("TrimExt(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	files := map[string]string{
+		"project-1.0/README.md":        "readme",
+		"project-1.0/docs/SECURITY.md": "security",
+	}
+
+	tests := []struct {
+		name string
+		file string
+		data []byte
+	}{
+		{name: "tar", file: "project.tar", data: buildTar(t, files, false)},
+		{name: "tar.gz", file: "project.tar.gz", data: buildTar(t, files, true)},
+		{name: "zip", file: "project.zip", data: buildZip(t, files)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys, err := Read(tt.file, tt.data)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			content, err := fs.ReadFile(fsys, "README.md")
+			if err != nil {
+				t.Fatalf("Expected top-level directory to be stripped, got %v", err)
+			}
+			if string(content) != "readme" {
+				t.Errorf("Expected content %q, got %q", "readme", content)
+			}
+
+			if err := fstest.TestFS(fsys, "README.md", "docs/SECURITY.md"); err != nil {
+				t.Errorf("Expected a valid fs.FS, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReadKeepsMultipleTopLevelEntries(t *testing.T) {
+	fsys, err := Read("flat.zip", buildZip(t, map[string]string{
+		"README.md":      "readme",
+		"docs/README.md": "docs",
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := fs.Stat(fsys, "docs/README.md"); err != nil {
+		t.Errorf("Expected docs/README.md to exist, got %v", err)
+	}
+}
+
+func TestReadRejectsEscapingPaths(t *testing.T) {
+	fsys, err := Read("evil.tar", buildTar(t, map[string]string{
+		"../../etc/passwd": "root",
+		"README.md":        "readme",
+	}, false))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := fs.Stat(fsys, "etc/passwd"); err != nil {
+		t.Errorf("Expected escaping path to be confined to the root, got %v", err)
+	}
+}
+
+func TestReadRejectsLargeFiles(t *testing.T) {
+	defer func(size int64) { maxFileSize = size }(maxFileSize)
+	maxFileSize = 8
+
+	files := map[string]string{"README.md": "readme", "LICENSE.md": "a license longer than the limit"}
+	for name, data := range map[string][]byte{
+		"pack.tar": buildTar(t, files, false),
+		"pack.zip": buildZip(t, files),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Read(name, data)
+			if err == nil || !strings.Contains(err.Error(), "LICENSE.md exceeds the maximum file size") {
+				t.Errorf("Expected LICENSE.md to exceed the maximum file size, got %v", err)
+			}
+		})
+	}
+
+	t.Run("unchecked header", func(t *testing.T) {
+		// A member larger than its header claims is cut off at the limit as well
+		if _, err := readMember(strings.NewReader("a license longer than the limit"), "LICENSE.md"); err == nil {
+			t.Error("Expected an error for a member over the limit, got nil")
+		}
+		data, err := readMember(strings.NewReader("readme"), "README.md")
+		if err != nil || string(data) != "readme" {
+			t.Errorf("Expected readme, got %q (%v)", data, err)
+		}
+	})
+}
+
+func TestReadLinks(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	members := []tar.Header{
+		{Name: "project/COPYING", Typeflag: tar.TypeReg, Size: 7, Mode: 0644},
+		{Name: "project/guide/index.md", Typeflag: tar.TypeReg, Size: 7, Mode: 0644},
+		{Name: "project/LICENSE.md", Typeflag: tar.TypeSymlink, Linkname: "COPYING"},
+		{Name: "project/NOTICE", Typeflag: tar.TypeLink, Linkname: "project/COPYING"},
+		{Name: "project/docs", Typeflag: tar.TypeSymlink, Linkname: "guide"},
+		{Name: "project/README.md", Typeflag: tar.TypeSymlink, Linkname: "docs/index.md"},
+		{Name: "project/SECURITY.md", Typeflag: tar.TypeSymlink, Linkname: "../../etc/passwd"},
+		{Name: "project/CODEOWNERS", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+		{Name: "project/CHANGELOG.md", Typeflag: tar.TypeSymlink, Linkname: "missing.md"},
+		{Name: "project/loop", Typeflag: tar.TypeSymlink, Linkname: "loop"},
+	}
+	for _, hdr := range members {
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if hdr.Size > 0 {
+			tw.Write([]byte("content"))
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+
+	fsys, err := Read("project.tar", buf.Bytes())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, name := range []string{"LICENSE.md", "NOTICE", "docs/index.md", "README.md"} {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil || string(content) != "content" {
+			t.Errorf("Expected %s to resolve to its target, got %q (%v)", name, content, err)
+		}
+	}
+	if info, err := fs.Stat(fsys, "LICENSE.md"); err != nil || info.Name() != "LICENSE.md" || !info.Mode().IsRegular() {
+		t.Errorf("Expected LICENSE.md to be a regular file named after the link, got %v (%v)", info, err)
+	}
+	if info, err := fs.Stat(fsys, "docs"); err != nil || !info.IsDir() {
+		t.Errorf("Expected docs to be a directory, got %v (%v)", info, err)
+	}
+
+	if _, err := fs.Stat(fsys, "CHANGELOG.md"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a dangling link to be missing, got %v", err)
+	}
+	for _, name := range []string{"SECURITY.md", "CODEOWNERS", "loop"} {
+		if _, err := fs.Stat(fsys, name); err == nil || errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected an error other than a missing file for %s, got %v", name, err)
+		}
+	}
+
+	t.Run("zip", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, _ := zw.Create("README.md")
+		w.Write([]byte("readme"))
+		hdr := &zip.FileHeader{Name: "LICENSE.md"}
+		hdr.SetMode(fs.ModeSymlink | 0777)
+		w, _ = zw.CreateHeader(hdr)
+		w.Write([]byte("README.md"))
+		zw.Close()
+
+		fsys, err := Read("project.zip", buf.Bytes())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if content, err := fs.ReadFile(fsys, "LICENSE.md"); err != nil || string(content) != "readme" {
+			t.Errorf("Expected LICENSE.md to resolve to README.md, got %q (%v)", content, err)
+		}
+	})
+}
+
+func TestReadRejectsLargeArchives(t *testing.T) {
+	defer func(size int64) { maxTotalSize = size }(maxTotalSize)
+	maxTotalSize = 10
+
+	files := map[string]string{"README.md": "readme", "LICENSE.md": "license"}
+	for name, data := range map[string][]byte{
+		"pack.tar": buildTar(t, files, false),
+		"pack.zip": buildZip(t, files),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Read(name, data); err == nil || !strings.Contains(err.Error(), "maximum total size") {
+				t.Errorf("Expected the archive to exceed the maximum total size, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "pack.tgz")
+	if err := os.WriteFile(archive, buildTar(t, map[string]string{"a.txt": "a"}, true), 0644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+
+	if _, err := Open(archive); err != nil {
+		t.Errorf("Expected archive to open, got %v", err)
+	}
+	if _, err := Open(dir); err != nil {
+		t.Errorf("Expected directory to open, got %v", err)
+	}
+	if _, err := Open(filepath.Join(dir, "missing.zip")); err == nil {
+		t.Errorf("Expected error for missing archive, got nil")
+	}
+
+	plain := filepath.Join(dir, "plain.txt")
+	if err := os.WriteFile(plain, []byte("x"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := Open(plain); err == nil {
+		t.Errorf("Expected error for unsupported format, got nil")
+	}
+}
+
+func TestOpenFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dist/project-1.0.zip": {Data: buildZip(t, map[string]string{"project-1.0/README.md": "readme"})},
+		"templates/a.tmpl":     {Data: []byte("a")},
+	}
+
+	archive, err := OpenFS(fsys, "dist/project-1.0.zip")
+	if err != nil {
+		t.Fatalf("Expected archive to open, got %v", err)
+	}
+	if data, err := fs.ReadFile(archive, "README.md"); err != nil || string(data) != "readme" {
+		t.Errorf("Expected README.md with the prefix stripped, got %q (%v)", data, err)
+	}
+
+	dir, err := OpenFS(fsys, "templates")
+	if err != nil {
+		t.Fatalf("Expected directory to open, got %v", err)
+	}
+	if _, err := fs.Stat(dir, "a.tmpl"); err != nil {
+		t.Errorf("Expected a.tmpl in directory, got %v", err)
+	}
+
+	if _, err := OpenFS(fsys, "missing.zip"); err == nil {
+		t.Errorf("Expected error for missing archive, got nil")
+	}
+}
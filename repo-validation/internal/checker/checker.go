@@ -3,11 +3,16 @@ package checker
 import (
 	"bytes"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
-	"text/template"
 
 	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/gitrepo"
+	"github.com/LarsArtmann/templates/repo-validation/internal/lock"
+	"github.com/LarsArtmann/templates/repo-validation/internal/managed"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
 	"github.com/LarsArtmann/templates/repo-validation/internal/templates"
 )
 
@@ -17,27 +22,143 @@ type ValidationResult struct {
 	Requirement config.FileRequirement
 	// Exists indicates whether the file exists
 	Exists bool
+	// Outdated indicates the managed block of an existing file does not match its template
+	Outdated bool
+	// Git is the git status of the file, "" if the repository is not a git working tree
+	Git string
+	// MissingEntries are the entries the content rule of the requirement expects but the
+	// file does not contain
+	MissingEntries []string
+	// Drift compares generated files with the template they were rendered from, "" if the
+	// files were not generated by --fix
+	Drift string
+	// Placeholder indicates the file exists but still contains the placeholder it was generated
+	// with, so it counts as missing
+	Placeholder bool
+	// Waived indicates the file is missing, but the policy exempts the repository from it
+	Waived bool
 	// Error is any error that occurred during validation
 	Error error
 }
 
+// Statuses of a validation result
+const (
+	StatusPresent = "present"
+	StatusMissing = "missing"
+	StatusWaived  = "waived"
+	// StatusError means the file could not be validated
+	StatusError = "error"
+)
+
+// Status returns whether the file is present, missing, waived or could not be validated
+func (r ValidationResult) Status() string {
+	switch {
+	case r.Error != nil:
+		return StatusError
+	case r.Exists:
+		return StatusPresent
+	case r.Waived:
+		return StatusWaived
+	}
+	return StatusMissing
+}
+
 // Checker is responsible for checking if files exist in a repository
 type Checker struct {
 	// Config is the configuration for the checker
 	Config *config.Config
+	// Templates resolves templates used to generate missing files
+	Templates *templates.Resolver
+	// Lock records the templates generated files were rendered from
+	Lock *lock.Lock
+	// Git reads the index and ignore files, nil if the repository is not a git working tree
+	Git *gitrepo.Repo
+	// FS is the tree of the repository that is validated
+	FS fs.FS
+	// Variables are template variables in addition to those of the policy, such as values
+	// entered interactively, they take precedence over the policy variables
+	Variables map[string]string
+	// Roots are the directories template packs outside the repository must be below, packs
+	// may be anywhere if empty
+	Roots []string
+
+	// root is the repository directory held open by NewCheckerRoot
+	root *os.Root
 }
 
-// NewChecker creates a new Checker
+// NewChecker creates a new Checker for the repository on disk
 func NewChecker(cfg *config.Config) *Checker {
+	return NewCheckerFS(cfg, os.DirFS(cfg.RepoPath))
+}
+
+// NewCheckerFS creates a new Checker that reads the repository from fsys, such as the tree of
+// a git revision
+func NewCheckerFS(cfg *config.Config, fsys fs.FS) *Checker {
 	return &Checker{
-		Config: cfg,
+		Config:    cfg,
+		Templates: templates.NewResolver(templates.DefaultSourcesFS(fsys, cfg.RepoPath, cfg.TemplateDirs)...),
+		FS:        fsys,
+	}
+}
+
+// NewCheckerRoot creates a new Checker for the repository on disk that does not follow
+// symlinks out of the repository. The repository stays open until Close is called.
+func NewCheckerRoot(cfg *config.Config) (*Checker, error) {
+	root, err := os.OpenRoot(cfg.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewCheckerFS(cfg, root.FS())
+	c.root = root
+	return c, nil
+}
+
+// Close releases the repository opened by NewCheckerRoot, it does nothing for other checkers
+func (c *Checker) Close() error {
+	if c.root == nil {
+		return nil
+	}
+	return c.root.Close()
+}
+
+// LoadPacks loads the template packs referenced by the policy into the template registry
+func (c *Checker) LoadPacks() error {
+	if c.Config.Policy == nil {
+		return nil
 	}
+
+	packs, err := templates.LoadPacksFS(c.FS, c.Config.RepoPath, c.Config.Policy.Packs, c.Roots...)
+	if err != nil {
+		return err
+	}
+
+	for _, pack := range packs {
+		c.Templates.AddPack(pack)
+	}
+
+	return nil
 }
 
 // CheckRepository checks if all required files exist in the repository
 func (c *Checker) CheckRepository() ([]ValidationResult, error) {
 	var results []ValidationResult
 
+	lck, err := lock.LoadFS(c.FS)
+	if err != nil {
+		return nil, err
+	}
+	c.Lock = lck
+
+	// Revisions and archives have no index or untracked files to report
+	if !c.Config.ReadOnly() {
+		repo, err := gitrepo.Open(c.Config.RepoPath)
+		if err != nil {
+			return nil, err
+		}
+		c.Git = repo
+	}
+
 	// Check all files using the consolidated list based on configuration
 	allRequirements := config.GetAllFileRequirements(c.Config)
 	for _, req := range allRequirements {
@@ -48,11 +169,37 @@ func (c *Checker) CheckRepository() ([]ValidationResult, error) {
 	return results, nil
 }
 
-// checkFile checks if a file exists in the repository
+// checkFile checks if a file exists in the repository and satisfies its content rule
 func (c *Checker) checkFile(req config.FileRequirement) ValidationResult {
-	filePath := filepath.Join(c.Config.RepoPath, req.Path)
+	result := c.checkExists(req)
+
+	if result.Exists && result.Error == nil && c.Git != nil {
+		c.checkGit(&result)
+	}
+
+	if result.Exists && result.Error == nil {
+		c.checkPlaceholder(&result)
+	}
+
+	if result.Exists && result.Error == nil && req.ContentRule != "" {
+		result.MissingEntries, result.Error = c.checkContent(req)
+	}
+
+	if !result.Exists && result.Error == nil {
+		result.Waived = c.Config.Policy.Waiver(req.Path) != nil
+	}
+
+	return result
+}
 
-	_, err := os.Stat(filePath)
+// checkExists checks if a file exists in the repository
+func (c *Checker) checkExists(req config.FileRequirement) ValidationResult {
+	// Directory templates are only satisfied when every file of the tree exists
+	if name := c.templateFor(req); name != "" && c.Templates.IsDir(name) {
+		return c.checkTree(req, name)
+	}
+
+	_, err := c.stat(req.Path)
 	exists := !os.IsNotExist(err)
 
 	if err != nil && !os.IsNotExist(err) {
@@ -63,81 +210,192 @@ func (c *Checker) checkFile(req config.FileRequirement) ValidationResult {
 		}
 	}
 
-	return ValidationResult{
+	// Managed files must also contain the current rendering of their template
+	if exists && req.Managed {
+		if name := c.templateFor(req); name != "" {
+			return c.checkManaged(req, name)
+		}
+	}
+
+	result := ValidationResult{
 		Requirement: req,
 		Exists:      exists,
 		Error:       nil,
 	}
+	if exists {
+		result.Drift, result.Error = c.driftOf(req)
+	}
+
+	return result
 }
 
-// FixMissingFiles generates missing files based on templates
-func (c *Checker) FixMissingFiles(results []ValidationResult) error {
-	if c.Config.DryRun {
-		return nil
+// checkGit sets the git status of an existing file, which counts as missing if it is
+// ignored, or untracked when the policy says so
+func (c *Checker) checkGit(result *ValidationResult) {
+	req := result.Requirement
+
+	paths := []string{req.Path}
+	if name := c.templateFor(req); name != "" && c.Templates.IsDir(name) {
+		treePaths, err := c.Templates.TreePaths(name, c.templateData())
+		if err != nil {
+			result.Error = fmt.Errorf("error checking directory %s: %w", req.Path, err)
+			return
+		}
+		paths = paths[:0]
+		for _, p := range treePaths {
+			paths = append(paths, path.Join(filepath.ToSlash(req.Path), p))
+		}
 	}
 
-	for _, result := range results {
-		if !result.Exists && result.Error == nil && result.Requirement.TemplatePath != "" {
-			if err := c.generateFile(result.Requirement); err != nil {
-				return fmt.Errorf("error generating file %s: %w", result.Requirement.Path, err)
-			}
+	for _, p := range paths {
+		stat, err := c.stat(p)
+		if err != nil {
+			result.Error = fmt.Errorf("error checking file %s: %w", p, err)
+			return
+		}
+
+		status, err := c.Git.Status(p, stat.IsDir())
+		if err != nil {
+			result.Error = fmt.Errorf("error checking git status of %s: %w", p, err)
+			return
+		}
+		if result.Git == "" || status == gitrepo.Ignored || (status == gitrepo.Untracked && result.Git == gitrepo.Tracked) {
+			result.Git = status
 		}
 	}
 
-	return nil
+	untrackedMissing := c.Config.Policy != nil && c.Config.Policy.Git.Untracked == policy.UntrackedMissing
+	if result.Git == gitrepo.Ignored || (result.Git == gitrepo.Untracked && untrackedMissing) {
+		result.Exists = false
+	}
 }
 
-// generateFile generates a file from a template
-func (c *Checker) generateFile(req config.FileRequirement) error {
-	// Skip if no template path is provided
-	if req.TemplatePath == "" {
-		return nil
+// checkPlaceholder sets whether an existing file still contains the placeholder of a generated
+// file, which counts as missing until the content is added by hand
+func (c *Checker) checkPlaceholder(result *ValidationResult) {
+	req := result.Requirement
+
+	stat, err := c.stat(req.Path)
+	if err != nil {
+		result.Error = fmt.Errorf("error checking file %s: %w", req.Path, err)
+		return
+	}
+	if stat.IsDir() {
+		return
 	}
 
-	// Extract the template filename from the path
-	templateFilename := filepath.Base(req.TemplatePath)
+	content, err := c.readFile(req.Path)
+	if err != nil {
+		result.Error = fmt.Errorf("error reading file %s: %w", req.Path, err)
+		return
+	}
+	if bytes.Contains(content, []byte(templates.PlaceholderMarker)) {
+		result.Exists = false
+		result.Placeholder = true
+	}
+}
 
-	// Read the template from the embedded filesystem
-	templateContent, err := templates.TemplateFS.ReadFile(templateFilename)
+// checkManaged checks if the managed block of a file matches the rendered template
+func (c *Checker) checkManaged(req config.FileRequirement, templateName string) ValidationResult {
+	content, err := c.readFile(req.Path)
 	if err != nil {
-		// Try to read from the filesystem as a fallback
-		templatePath := filepath.Join(filepath.Dir(os.Args[0]), req.TemplatePath)
-		templateContent, err = os.ReadFile(templatePath)
-		if err != nil {
-			return fmt.Errorf("error reading template %s: %w", req.TemplatePath, err)
+		return ValidationResult{
+			Requirement: req,
+			Exists:      true,
+			Error:       fmt.Errorf("error reading file %s: %w", req.Path, err),
 		}
 	}
 
-	// Parse template
-	tmpl, err := template.New(req.Path).Parse(string(templateContent))
+	// Files without markers were written by hand and are left alone, only files with a managed
+	// block are kept up to date
+	if _, found, err := managed.Content(content); err == nil && !found {
+		return ValidationResult{Requirement: req, Exists: true}
+	}
+
+	body, _, err := c.Templates.Render(templateName, c.templateData())
 	if err != nil {
-		return fmt.Errorf("error parsing template %s: %w", req.TemplatePath, err)
+		return ValidationResult{
+			Requirement: req,
+			Exists:      true,
+			Error:       fmt.Errorf("error rendering template for %s: %w", req.Path, err),
+		}
 	}
 
-	// Prepare template data
-	data := map[string]interface{}{
-		"RepoName": filepath.Base(c.Config.RepoPath),
-		// Add more variables as needed
+	matches, err := managed.Matches(content, body)
+	if err != nil {
+		return ValidationResult{
+			Requirement: req,
+			Exists:      true,
+			Error:       fmt.Errorf("error reading managed block of %s: %w", req.Path, err),
+		}
 	}
 
-	// Render template to buffer
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return fmt.Errorf("error executing template %s: %w", req.TemplatePath, err)
+	return ValidationResult{Requirement: req, Exists: true, Outdated: !matches}
+}
+
+// checkTree checks if every file of a directory template exists under the requirement path
+func (c *Checker) checkTree(req config.FileRequirement, templateName string) ValidationResult {
+	paths, err := c.Templates.TreePaths(templateName, c.templateData())
+	if err != nil {
+		return ValidationResult{
+			Requirement: req,
+			Exists:      false,
+			Error:       fmt.Errorf("error checking directory %s: %w", req.Path, err),
+		}
 	}
 
-	// Create the output file
-	outputPath := filepath.Join(c.Config.RepoPath, req.Path)
+	for _, p := range paths {
+		if _, err := c.stat(path.Join(filepath.ToSlash(req.Path), p)); err != nil {
+			if os.IsNotExist(err) {
+				return ValidationResult{Requirement: req, Exists: false}
+			}
+			return ValidationResult{
+				Requirement: req,
+				Exists:      false,
+				Error:       fmt.Errorf("error checking file %s: %w", path.Join(req.Path, p), err),
+			}
+		}
+	}
 
-	// Ensure the directory exists
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return fmt.Errorf("error creating directory for %s: %w", req.Path, err)
+	drift, err := c.driftOf(req)
+	return ValidationResult{Requirement: req, Exists: true, Drift: drift, Error: err}
+}
+
+// templateFor returns the name of the template used to generate a requirement, or ""
+func (c *Checker) templateFor(req config.FileRequirement) string {
+	return c.Templates.TemplateFor(req.Path, req.TemplatePath)
+}
+
+// templateData returns the variables available to templates
+func (c *Checker) templateData() map[string]interface{} {
+	data := map[string]interface{}{
+		"RepoName": c.Config.RepoName(),
+		"Stacks":   c.stacks(),
 	}
 
-	// Write the file
-	if err := os.WriteFile(outputPath, buf.Bytes(), 0644); err != nil {
-		return fmt.Errorf("error writing file %s: %w", req.Path, err)
+	if c.Config.Policy != nil {
+		for key, value := range c.Config.Policy.Variables {
+			data[key] = value
+		}
+	}
+	for key, value := range c.Variables {
+		data[key] = value
 	}
 
-	return nil
+	return data
+}
+
+// stat returns the file info of a path relative to the repository root
+func (c *Checker) stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(c.FS, fsPath(name))
+}
+
+// readFile reads a file relative to the repository root
+func (c *Checker) readFile(name string) ([]byte, error) {
+	return fs.ReadFile(c.FS, fsPath(name))
+}
+
+// fsPath converts a path relative to the repository root into an fs.FS path
+func fsPath(name string) string {
+	return path.Clean(filepath.ToSlash(name))
 }
@@ -3,9 +3,20 @@ package checker
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/LarsArtmann/templates/repo-validation/internal/archivefs"
 	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/gitrepo"
+	"github.com/LarsArtmann/templates/repo-validation/internal/lock"
+	"github.com/LarsArtmann/templates/repo-validation/internal/managed"
+	"github.com/LarsArtmann/templates/repo-validation/internal/patch"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+	"github.com/LarsArtmann/templates/repo-validation/internal/templates"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 // setupTestDir creates a temporary directory with test files
@@ -121,10 +132,13 @@ func TestFixMissingFiles(t *testing.T) {
 		}
 
 		// Fix the missing files
-		err := chk.FixMissingFiles(results)
+		created, _, err := chk.FixMissingFiles(results)
 		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
+		if len(created) != 0 {
+			t.Errorf("Expected no files to be created, got %v", created)
+		}
 
 		// The file should not be created since there's no template
 		contributingPath := filepath.Join(tempDir, "CONTRIBUTING.md")
@@ -187,3 +201,793 @@ func TestCheckFile(t *testing.T) {
 	// since the behavior of os.Stat on directories varies by OS.
 	// Instead, we'll test the normal cases thoroughly.
 }
+
+func TestFixMissingFilesFromPack(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(tempDir)
+
+	// Create a template pack providing CONTRIBUTING.md
+	packDir := filepath.Join(tempDir, "packs", "acme")
+	content := "Contribute to {{.RepoName}} at {{.Org}}\n"
+	manifest := "name: acme\nversion: 1.0.0\ntemplates:\n  - name: CONTRIBUTING.md.tmpl\n    requirements: [CONTRIBUTING.md]\n    variables: [Org]\n    sha256: " + templates.Checksum([]byte(content)) + "\n"
+	if err := os.MkdirAll(packDir, 0755); err != nil {
+		t.Fatalf("Failed to create pack directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(packDir, templates.ManifestName), []byte(manifest), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(packDir, "CONTRIBUTING.md.tmpl"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	results := []ValidationResult{
+		{
+			Requirement: config.FileRequirement{Path: "CONTRIBUTING.md", Priority: config.PriorityShouldHave},
+			Exists:      false,
+		},
+	}
+
+	t.Run("missing pack variable", func(t *testing.T) {
+		cfg := &config.Config{
+			RepoPath: tempDir,
+			Fix:      true,
+			Policy:   &policy.Policy{Packs: []policy.PackRef{{Path: "packs/acme"}}},
+		}
+		chk := NewChecker(cfg)
+		if err := chk.LoadPacks(); err != nil {
+			t.Fatalf("Expected no error loading packs, got %v", err)
+		}
+
+		if _, _, err := chk.FixMissingFiles(results); err == nil {
+			t.Errorf("Expected error for missing variable Org, got nil")
+		}
+	})
+
+	t.Run("pack template rendered", func(t *testing.T) {
+		cfg := &config.Config{
+			RepoPath: tempDir,
+			Fix:      true,
+			Policy: &policy.Policy{
+				Packs:     []policy.PackRef{{Path: "packs/acme", Version: "1.0.0"}},
+				Variables: map[string]string{"Org": "Acme"},
+			},
+		}
+		chk := NewChecker(cfg)
+		if err := chk.LoadPacks(); err != nil {
+			t.Fatalf("Expected no error loading packs, got %v", err)
+		}
+
+		if _, _, err := chk.FixMissingFiles(results); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		generated, err := os.ReadFile(filepath.Join(tempDir, "CONTRIBUTING.md"))
+		if err != nil {
+			t.Fatalf("Expected CONTRIBUTING.md to be generated, got %v", err)
+		}
+		want := "Contribute to " + filepath.Base(tempDir) + " at Acme\n"
+		if string(generated) != want {
+			t.Errorf("Expected %q, got %q", want, generated)
+		}
+	})
+}
+
+func TestFixMissingFilesDirectoryTemplate(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(tempDir)
+
+	// A hand-written bug report form must be left alone
+	existing := filepath.Join(tempDir, ".github", "ISSUE_TEMPLATE", "bug_report.yml")
+	if err := os.MkdirAll(filepath.Dir(existing), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(existing, []byte("custom"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	cfg := &config.Config{
+		RepoPath:    tempDir,
+		Fix:         true,
+		CheckGitHub: true,
+	}
+	chk := NewChecker(cfg)
+
+	result := chk.checkFile(config.GetGitHubFiles()[0])
+	if result.Exists || result.Error != nil {
+		t.Fatalf("Expected partially present .github to be missing without error, got exists=%v error=%v", result.Exists, result.Error)
+	}
+
+	created, _, err := chk.FixMissingFiles([]ValidationResult{result})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := []string{
+		".github/ISSUE_TEMPLATE/config.yml",
+		".github/ISSUE_TEMPLATE/feature_request.yml",
+		".github/pull_request_template.md",
+	}
+	if strings.Join(created, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected created files %v, got %v", want, created)
+	}
+
+	content, err := os.ReadFile(existing)
+	if err != nil || string(content) != "custom" {
+		t.Errorf("Expected existing file to be left alone, got %q (%v)", content, err)
+	}
+
+	if result := chk.checkFile(config.GetGitHubFiles()[0]); !result.Exists {
+		t.Errorf("Expected .github to be complete after fixing")
+	}
+}
+
+func TestPlanFixes(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(tempDir)
+
+	cfg := &config.Config{
+		RepoPath: tempDir,
+		Fix:      true,
+		Diff:     true,
+	}
+	chk := NewChecker(cfg)
+
+	results, err := chk.CheckRepository()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	changes, err := chk.PlanFixes(results)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// .gitignore was written by hand without a managed block and lacks no required entry, so
+	// it is left alone
+	var paths []string
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+		if !change.IsNew {
+			t.Errorf("Expected %s to be a new file", change.Path)
+		}
+	}
+	if want := "SECURITY.md,.editorconfig"; strings.Join(paths, ",") != want {
+		t.Errorf("Expected changes for %s, got %v", want, paths)
+	}
+
+	// Planning must not touch the working tree
+	if _, err := os.Stat(filepath.Join(tempDir, "SECURITY.md")); !os.IsNotExist(err) {
+		t.Errorf("Expected SECURITY.md not to be written while planning")
+	}
+
+	diff := Patch(changes)
+	if !strings.Contains(diff, "diff --git a/SECURITY.md b/SECURITY.md\nnew file mode 100644\n--- /dev/null\n+++ b/SECURITY.md\n@@ -0,0 +1,") {
+		t.Errorf("Expected a git-style patch for SECURITY.md, got:\n%s", diff)
+	}
+
+	// The lock file stamps are planned like the files, so previews show what --fix writes
+	stamps, err := chk.PlanLock(changes)
+	if err != nil || stamps == nil {
+		t.Fatalf("Expected a lock file change, got %v (%v)", stamps, err)
+	}
+	if stamps.Path != lock.FileName || !stamps.IsNew || !strings.Contains(string(stamps.New), "SECURITY.md:") {
+		t.Errorf("Expected a new lock file stamping SECURITY.md, got %+v", stamps)
+	}
+	if _, err := chk.ApplyChanges(changes); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	written, err := os.ReadFile(filepath.Join(tempDir, lock.FileName))
+	if err != nil || string(written) != string(stamps.New) {
+		t.Errorf("Expected the planned lock file to be written, got %q (%v)", written, err)
+	}
+	if stamps, err := chk.PlanLock(changes); err != nil || stamps.IsNew || string(stamps.Old) != string(written) {
+		t.Errorf("Expected the existing lock file to be modified, got %+v (%v)", stamps, err)
+	}
+	if stamps, err := chk.PlanLock(nil); err != nil || stamps != nil {
+		t.Errorf("Expected no lock file change without stamps, got %+v (%v)", stamps, err)
+	}
+}
+
+func TestManagedBlocks(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(tempDir)
+
+	gitignore := filepath.Join(tempDir, ".gitignore")
+	original := "# Project specific\n/tmp/\n\n" + managed.Begin + "\n*.exe\n" + managed.End + "\n\n# After\n*.bak\n"
+	if err := os.WriteFile(gitignore, []byte(original), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	cfg := &config.Config{RepoPath: tempDir, Fix: true}
+	chk := NewChecker(cfg)
+	req := config.GetGeneralMustHaveFiles()[1]
+
+	result := chk.checkFile(req)
+	if !result.Exists || !result.Outdated || result.Error != nil {
+		t.Fatalf("Expected .gitignore to exist with an outdated block, got exists=%v outdated=%v error=%v", result.Exists, result.Outdated, result.Error)
+	}
+
+	created, modified, err := chk.FixMissingFiles([]ValidationResult{result})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(created) != 0 || strings.Join(modified, ",") != ".gitignore" {
+		t.Errorf("Expected only .gitignore to be modified, got created=%v modified=%v", created, modified)
+	}
+
+	content, err := os.ReadFile(gitignore)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "# Project specific\n/tmp/\n\n"+managed.Begin+"\n") {
+		t.Errorf("Expected content before the block to be kept, got:\n%s", content)
+	}
+	if !strings.HasSuffix(string(content), managed.End+"\n\n# After\n*.bak\n") {
+		t.Errorf("Expected content after the block to be kept, got:\n%s", content)
+	}
+	if !strings.Contains(string(content), ".DS_Store\n") {
+		t.Errorf("Expected the block to contain the template, got:\n%s", content)
+	}
+
+	if result := chk.checkFile(req); result.Outdated || result.Error != nil {
+		t.Errorf("Expected managed block to be current after fixing, got outdated=%v error=%v", result.Outdated, result.Error)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(tempDir)
+
+	override := filepath.Join(tempDir, templates.RepoTemplateDir, "CONTRIBUTING.md.tmpl")
+	if err := os.MkdirAll(filepath.Dir(override), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(override, []byte("# Contributing to {{ .Org }}/{{ .RepoName }}\n\nAsk {{ .Team }}.\n"), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	req := config.FileRequirement{Path: "CONTRIBUTING.md", Priority: config.PriorityShouldHave, TemplatePath: "CONTRIBUTING.md.tmpl"}
+	chk := NewChecker(&config.Config{RepoPath: tempDir, Fix: true, Policy: &policy.Policy{Variables: map[string]string{"Team": "platform"}}})
+
+	results := []ValidationResult{{Requirement: req}, {Requirement: config.FileRequirement{Path: "README.md"}, Exists: true}}
+	fixable := chk.Fixable(results)
+	if len(fixable) != 1 || fixable[0].Requirement.Path != "CONTRIBUTING.md" {
+		t.Fatalf("Expected only CONTRIBUTING.md to be fixable, got %v", fixable)
+	}
+
+	changes, missing, err := chk.Preview(fixable[0])
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(missing) != 1 || missing[0] != "Org" {
+		t.Errorf("Expected Org to be missing, got %v", missing)
+	}
+	if len(changes) != 1 || !strings.HasPrefix(string(changes[0].New), "# Contributing to <Org>/") {
+		t.Errorf("Expected a placeholder for Org, got %v", changes)
+	}
+
+	// Entered variables are used when fixing
+	chk.Variables = map[string]string{"Org": "acme"}
+	if _, _, err := chk.FixMissingFiles(fixable); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tempDir, "CONTRIBUTING.md"))
+	if err != nil || !strings.HasPrefix(string(content), "# Contributing to acme/") || !strings.Contains(string(content), "Ask platform.") {
+		t.Errorf("Expected the entered variable to be rendered, got %q (%v)", content, err)
+	}
+}
+
+func TestDriftAndUpgrade(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(tempDir)
+
+	// Generate CONTRIBUTING.md from a repository override
+	override := filepath.Join(tempDir, templates.RepoTemplateDir, "CONTRIBUTING.md.tmpl")
+	if err := os.MkdirAll(filepath.Dir(override), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	writeTemplate := func(content string) {
+		if err := os.WriteFile(override, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+	}
+	writeTemplate("# Contributing\n\nOpen an issue.\n\nBe nice.\n")
+
+	req := config.FileRequirement{Path: "CONTRIBUTING.md", Priority: config.PriorityShouldHave, TemplatePath: "CONTRIBUTING.md.tmpl"}
+	chk := NewChecker(&config.Config{RepoPath: tempDir, Fix: true})
+	if _, _, err := chk.FixMissingFiles([]ValidationResult{{Requirement: req}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Templates that do not come from a pack are versioned by a hash of their content
+	lck, err := lock.Load(tempDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entry := lck.Files["CONTRIBUTING.md"]; entry.Source != templates.SourceRepo || !strings.HasPrefix(entry.Version, "sha256:") {
+		t.Errorf("Expected CONTRIBUTING.md to be stamped with a template version, got %+v", entry)
+	}
+
+	checkDrift := func(want string) {
+		t.Helper()
+		if _, err := chk.CheckRepository(); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result := chk.checkFile(req); result.Drift != want || result.Error != nil {
+			t.Errorf("Expected drift %q, got %q (%v)", want, result.Drift, result.Error)
+		}
+	}
+	checkDrift(lock.DriftCurrent)
+
+	// Edit the file, then improve the template
+	file := filepath.Join(tempDir, "CONTRIBUTING.md")
+	if err := os.WriteFile(file, []byte("# Contributing\n\nOpen an issue.\n\nBe nice.\n\nAsk Jo for reviews.\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	checkDrift(lock.DriftEdited)
+
+	writeTemplate("# Contributing\n\nOpen an issue first.\n\nBe nice.\n")
+	checkDrift(lock.DriftDiverged)
+
+	upgrades, err := chk.PlanUpgrades()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(upgrades) != 1 || upgrades[0].Conflicts != 0 {
+		t.Fatalf("Expected one clean upgrade, got %+v", upgrades)
+	}
+	if err := chk.ApplyUpgrades(upgrades); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	content, _ := os.ReadFile(file)
+	if want := "# Contributing\n\nOpen an issue first.\n\nBe nice.\n\nAsk Jo for reviews.\n"; string(content) != want {
+		t.Errorf("Expected merged content %q, got %q", want, content)
+	}
+	checkDrift(lock.DriftEdited)
+
+	// A conflicting template change leaves markers
+	writeTemplate("# Contributing\n\nOpen an issue first.\n\nBe kind.\n")
+	if err := os.WriteFile(file, []byte("# Contributing\n\nOpen an issue first.\n\nBe very nice.\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	upgrades, err = chk.PlanUpgrades()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(upgrades) != 1 || upgrades[0].Conflicts != 1 {
+		t.Fatalf("Expected one conflicting upgrade, got %+v", upgrades)
+	}
+	if !strings.Contains(string(upgrades[0].New), patch.MarkerOurs+"\nBe very nice.\n"+patch.MarkerBase+"\nBe kind.\n"+patch.MarkerTheirs) {
+		t.Errorf("Expected conflict markers, got:\n%s", upgrades[0].New)
+	}
+}
+
+func TestGitignoreEntries(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(tempDir)
+
+	// A Node project whose hand-written .gitignore and overridden template both lack
+	// node_modules
+	if err := os.WriteFile(filepath.Join(tempDir, "package.json"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	gitignore := filepath.Join(tempDir, ".gitignore")
+	if err := os.WriteFile(gitignore, []byte("/.env\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	override := filepath.Join(tempDir, templates.RepoTemplateDir, ".gitignore.tmpl")
+	if err := os.MkdirAll(filepath.Dir(override), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(override, []byte("*.log\n"), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	chk := NewChecker(&config.Config{RepoPath: tempDir, Fix: true})
+	req := config.GetGeneralMustHaveFiles()[1]
+
+	result := chk.checkFile(req)
+	if result.Error != nil || strings.Join(result.MissingEntries, ",") != "node_modules/" {
+		t.Fatalf("Expected node_modules/ to be missing, got %v (%v)", result.MissingEntries, result.Error)
+	}
+	if result.Outdated {
+		t.Errorf("Expected a .gitignore without markers not to be outdated")
+	}
+
+	if _, _, err := chk.FixMissingFiles([]ValidationResult{result}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	content, err := os.ReadFile(gitignore)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	want := "/.env\n\n# Required by repo-validation\nnode_modules/\n"
+	if string(content) != want {
+		t.Errorf("Expected only node_modules/ to be appended, without a managed block, got:\n%s", content)
+	}
+
+	if result := chk.checkFile(req); len(result.MissingEntries) != 0 || result.Outdated {
+		t.Errorf("Expected .gitignore to be complete after fixing, got missing=%v outdated=%v", result.MissingEntries, result.Outdated)
+	}
+}
+
+func TestHandWrittenManagedFiles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(tempDir)
+
+	editorconfig := filepath.Join(tempDir, ".editorconfig")
+	original := "root = true\n\n[*]\nindent_style = tab\n"
+	if err := os.WriteFile(editorconfig, []byte(original), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	chk := NewChecker(&config.Config{RepoPath: tempDir, Fix: true})
+	req := config.GetGeneralShouldHaveFiles()[2]
+
+	result := chk.checkFile(req)
+	if req.Path != ".editorconfig" || !req.Managed || !result.Exists || result.Outdated || result.Error != nil {
+		t.Fatalf("Expected the hand-written %s to be current, got exists=%v outdated=%v error=%v", req.Path, result.Exists, result.Outdated, result.Error)
+	}
+
+	changes, err := chk.PlanFixes([]ValidationResult{result, {Requirement: req, Exists: true, Outdated: true}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Expected a file without markers never to be rewritten, got %d changes", len(changes))
+	}
+}
+
+func TestGitStatus(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(tempDir)
+
+	repo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("Failed to init repository: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, ".gitignore"), []byte("LICENSE.md\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to open worktree: %v", err)
+	}
+	if _, err := wt.Add(".gitignore"); err != nil {
+		t.Fatalf("Failed to add .gitignore: %v", err)
+	}
+
+	statuses := func(untracked string) map[string]ValidationResult {
+		chk := NewChecker(&config.Config{RepoPath: tempDir, Policy: &policy.Policy{Git: policy.GitPolicy{Untracked: untracked}}})
+		results, err := chk.CheckRepository()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		byPath := map[string]ValidationResult{}
+		for _, result := range results {
+			byPath[result.Requirement.Path] = result
+		}
+		return byPath
+	}
+
+	t.Run("untracked counts as present", func(t *testing.T) {
+		results := statuses("")
+		if r := results[".gitignore"]; r.Git != gitrepo.Tracked || !r.Exists {
+			t.Errorf("Expected .gitignore to be tracked and present, got %s %v", r.Git, r.Exists)
+		}
+		if r := results["README.md"]; r.Git != gitrepo.Untracked || !r.Exists {
+			t.Errorf("Expected README.md to be untracked and present, got %s %v", r.Git, r.Exists)
+		}
+		if r := results["LICENSE.md"]; r.Git != gitrepo.Ignored || r.Exists {
+			t.Errorf("Expected LICENSE.md to be ignored and missing, got %s %v", r.Git, r.Exists)
+		}
+	})
+
+	t.Run("untracked counts as missing", func(t *testing.T) {
+		results := statuses(policy.UntrackedMissing)
+		if r := results["README.md"]; r.Git != gitrepo.Untracked || r.Exists {
+			t.Errorf("Expected README.md to be untracked and missing, got %s %v", r.Git, r.Exists)
+		}
+
+		// Fixing must not overwrite files that only need to be added to git
+		chk := NewChecker(&config.Config{RepoPath: tempDir, Fix: true})
+		changes, err := chk.PlanFixes([]ValidationResult{results["README.md"], results["LICENSE.md"]})
+		if err != nil || len(changes) != 0 {
+			t.Errorf("Expected no changes for untracked and ignored files, got %v (%v)", changes, err)
+		}
+	})
+}
+
+func TestCheckRevision(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(tempDir)
+
+	repo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("Failed to init repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to open worktree: %v", err)
+	}
+	for _, name := range []string{"README.md", "LICENSE.md"} {
+		if _, err := wt.Add(name); err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+	}
+	sig := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Unix(1700000000, 0)}
+	if _, err := wt.Commit("initial", &git.CommitOptions{Author: sig, Committer: sig}); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	// The working tree no longer matches the commit
+	if err := os.Remove(filepath.Join(tempDir, "README.md")); err != nil {
+		t.Fatalf("Failed to remove README.md: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "SECURITY.md"), []byte("test content"), 0644); err != nil {
+		t.Fatalf("Failed to write SECURITY.md: %v", err)
+	}
+
+	bareDir := filepath.Join(t.TempDir(), "project.git")
+	if _, err := git.PlainClone(bareDir, true, &git.CloneOptions{URL: tempDir}); err != nil {
+		t.Fatalf("Failed to clone repository: %v", err)
+	}
+
+	for name, repoPath := range map[string]string{"worktree": tempDir, "bare": bareDir} {
+		t.Run(name, func(t *testing.T) {
+			tree, _, err := gitrepo.OpenTree(repoPath, "HEAD")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			cfg := &config.Config{RepoPath: repoPath, Ref: "HEAD", Fix: true, Diff: true}
+			chk := NewCheckerFS(cfg, tree)
+			results, err := chk.CheckRepository()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			byPath := map[string]ValidationResult{}
+			for _, result := range results {
+				byPath[result.Requirement.Path] = result
+			}
+			if r := byPath["README.md"]; !r.Exists || r.Git != "" {
+				t.Errorf("Expected committed README.md to exist without git status, got %v %q", r.Exists, r.Git)
+			}
+			if r := byPath["SECURITY.md"]; r.Exists {
+				t.Errorf("Expected uncommitted SECURITY.md to be missing")
+			}
+
+			// Changes to a revision can be planned but not written
+			changes, err := chk.PlanFixes(results)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(changes) == 0 {
+				t.Fatalf("Expected changes for the missing files, got none")
+			}
+			if _, err := chk.ApplyChanges(changes); err == nil {
+				t.Errorf("Expected an error writing changes to a revision")
+			}
+		})
+	}
+
+	if name := (&config.Config{RepoPath: bareDir}).RepoName(); name != "project" {
+		t.Errorf("Expected repository name project, got %s", name)
+	}
+}
+
+func TestCheckArchive(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	archive := archivefs.New()
+	archive.AddFile("README.md", []byte("readme"), 0644, time.Time{})
+	archive.AddFile("LICENSE.md", []byte("license"), 0644, time.Time{})
+
+	cfg := &config.Config{RepoPath: filepath.Join(t.TempDir(), "project-1.0.tar.gz"), Fix: true, Diff: true}
+	if !cfg.IsArchive() || !cfg.ReadOnly() {
+		t.Fatalf("Expected an archive path to be read-only")
+	}
+	if name := cfg.RepoName(); name != "project-1.0" {
+		t.Errorf("Expected repository name project-1.0, got %s", name)
+	}
+
+	chk := NewCheckerFS(cfg, archive)
+	results, err := chk.CheckRepository()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, result := range results {
+		switch result.Requirement.Path {
+		case "README.md", "LICENSE.md":
+			if !result.Exists {
+				t.Errorf("Expected %s in the archive to exist", result.Requirement.Path)
+			}
+		case "SECURITY.md":
+			if result.Exists {
+				t.Errorf("Expected SECURITY.md to be missing from the archive")
+			}
+		}
+		if result.Git != "" {
+			t.Errorf("Expected no git status for %s, got %s", result.Requirement.Path, result.Git)
+		}
+	}
+
+	changes, err := chk.PlanFixes(results)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := chk.ApplyChanges(changes); err == nil {
+		t.Errorf("Expected an error writing changes to an archive")
+	}
+}
+
+func TestWaivers(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(tempDir)
+
+	pol := &policy.Policy{Waivers: []policy.Waiver{
+		{Path: "SECURITY.md", Reason: "not a public project"},
+		{Path: "README.md", Reason: "waivers of existing files have no effect"},
+	}}
+	chk := NewChecker(&config.Config{RepoPath: tempDir, Policy: pol})
+	results, err := chk.CheckRepository()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var waived []ValidationResult
+	for _, result := range results {
+		if result.Waived {
+			waived = append(waived, result)
+		}
+	}
+	if len(waived) != 1 || waived[0].Requirement.Path != "SECURITY.md" || waived[0].Exists {
+		t.Fatalf("Expected only the missing SECURITY.md to be waived, got %v", waived)
+	}
+
+	changes, err := chk.PlanFixes(waived)
+	if err != nil || len(changes) != 0 {
+		t.Errorf("Expected no changes for waived files, got %v (%v)", changes, err)
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(tempDir)
+
+	if err := os.Remove(filepath.Join(tempDir, "LICENSE.md")); err != nil {
+		t.Fatalf("Failed to remove LICENSE.md: %v", err)
+	}
+
+	chk := NewChecker(&config.Config{RepoPath: tempDir, Fix: true, Policy: &policy.Policy{Variables: map[string]string{"License": "MIT"}}})
+	license := func() ValidationResult {
+		t.Helper()
+		results, err := chk.CheckRepository()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for _, result := range results {
+			if result.Requirement.Path == "LICENSE.md" {
+				return result
+			}
+		}
+		t.Fatal("Expected a result for LICENSE.md")
+		return ValidationResult{}
+	}
+
+	// A license without an embedded text is generated as a placeholder, which is still missing
+	if _, _, err := chk.FixMissingFiles([]ValidationResult{license()}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	result := license()
+	if !result.Placeholder || result.Status() != StatusMissing {
+		t.Errorf("Expected the placeholder to count as missing, got %+v", result)
+	}
+	if fixable := chk.Fixable([]ValidationResult{result}); len(fixable) != 0 {
+		t.Errorf("Expected the placeholder not to be fixable, got %v", fixable)
+	}
+
+	// Replacing the placeholder with the text makes the file present
+	if err := os.WriteFile(filepath.Join(tempDir, "LICENSE.md"), []byte("MIT License\n\nPermission is hereby granted...\n"), 0644); err != nil {
+		t.Fatalf("Failed to write LICENSE.md: %v", err)
+	}
+	if result := license(); result.Placeholder || result.Status() != StatusPresent {
+		t.Errorf("Expected the license text to be present, got %+v", result)
+	}
+}
+
+func TestRecheck(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(tempDir)
+
+	chk := NewChecker(&config.Config{RepoPath: tempDir, CheckGitHub: true})
+	results, err := chk.CheckRepository()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	status := func(results []ValidationResult, path string) string {
+		for _, result := range results {
+			if result.Requirement.Path == path {
+				return result.Status()
+			}
+		}
+		return ""
+	}
+
+	for _, file := range []string{"SECURITY.md", "AUTHORS"} {
+		if err := os.WriteFile(filepath.Join(tempDir, file), []byte("test content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", file, err)
+		}
+	}
+
+	// Only the requirements of the changed files are checked again
+	results, err = chk.Recheck(results, []string{"SECURITY.md"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := status(results, "SECURITY.md"); got != StatusPresent {
+		t.Errorf("Expected SECURITY.md to be present, got %s", got)
+	}
+	if got := status(results, "AUTHORS"); got != StatusMissing {
+		t.Errorf("Expected AUTHORS not to be checked again, got %s", got)
+	}
+
+	// Changes to the ignore files check everything again
+	results, err = chk.Recheck(results, []string{".gitignore"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := status(results, "AUTHORS"); got != StatusPresent {
+		t.Errorf("Expected AUTHORS to be present, got %s", got)
+	}
+
+	t.Run("affects", func(t *testing.T) {
+		tests := []struct {
+			req  config.FileRequirement
+			name string
+			want bool
+		}{
+			{config.FileRequirement{Path: "README.md"}, "README.md", true},
+			{config.FileRequirement{Path: "README.md"}, "docs/README.md", false},
+			{config.FileRequirement{Path: ".github/ISSUE_TEMPLATE"}, ".github/ISSUE_TEMPLATE/bug.md", true},
+			{config.FileRequirement{Path: ".github/CODEOWNERS"}, ".github", true},
+			{config.FileRequirement{Path: ".gitignore", ContentRule: config.ContentRuleGitignore}, "package.json", true},
+			{config.FileRequirement{Path: ".gitignore", ContentRule: config.ContentRuleGitignore}, "src/index.ts", false},
+		}
+		for _, tt := range tests {
+			if got := Affects(tt.req, tt.name); got != tt.want {
+				t.Errorf("Expected Affects(%s, %s) to be %v, got %v", tt.req.Path, tt.name, tt.want, got)
+			}
+		}
+	})
+}
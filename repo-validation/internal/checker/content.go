@@ -0,0 +1,62 @@
+package checker
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/gitignore"
+)
+
+// stacks returns the stacks of the repository, detected from marker files and the enabled
+// file groups
+func (c *Checker) stacks() []string {
+	names := gitignore.Detect(c.FS)
+	if c.Config.CheckTypeScript {
+		names = append(names, gitignore.StackNode)
+	}
+	if c.Config.CheckDevEnv {
+		names = append(names, gitignore.StackDevEnv)
+	}
+
+	// Only known stacks are ever added, so this cannot fail
+	names, _ = gitignore.Normalize(names)
+	return names
+}
+
+// requiredEntries returns the entries the content rule of a requirement expects
+func (c *Checker) requiredEntries(req config.FileRequirement) []string {
+	switch req.ContentRule {
+	case config.ContentRuleGitignore:
+		return gitignore.Required(c.stacks())
+	default:
+		return nil
+	}
+}
+
+// checkContent returns the entries the content rule of a requirement expects but the file
+// does not contain
+func (c *Checker) checkContent(req config.FileRequirement) ([]string, error) {
+	required := c.requiredEntries(req)
+	if required == nil {
+		return nil, fmt.Errorf("unknown content rule %q", req.ContentRule)
+	}
+
+	content, err := c.readFile(req.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file %s: %w", req.Path, err)
+	}
+
+	return gitignore.Missing(content, required), nil
+}
+
+// appendMissingEntries appends the entries the content rule of a requirement expects to a
+// planned change that does not contain them yet
+func (c *Checker) appendMissingEntries(change *Change) {
+	if change.Requirement.ContentRule == "" || change.Path != filepath.ToSlash(change.Requirement.Path) {
+		return
+	}
+
+	missing := gitignore.Missing(change.New, c.requiredEntries(change.Requirement))
+	change.New = gitignore.Append(change.New, missing)
+}
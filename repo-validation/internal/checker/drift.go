@@ -0,0 +1,155 @@
+package checker
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/lock"
+	"github.com/LarsArtmann/templates/repo-validation/internal/patch"
+)
+
+// Upgrade is a generated file brought up to date with its template
+type Upgrade struct {
+	Change
+	// Drift is the drift status of the file before upgrading
+	Drift string
+	// Conflicts is the number of conflicts left between conflict markers
+	Conflicts int
+}
+
+// driftOf compares the generated files of a requirement with the current rendering of its
+// template, returning the status that needs the most attention, or "" if none is stamped
+func (c *Checker) driftOf(req config.FileRequirement) (string, error) {
+	if c.Lock == nil {
+		return "", nil
+	}
+
+	drift := ""
+	for _, p := range c.Lock.Paths() {
+		entry := c.Lock.Files[p]
+		if entry.Requirement != filepath.ToSlash(req.Path) {
+			continue
+		}
+
+		current, err := c.readFile(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("error reading file %s: %w", p, err)
+		}
+
+		rendered, err := c.renderStamped(p, entry)
+		if err != nil {
+			return "", fmt.Errorf("error rendering template for %s: %w", p, err)
+		}
+
+		drift = lock.Worse(drift, lock.Drift(entry, current, rendered))
+	}
+
+	return drift, nil
+}
+
+// renderStamped renders the current template of a stamped file, nil if the template no
+// longer produces the file
+func (c *Checker) renderStamped(p string, entry lock.Entry) ([]byte, error) {
+	name := c.Templates.TemplateFor(entry.Requirement, entry.Template)
+
+	if !c.Templates.IsDir(name) {
+		content, _, err := c.Templates.Render(name, c.templateData())
+		return content, err
+	}
+
+	files, err := c.Templates.RenderTree(name, c.templateData())
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if path.Join(entry.Requirement, file.Path) == p {
+			return file.Content, nil
+		}
+	}
+	return nil, nil
+}
+
+// PlanUpgrades brings every generated file that is behind its template up to date. Files
+// that were not edited are replaced with the new rendering, edited files are merged three
+// ways between the original rendering, the edits and the new rendering.
+func (c *Checker) PlanUpgrades() ([]Upgrade, error) {
+	lck, err := lock.Load(c.Config.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+	c.Lock = lck
+
+	var upgrades []Upgrade
+	for _, p := range lck.Paths() {
+		entry := lck.Files[p]
+
+		current, err := c.readFile(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading file %s: %w", p, err)
+		}
+
+		rendered, err := c.renderStamped(p, entry)
+		if err != nil {
+			return nil, fmt.Errorf("error rendering template for %s: %w", p, err)
+		}
+		if rendered == nil {
+			continue
+		}
+
+		drift := lock.Drift(entry, current, rendered)
+		if drift != lock.DriftBehind && drift != lock.DriftDiverged {
+			continue
+		}
+
+		name := c.Templates.TemplateFor(entry.Requirement, entry.Template)
+		src, _, err := c.Templates.Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+
+		req := config.FileRequirement{Path: entry.Requirement, TemplatePath: entry.Template}
+		stamped, err := c.stamp(req, name, src, rendered)
+		if err != nil {
+			return nil, err
+		}
+		upgrade := Upgrade{
+			Change: Change{
+				Requirement: req,
+				Path:        p,
+				Old:         current,
+				New:         rendered,
+				Stamp:       stamped,
+			},
+			Drift: drift,
+		}
+		if drift == lock.DriftDiverged {
+			merged, conflicts := patch.Merge(entry.Base, string(current), string(rendered))
+			upgrade.New = []byte(merged)
+			upgrade.Conflicts = conflicts
+		}
+
+		upgrades = append(upgrades, upgrade)
+	}
+
+	return upgrades, nil
+}
+
+// ApplyUpgrades writes upgraded files and their new stamps in a single transaction
+func (c *Checker) ApplyUpgrades(upgrades []Upgrade) error {
+	changes := make([]Change, 0, len(upgrades))
+	for _, upgrade := range upgrades {
+		changes = append(changes, upgrade.Change)
+	}
+
+	_, err := c.ApplyChanges(changes)
+	return err
+}
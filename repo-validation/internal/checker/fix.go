@@ -0,0 +1,344 @@
+package checker
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/gitrepo"
+	"github.com/LarsArtmann/templates/repo-validation/internal/journal"
+	"github.com/LarsArtmann/templates/repo-validation/internal/lock"
+	"github.com/LarsArtmann/templates/repo-validation/internal/managed"
+	"github.com/LarsArtmann/templates/repo-validation/internal/patch"
+	"github.com/LarsArtmann/templates/repo-validation/internal/templates"
+)
+
+// Change is a file that fixing a requirement would create or modify
+type Change struct {
+	// Requirement is the requirement the change fixes
+	Requirement config.FileRequirement
+	// Path is the path of the file, relative to the repository root, using forward slashes
+	Path string
+	// Old is the current content of the file, nil if it does not exist
+	Old []byte
+	// New is the rendered content of the file
+	New []byte
+	// IsNew indicates that the file does not exist yet
+	IsNew bool
+	// Stamp records the template the file is rendered from in the lock file, nil for files
+	// that are not stamped such as managed blocks
+	Stamp *lock.Entry
+}
+
+// FixMissingFiles generates missing files and updates outdated managed blocks based on
+// templates, and returns the paths of the files it created and modified, relative to the
+// repository root
+func (c *Checker) FixMissingFiles(results []ValidationResult) (created, modified []string, err error) {
+	if c.Config.DryRun {
+		return nil, nil, nil
+	}
+
+	changes, err := c.PlanFixes(results)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	j, err := c.ApplyChanges(changes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, entry := range j.Entries {
+		if entry.Path == lock.FileName {
+			continue
+		}
+		if entry.Action == journal.ActionCreated {
+			created = append(created, entry.Path)
+		} else {
+			modified = append(modified, entry.Path)
+		}
+	}
+
+	return created, modified, nil
+}
+
+// PlanFixes renders the templates of every fixable missing or outdated requirement and
+// returns the resulting changes without touching the repository
+func (c *Checker) PlanFixes(results []ValidationResult) ([]Change, error) {
+	var changes []Change
+
+	for _, result := range c.Fixable(results) {
+		planned, err := c.planFile(result.Requirement)
+		if err != nil {
+			return nil, fmt.Errorf("error generating file %s: %w", result.Requirement.Path, err)
+		}
+		for i := range planned {
+			c.appendMissingEntries(&planned[i])
+			if !planned[i].IsNew && bytes.Equal(planned[i].Old, planned[i].New) {
+				continue
+			}
+			changes = append(changes, planned[i])
+		}
+	}
+
+	return changes, nil
+}
+
+// Fixable returns the missing or outdated requirements that have a template to fix them with
+func (c *Checker) Fixable(results []ValidationResult) []ValidationResult {
+	var fixable []ValidationResult
+
+	for _, result := range results {
+		if (result.Exists && !result.Outdated && len(result.MissingEntries) == 0) || result.Error != nil || c.templateFor(result.Requirement) == "" {
+			continue
+		}
+
+		// Files that exist but are not tracked by git are fixed with git, not by rewriting them
+		if !result.Exists && (result.Git == gitrepo.Untracked || result.Git == gitrepo.Ignored) {
+			continue
+		}
+
+		// Placeholders are completed by hand, rendering them again would only restore them
+		if result.Placeholder {
+			continue
+		}
+
+		// Waived files are deliberately absent
+		if result.Waived {
+			continue
+		}
+
+		fixable = append(fixable, result)
+	}
+
+	return fixable
+}
+
+// Preview renders the changes fixing a result would make. Variables the templates need that
+// are not set are rendered as <Name> placeholders and returned, so the preview can be shown
+// before asking for them.
+func (c *Checker) Preview(result ValidationResult) ([]Change, []string, error) {
+	preview := *c
+	preview.Variables = maps.Clone(c.Variables)
+	if preview.Variables == nil {
+		preview.Variables = map[string]string{}
+	}
+
+	var missing []string
+	for {
+		changes, err := preview.PlanFixes([]ValidationResult{result})
+		if err == nil {
+			return changes, missing, nil
+		}
+
+		names := templates.MissingVariables(err)
+		if len(names) == 0 {
+			return nil, missing, err
+		}
+		for _, name := range names {
+			// A placeholder that did not help means the variable needs a non-empty value
+			if _, ok := preview.Variables[name]; ok {
+				return nil, missing, err
+			}
+			preview.Variables[name] = "<" + name + ">"
+			missing = append(missing, name)
+		}
+	}
+}
+
+// planFile renders a file, or a tree of files for a directory template
+func (c *Checker) planFile(req config.FileRequirement) ([]Change, error) {
+	templateName := c.templateFor(req)
+
+	if c.Templates.IsDir(templateName) {
+		return c.planTree(req, templateName)
+	}
+
+	// Render the template from the first source in the search path that provides it
+	content, src, err := c.Templates.Render(templateName, c.templateData())
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Managed {
+		return c.planManaged(req, content)
+	}
+
+	entry, err := c.stamp(req, templateName, src, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return []Change{{
+		Requirement: req,
+		Path:        filepath.ToSlash(req.Path),
+		New:         content,
+		IsNew:       true,
+		Stamp:       entry,
+	}}, nil
+}
+
+// stamp creates the lock entry for a file rendered from a template
+func (c *Checker) stamp(req config.FileRequirement, templateName string, src templates.Source, content []byte) (*lock.Entry, error) {
+	version, err := c.Templates.Version(templateName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lock.Entry{
+		Requirement: filepath.ToSlash(req.Path),
+		Template:    templates.Normalize(templateName),
+		Source:      src.Kind,
+		Version:     version,
+		Base:        string(content),
+	}, nil
+}
+
+// planManaged rewrites the managed block of a file with body, leaving the rest of the file
+// untouched, or creates the file with only the managed block if it does not exist. Files
+// without a managed block are left as they are.
+func (c *Checker) planManaged(req config.FileRequirement, body []byte) ([]Change, error) {
+	change := Change{Requirement: req, Path: filepath.ToSlash(req.Path)}
+
+	old, err := c.readFile(req.Path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		change.New = managed.Wrap(body)
+		change.IsNew = true
+		return []Change{change}, nil
+	}
+
+	change.Old = old
+	if _, found, err := managed.Content(old); err != nil {
+		return nil, err
+	} else if !found {
+		// Hand-written files only get the entries their content rule expects appended
+		change.New = old
+		return []Change{change}, nil
+	}
+	if change.New, err = managed.Update(old, body); err != nil {
+		return nil, err
+	}
+
+	return []Change{change}, nil
+}
+
+// planTree renders a directory template under the requirement path, skipping files that
+// already exist
+func (c *Checker) planTree(req config.FileRequirement, templateName string) ([]Change, error) {
+	files, err := c.Templates.RenderTree(templateName, c.templateData())
+	if err != nil {
+		return nil, err
+	}
+
+	src, _, err := c.Templates.Lookup(templateName)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, file := range files {
+		relPath := path.Join(filepath.ToSlash(req.Path), file.Path)
+
+		if _, err := c.stat(relPath); err == nil {
+			continue
+		}
+
+		entry, err := c.stamp(req, templateName, src, file.Content)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, Change{
+			Requirement: req,
+			Path:        relPath,
+			New:         file.Content,
+			IsNew:       true,
+			Stamp:       entry,
+		})
+	}
+
+	return changes, nil
+}
+
+// ApplyChanges writes planned changes to the repository in a single transaction and returns
+// the journal recording them. Either every change is applied and journaled, or none is.
+func (c *Checker) ApplyChanges(changes []Change) (*journal.Journal, error) {
+	if len(changes) == 0 {
+		return &journal.Journal{}, nil
+	}
+	if c.Config.ReadOnly() {
+		return nil, fmt.Errorf("cannot write changes to a revision or an archive")
+	}
+
+	tx := journal.Begin(c.Config.RepoPath)
+	for _, change := range changes {
+		tx.Stage(change.Path, change.New)
+	}
+
+	// Stamp generated files in the lock file as part of the same transaction
+	stamps, err := c.PlanLock(changes)
+	if err != nil {
+		return nil, err
+	}
+	if stamps != nil {
+		tx.Stage(stamps.Path, stamps.New)
+	}
+
+	j, err := tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("error applying fixes, no files were changed: %w", err)
+	}
+
+	return j, nil
+}
+
+// PlanLock returns the change to the lock file that stamps the generated files of changes,
+// which ApplyChanges writes along with them. It returns nil if no change is stamped.
+func (c *Checker) PlanLock(changes []Change) (*Change, error) {
+	change := &Change{Path: lock.FileName}
+
+	lck := lock.New()
+	old, err := c.readFile(lock.FileName)
+	switch {
+	case err == nil:
+		change.Old = old
+		if lck, err = lock.Parse(old); err != nil {
+			return nil, err
+		}
+	case os.IsNotExist(err):
+		change.IsNew = true
+	default:
+		return nil, err
+	}
+
+	stamped := false
+	for _, ch := range changes {
+		if ch.Stamp != nil {
+			lck.Files[ch.Path] = *ch.Stamp
+			stamped = true
+		}
+	}
+	if !stamped {
+		return nil, nil
+	}
+
+	if change.New, err = lck.Marshal(); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// Patch returns the changes as a patch that can be applied with git apply
+func Patch(changes []Change) string {
+	files := make([]patch.File, 0, len(changes))
+	for _, change := range changes {
+		files = append(files, patch.File{Path: change.Path, Old: change.Old, New: change.New, IsNew: change.IsNew})
+	}
+	return patch.Format(files)
+}
@@ -0,0 +1,66 @@
+package checker
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/lock"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+	"github.com/LarsArtmann/templates/repo-validation/internal/templates"
+)
+
+// Recheck checks the requirements affected by changed files again and returns the results
+// with theirs replaced. The changed paths are slash-separated and relative to the repository
+// root. Changes to files every requirement depends on, such as the lock file, the template
+// overrides or the ignore files, check the whole repository again.
+func (c *Checker) Recheck(results []ValidationResult, changed []string) ([]ValidationResult, error) {
+	for _, name := range changed {
+		if affectsAll(name) {
+			return c.CheckRepository()
+		}
+	}
+
+	rechecked := make([]ValidationResult, len(results))
+	for i, result := range results {
+		rechecked[i] = result
+		for _, name := range changed {
+			if Affects(result.Requirement, name) {
+				rechecked[i] = c.checkFile(result.Requirement)
+				break
+			}
+		}
+	}
+	return rechecked, nil
+}
+
+// Affects reports whether a change to a file can change the result of a requirement: the
+// required file itself, a file below a required directory, a directory containing the required
+// file, or for requirements with a content rule any file in the root, as marker files decide
+// the entries they need
+func Affects(req config.FileRequirement, name string) bool {
+	reqPath := filepath.ToSlash(req.Path)
+	switch {
+	case name == reqPath, strings.HasPrefix(name, reqPath+"/"), strings.HasPrefix(reqPath, name+"/"):
+		return true
+	case req.ContentRule != "" && !strings.Contains(name, "/"):
+		return true
+	default:
+		return false
+	}
+}
+
+// affectsAll reports whether a change to a file can change the result of every requirement
+func affectsAll(name string) bool {
+	switch {
+	case name == policy.FileName, name == lock.FileName:
+		return true
+	case name == templates.RepoTemplateDir, strings.HasPrefix(name, templates.RepoTemplateDir+"/"):
+		return true
+	case name == ".git/index", name == ".git/info/exclude", path.Base(name) == ".gitignore":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,163 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+)
+
+// Classes of a requirement in a comparison
+const (
+	// Fixed means the requirement was not satisfied before and is now
+	Fixed = "fixed"
+	// Regressed means the requirement was satisfied before and is not now
+	Regressed = "regressed"
+	// Unchanged means the requirement is satisfied, or not satisfied, on both sides
+	Unchanged = "unchanged"
+	// New means the requirement only applies to the second side
+	New = "new"
+	// Dropped means the requirement only applies to the first side
+	Dropped = "dropped"
+)
+
+// Classes are all classes in the order they are reported
+var Classes = []string{Regressed, Fixed, New, Dropped, Unchanged}
+
+// Side is one side of a comparison, a result file or a validated repository
+type Side struct {
+	// Name describes the side, the result file or repository path
+	Name string
+	// Requirements are the validated requirements with their status
+	Requirements []Requirement
+}
+
+// Requirement is a validated requirement with its status
+type Requirement struct {
+	// Path is the path of the required file
+	Path string `json:"path"`
+	// Priority is the priority of the requirement
+	Priority string `json:"priority"`
+	// Category is the category of the requirement
+	Category string `json:"category,omitempty"`
+	// Status is present, missing, waived or error
+	Status string `json:"status"`
+}
+
+// Change is the comparison of a requirement between two sides
+type Change struct {
+	// Path is the path of the required file
+	Path string
+	// Priority is the priority of the requirement, from the second side if it applies there
+	Priority string
+	// Category is the category of the requirement, from the second side if it applies there
+	Category string
+	// Class is fixed, regressed, unchanged, new or dropped
+	Class string
+	// Before is the status on the first side, "" if the requirement is new
+	Before string
+	// After is the status on the second side, "" if the requirement was dropped
+	After string
+}
+
+// Result is the comparison of two sides
+type Result struct {
+	// Before is the name of the first side
+	Before string
+	// After is the name of the second side
+	After string
+	// Changes are the requirements of the second side in order, followed by those dropped
+	Changes []Change
+}
+
+// FromResults creates a side from validation results
+func FromResults(name string, results []checker.ValidationResult) Side {
+	side := Side{Name: name, Requirements: make([]Requirement, 0, len(results))}
+	for _, r := range results {
+		side.Requirements = append(side.Requirements, Requirement{
+			Path:     r.Requirement.Path,
+			Priority: r.Requirement.Priority,
+			Category: r.Requirement.Category,
+			Status:   r.Status(),
+		})
+	}
+	return side
+}
+
+// Load reads a side from a file written by repo-validate --json. Only the first JSON document
+// is read, a failed validation is followed by an error document.
+func Load(file string) (Side, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return Side{}, err
+	}
+	defer f.Close()
+
+	var result struct {
+		Requirements []Requirement `json:"requirements"`
+	}
+	if err := json.NewDecoder(f).Decode(&result); err != nil {
+		return Side{}, fmt.Errorf("error parsing %s: %w", file, err)
+	}
+	if result.Requirements == nil {
+		return Side{}, fmt.Errorf("%s lists no requirements, write it with repo-validate --json", file)
+	}
+
+	return Side{Name: file, Requirements: result.Requirements}, nil
+}
+
+// Compare classifies every requirement of both sides. Waived files count as satisfied, so a
+// missing file that is waived is fixed and a waived file that is no longer waived regressed.
+func Compare(before, after Side) *Result {
+	result := &Result{Before: before.Name, After: after.Name}
+
+	statuses := map[string]string{}
+	for _, r := range before.Requirements {
+		statuses[r.Path] = r.Status
+	}
+
+	seen := map[string]bool{}
+	for _, r := range after.Requirements {
+		seen[r.Path] = true
+		change := Change{Path: r.Path, Priority: r.Priority, Category: r.Category, After: r.Status}
+
+		status, ok := statuses[r.Path]
+		change.Before = status
+		switch {
+		case !ok:
+			change.Class = New
+		case satisfied(status) == satisfied(r.Status):
+			change.Class = Unchanged
+		case satisfied(r.Status):
+			change.Class = Fixed
+		default:
+			change.Class = Regressed
+		}
+		result.Changes = append(result.Changes, change)
+	}
+
+	for _, r := range before.Requirements {
+		if !seen[r.Path] {
+			result.Changes = append(result.Changes, Change{Path: r.Path, Priority: r.Priority, Category: r.Category, Class: Dropped, Before: r.Status})
+		}
+	}
+
+	return result
+}
+
+// satisfied reports whether a status satisfies a requirement
+func satisfied(status string) bool {
+	return status == checker.StatusPresent || status == checker.StatusWaived
+}
+
+// Count returns the number of requirements of a class
+func (r *Result) Count(class string) int {
+	n := 0
+	for _, change := range r.Changes {
+		if change.Class == class {
+			n++
+		}
+	}
+	return n
+}
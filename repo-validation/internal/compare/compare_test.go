@@ -0,0 +1,107 @@
+package compare
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+)
+
+func TestCompare(t *testing.T) {
+	before := Side{Name: "yesterday.json", Requirements: []Requirement{
+		{Path: "README.md", Priority: config.PriorityMustHave, Status: checker.StatusPresent},
+		{Path: "LICENSE.md", Priority: config.PriorityMustHave, Status: checker.StatusMissing},
+		{Path: "SECURITY.md", Priority: config.PriorityShouldHave, Status: checker.StatusPresent},
+		{Path: "AUTHORS", Priority: config.PriorityNiceToHave, Status: checker.StatusMissing},
+		{Path: "Dockerfile", Priority: config.PriorityMustHave, Status: checker.StatusPresent},
+	}}
+	after := Side{Name: "today.json", Requirements: []Requirement{
+		{Path: "README.md", Priority: config.PriorityMustHave, Status: checker.StatusPresent},
+		{Path: "LICENSE.md", Priority: config.PriorityMustHave, Status: checker.StatusPresent},
+		{Path: "SECURITY.md", Priority: config.PriorityShouldHave, Status: checker.StatusError},
+		{Path: "AUTHORS", Priority: config.PriorityNiceToHave, Status: checker.StatusWaived},
+		{Path: "package.json", Priority: config.PriorityMustHave, Status: checker.StatusMissing},
+	}}
+
+	result := Compare(before, after)
+
+	want := []Change{
+		{Path: "README.md", Priority: config.PriorityMustHave, Class: Unchanged, Before: checker.StatusPresent, After: checker.StatusPresent},
+		{Path: "LICENSE.md", Priority: config.PriorityMustHave, Class: Fixed, Before: checker.StatusMissing, After: checker.StatusPresent},
+		{Path: "SECURITY.md", Priority: config.PriorityShouldHave, Class: Regressed, Before: checker.StatusPresent, After: checker.StatusError},
+		{Path: "AUTHORS", Priority: config.PriorityNiceToHave, Class: Fixed, Before: checker.StatusMissing, After: checker.StatusWaived},
+		{Path: "package.json", Priority: config.PriorityMustHave, Class: New, After: checker.StatusMissing},
+		{Path: "Dockerfile", Priority: config.PriorityMustHave, Class: Dropped, Before: checker.StatusPresent},
+	}
+	if !reflect.DeepEqual(result.Changes, want) {
+		t.Errorf("Expected %+v, got %+v", want, result.Changes)
+	}
+
+	counts := map[string]int{Fixed: 2, Regressed: 1, Unchanged: 1, New: 1, Dropped: 1}
+	for class, n := range counts {
+		if got := result.Count(class); got != n {
+			t.Errorf("Expected %d %s, got %d", n, class, got)
+		}
+	}
+}
+
+func TestFromResults(t *testing.T) {
+	side := FromResults("/src/api", []checker.ValidationResult{
+		{Requirement: config.FileRequirement{Path: "README.md", Priority: config.PriorityMustHave, Category: config.CategoryGeneral}, Exists: true},
+		{Requirement: config.FileRequirement{Path: "LICENSE.md", Priority: config.PriorityMustHave}, Error: errors.New("permission denied")},
+	})
+
+	want := []Requirement{
+		{Path: "README.md", Priority: config.PriorityMustHave, Category: config.CategoryGeneral, Status: checker.StatusPresent},
+		{Path: "LICENSE.md", Priority: config.PriorityMustHave, Status: checker.StatusError},
+	}
+	if side.Name != "/src/api" || !reflect.DeepEqual(side.Requirements, want) {
+		t.Errorf("Expected %+v, got %+v", want, side)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("result file", func(t *testing.T) {
+		file := filepath.Join(dir, "result.json")
+		content := `{"success": false, "missingMustHaveFiles": ["LICENSE.md"], "requirements": [{"path": "LICENSE.md", "priority": "Must-have", "status": "missing"}]}
+{"error": "missing must-have files", "code": 3}`
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write result file: %v", err)
+		}
+
+		side, err := Load(file)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		want := []Requirement{{Path: "LICENSE.md", Priority: config.PriorityMustHave, Status: checker.StatusMissing}}
+		if side.Name != file || !reflect.DeepEqual(side.Requirements, want) {
+			t.Errorf("Expected %+v, got %+v", want, side)
+		}
+	})
+
+	t.Run("no requirements", func(t *testing.T) {
+		file := filepath.Join(dir, "old.json")
+		if err := os.WriteFile(file, []byte(`{"success": true}`), 0644); err != nil {
+			t.Fatalf("Failed to write result file: %v", err)
+		}
+		if _, err := Load(file); err == nil {
+			t.Error("Expected an error for a result file without requirements")
+		}
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		file := filepath.Join(dir, "invalid.json")
+		if err := os.WriteFile(file, []byte("Validation failed"), 0644); err != nil {
+			t.Fatalf("Failed to write result file: %v", err)
+		}
+		if _, err := Load(file); err == nil {
+			t.Error("Expected an error for invalid JSON")
+		}
+	})
+}
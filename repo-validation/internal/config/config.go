@@ -1,6 +1,15 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/archivefs"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+)
 
 // ConfigOption is a function that configures a Config
 type ConfigOption func(*Config)
@@ -19,6 +28,41 @@ func WithFix(fix bool) ConfigOption {
 	}
 }
 
+// WithDiff sets the Diff option
+func WithDiff(diff bool) ConfigOption {
+	return func(c *Config) {
+		c.Diff = diff
+	}
+}
+
+// WithPick sets the Pick option
+func WithPick(pick bool) ConfigOption {
+	return func(c *Config) {
+		c.Pick = pick
+	}
+}
+
+// WithTUI sets the TUI option
+func WithTUI(tui bool) ConfigOption {
+	return func(c *Config) {
+		c.TUI = tui
+	}
+}
+
+// WithWatch sets the Watch option
+func WithWatch(watch bool) ConfigOption {
+	return func(c *Config) {
+		c.Watch = watch
+	}
+}
+
+// WithPatchFile sets the PatchFile option
+func WithPatchFile(patchFile string) ConfigOption {
+	return func(c *Config) {
+		c.PatchFile = patchFile
+	}
+}
+
 // WithJSONOutput sets the JSONOutput option
 func WithJSONOutput(jsonOutput bool) ConfigOption {
 	return func(c *Config) {
@@ -33,6 +77,20 @@ func WithRepoPath(repoPath string) ConfigOption {
 	}
 }
 
+// WithRef sets the Ref option
+func WithRef(ref string) ConfigOption {
+	return func(c *Config) {
+		c.Ref = ref
+	}
+}
+
+// WithHistoryFile sets the HistoryFile option
+func WithHistoryFile(file string) ConfigOption {
+	return func(c *Config) {
+		c.HistoryFile = file
+	}
+}
+
 // WithInteractive sets the Interactive option
 func WithInteractive(interactive bool) ConfigOption {
 	return func(c *Config) {
@@ -40,6 +98,20 @@ func WithInteractive(interactive bool) ConfigOption {
 	}
 }
 
+// WithTemplateDirs adds organisation template directories to the template search path
+func WithTemplateDirs(dirs ...string) ConfigOption {
+	return func(c *Config) {
+		c.TemplateDirs = append(c.TemplateDirs, dirs...)
+	}
+}
+
+// WithPolicy sets the repository policy
+func WithPolicy(p *policy.Policy) ConfigOption {
+	return func(c *Config) {
+		c.Policy = p
+	}
+}
+
 // WithFileGroup enables a specific file group
 func WithFileGroup(group string, enabled bool) ConfigOption {
 	return func(c *Config) {
@@ -54,6 +126,8 @@ func WithFileGroup(group string, enabled bool) ConfigOption {
 			c.CheckDevContainer = enabled
 		case "devenv":
 			c.CheckDevEnv = enabled
+		case "github":
+			c.CheckGitHub = enabled
 		case "all":
 			c.CheckAll = enabled
 			c.CheckAugment = enabled
@@ -61,6 +135,7 @@ func WithFileGroup(group string, enabled bool) ConfigOption {
 			c.CheckTypeScript = enabled
 			c.CheckDevContainer = enabled
 			c.CheckDevEnv = enabled
+			c.CheckGitHub = enabled
 		}
 	}
 }
@@ -81,18 +156,43 @@ const (
 	CategoryTypeScript = "TypeScript"
 )
 
+// Content rules
+const (
+	// ContentRuleGitignore requires the ignore entries of the detected stacks
+	ContentRuleGitignore = "gitignore"
+)
+
 // Config represents the configuration for the repository validation script
 type Config struct {
 	// DryRun if true, only report issues without making changes
 	DryRun bool
 	// Fix if true, generate missing files
 	Fix bool
+	// Diff if true, print the changes --fix would make instead of writing them
+	Diff bool
+	// PatchFile if set, write the changes --fix would make to this patch file instead of writing them
+	PatchFile string
+	// Pick if true, choose the files --fix generates in an interactive form
+	Pick bool
+	// TUI if true, browse the results and fix them in an interactive dashboard
+	TUI bool
+	// Watch if true, check the requirements affected by changed files again until interrupted
+	Watch bool
 	// JSONOutput if true, output results in JSON format
 	JSONOutput bool
 	// RepoPath path to the repository to validate
 	RepoPath string
+	// Ref if set, validate this git revision instead of the working tree, RepoPath may then be
+	// a bare repository
+	Ref string
+	// HistoryFile if set, append the results to this history file
+	HistoryFile string
 	// Interactive if true, prompt for missing parameters
 	Interactive bool
+	// TemplateDirs organisation template directories, searched after the repository and user overrides
+	TemplateDirs []string
+	// Policy the repository policy, loaded from the policy file when not set
+	Policy *policy.Policy
 
 	// File group flags
 	CheckAll         bool // Check all file groups
@@ -101,6 +201,7 @@ type Config struct {
 	CheckTypeScript  bool // Check TypeScript/JavaScript related files (package.json, tsconfig.json)
 	CheckDevContainer bool // Check DevContainer related files (.devcontainer.json)
 	CheckDevEnv      bool // Check DevEnv related files (devenv.nix)
+	CheckGitHub      bool // Check GitHub issue and pull request templates (.github/)
 }
 
 // ValidationOption is a function that performs additional validation on a Config
@@ -113,6 +214,43 @@ func (c *Config) Validate(opts ...ValidationOption) error {
 		return fmt.Errorf("--dry-run and --fix cannot be used together")
 	}
 
+	// Previewing changes only makes sense when fixing
+	if (c.Diff || c.PatchFile != "") && !c.Fix {
+		return fmt.Errorf("--diff and --patch can only be used together with --fix")
+	}
+
+	// Picking files only makes sense when fixing, and needs a terminal
+	if c.Pick && !c.Fix {
+		return fmt.Errorf("--pick can only be used together with --fix")
+	}
+	if c.Pick && c.JSONOutput {
+		return fmt.Errorf("--pick and --json cannot be used together")
+	}
+
+	// The dashboard fixes requirements itself and needs a terminal
+	if c.TUI && (c.Fix || c.DryRun) {
+		return fmt.Errorf("--tui cannot be used together with --fix or --dry-run, fix requirements in the dashboard")
+	}
+	if c.TUI && c.JSONOutput {
+		return fmt.Errorf("--tui and --json cannot be used together")
+	}
+
+	// Watching reports results continuously, the working tree is the only thing that changes
+	if c.Watch && (c.Fix || c.TUI || c.JSONOutput || c.HistoryFile != "") {
+		return fmt.Errorf("--watch cannot be used together with --fix, --tui, --json or --record")
+	}
+	if c.Watch && c.ReadOnly() {
+		return fmt.Errorf("--watch cannot be used with --ref or an archive")
+	}
+
+	// Revisions and archives are read-only, their changes can only be previewed
+	if c.Ref != "" && c.IsArchive() {
+		return fmt.Errorf("--ref cannot be used with an archive")
+	}
+	if c.ReadOnly() && c.Fix && !c.Diff && c.PatchFile == "" {
+		return fmt.Errorf("--fix on a revision or an archive can only be used together with --diff or --patch")
+	}
+
 	// Check if the repository path exists and is a directory
 	if c.RepoPath == "" {
 		return fmt.Errorf("repository path cannot be empty")
@@ -138,6 +276,27 @@ func (c *Config) Validate(opts ...ValidationOption) error {
 	return nil
 }
 
+// RepoName returns the name of the repository, the base name of its path without the .git
+// suffix of bare repositories or the extension of archives
+func (c *Config) RepoName() string {
+	return archivefs.TrimExt(strings.TrimSuffix(filepath.Base(c.RepoPath), ".git"))
+}
+
+// IsArchive returns true if RepoPath is a tarball or zip archive rather than a directory
+func (c *Config) IsArchive() bool {
+	if !archivefs.IsArchive(c.RepoPath) {
+		return false
+	}
+	stat, err := os.Stat(c.RepoPath)
+	return err != nil || !stat.IsDir()
+}
+
+// ReadOnly returns true if the repository is read from a git revision or an archive, which
+// changes cannot be written to
+func (c *Config) ReadOnly() bool {
+	return c.Ref != "" || c.IsArchive()
+}
+
 // ValidateFileGroups checks if at least one file group is selected when the --all flag is used
 func ValidateFileGroups(c *Config) error {
 	// If the --all flag is not set, we don't need to validate file groups
@@ -146,7 +305,7 @@ func ValidateFileGroups(c *Config) error {
 	}
 
 	// If --all is set, at least one file group should be selected
-	if c.CheckAugment || c.CheckDocker || c.CheckTypeScript || c.CheckDevContainer || c.CheckDevEnv {
+	if c.CheckAugment || c.CheckDocker || c.CheckTypeScript || c.CheckDevContainer || c.CheckDevEnv || c.CheckGitHub {
 		return nil
 	}
 
@@ -166,6 +325,11 @@ type FileRequirement struct {
 	Description string
 	// TemplatePath is the path to the template file, if any
 	TemplatePath string
+	// Managed indicates the file is also edited by humans, so only the region between the
+	// managed block markers is owned by the template
+	Managed bool
+	// ContentRule names a rule the content of the file must satisfy, if any
+	ContentRule string
 }
 
 // FileRequirementList represents a list of file requirements with helper methods
@@ -211,6 +375,11 @@ func GetFileGroups(cfg *Config) []FileGroup {
 			Flag:         &cfg.CheckDevEnv,
 			Requirements: GetDevEnvFiles(),
 		},
+		{
+			Name:         "GitHub",
+			Flag:         &cfg.CheckGitHub,
+			Requirements: GetGitHubFiles(),
+		},
 	}
 }
 
@@ -223,15 +392,44 @@ func GetAllFileRequirements(cfg *Config) FileRequirementList {
 
 	// Add requirements from each group based on flags
 	for _, group := range fileGroups {
-		// If the flag is nil or true, include the requirements
-		if group.Flag == nil || *group.Flag {
+		// If the flag is nil or true, or the policy enables the group, include the requirements
+		if group.Flag == nil || *group.Flag || cfg.Policy.HasGroup(strings.ToLower(group.Name)) {
 			allRequirements = append(allRequirements, group.Requirements...)
 		}
 	}
 
+	// Add and override requirements from the policy
+	if cfg.Policy != nil {
+		allRequirements = applyPolicy(allRequirements, cfg.Policy.Requirements)
+	}
+
 	return allRequirements
 }
 
+// applyPolicy overrides the requirements with the same path as a policy requirement and
+// appends the others. Empty fields of a policy requirement keep the built-in value.
+func applyPolicy(list FileRequirementList, reqs []policy.Requirement) FileRequirementList {
+	for _, req := range reqs {
+		i := slices.IndexFunc(list, func(r FileRequirement) bool { return r.Path == req.Path })
+		if i < 0 {
+			list = append(list, FileRequirement{Path: req.Path, Category: CategoryGeneral})
+			i = len(list) - 1
+		}
+
+		list[i].Priority = req.Priority
+		if req.Category != "" {
+			list[i].Category = req.Category
+		}
+		if req.Description != "" {
+			list[i].Description = req.Description
+		}
+		if req.Template != "" {
+			list[i].TemplatePath = req.Template
+		}
+	}
+	return list
+}
+
 // Filter returns file requirements that match the given filter function
 func (list FileRequirementList) Filter(filterFn func(FileRequirement) bool) FileRequirementList {
 	var filtered FileRequirementList
@@ -273,6 +471,8 @@ func GetGeneralMustHaveFiles() []FileRequirement {
 			Priority:    PriorityMustHave,
 			Description: "Specifies intentionally untracked files to ignore when using Git",
 			TemplatePath: "templates/.gitignore.tmpl",
+			Managed:      true,
+			ContentRule:  ContentRuleGitignore,
 		},
 		{
 			Path:        "LICENSE.md",
@@ -354,6 +554,7 @@ func GetAugmentFiles() []FileRequirement {
 			Priority:    PriorityShouldHave,
 			Description: "Controls what files Augment AI indexes in the workspace",
 			TemplatePath: "templates/.augmentignore.tmpl",
+			Managed:      true,
 		},
 	}
 }
@@ -384,6 +585,19 @@ func GetDevEnvFiles() []FileRequirement {
 	}
 }
 
+// GetGitHubFiles returns the list of GitHub-related files
+func GetGitHubFiles() []FileRequirement {
+	return []FileRequirement{
+		{
+			Path:         ".github",
+			Category:     CategoryPublic,
+			Priority:     PriorityShouldHave,
+			Description:  "Issue forms and pull request template for GitHub (.github/ISSUE_TEMPLATE/, pull_request_template.md)",
+			TemplatePath: "templates/.github.tmpl",
+		},
+	}
+}
+
 // GetCoreFiles returns all core files (must-have and should-have)
 func GetCoreFiles() []FileRequirement {
 	return append(GetGeneralMustHaveFiles(), GetGeneralShouldHaveFiles()...)
@@ -412,6 +626,7 @@ func GetGeneralShouldHaveFiles() []FileRequirement {
 			Priority:    PriorityShouldHave,
 			Description: "Helps maintain consistent coding styles across various editors and IDEs",
 			TemplatePath: "templates/.editorconfig.tmpl",
+			Managed:      true,
 		},
 		{
 			Path:        "CONTRIBUTING.md",
@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"testing"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
 )
 
 func TestConfigOptions(t *testing.T) {
@@ -95,12 +97,18 @@ func TestConfigOptions(t *testing.T) {
 				enabled: true,
 				check:   func(c *Config) bool { return c.CheckDevEnv },
 			},
+			{
+				name:    "github enabled",
+				group:   "github",
+				enabled: true,
+				check:   func(c *Config) bool { return c.CheckGitHub },
+			},
 			{
 				name:    "all enabled",
 				group:   "all",
 				enabled: true,
 				check: func(c *Config) bool {
-					return c.CheckAll && c.CheckAugment && c.CheckDocker && c.CheckTypeScript && c.CheckDevContainer && c.CheckDevEnv
+					return c.CheckAll && c.CheckAugment && c.CheckDocker && c.CheckTypeScript && c.CheckDevContainer && c.CheckDevEnv && c.CheckGitHub
 				},
 			},
 		}
@@ -151,6 +159,103 @@ func TestConfigValidate(t *testing.T) {
 		}
 	})
 
+	// Test --diff and --patch without --fix
+	t.Run("diff without fix", func(t *testing.T) {
+		for _, cfg := range []*Config{
+			{RepoPath: "/test/path", Diff: true},
+			{RepoPath: "/test/path", PatchFile: "fix.patch"},
+		} {
+			if err := cfg.Validate(); err == nil {
+				t.Errorf("Expected error for --diff/--patch without --fix, got nil")
+			}
+		}
+
+		cfg := &Config{RepoPath: "/test/path", Fix: true, Diff: true, PatchFile: "fix.patch"}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected no error for --fix --diff --patch, got %v", err)
+		}
+	})
+
+	// Test --pick without --fix or with --json
+	t.Run("pick", func(t *testing.T) {
+		for _, cfg := range []*Config{
+			{RepoPath: "/test/path", Pick: true},
+			{RepoPath: "/test/path", Pick: true, Fix: true, JSONOutput: true},
+		} {
+			if err := cfg.Validate(); err == nil {
+				t.Errorf("Expected error for --pick without --fix or with --json, got nil")
+			}
+		}
+
+		cfg := &Config{RepoPath: "/test/path", Fix: true, Pick: true, Diff: true}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected no error for --fix --pick --diff, got %v", err)
+		}
+	})
+
+	// Test --tui with --fix or --json
+	t.Run("tui", func(t *testing.T) {
+		for _, cfg := range []*Config{
+			{RepoPath: "/test/path", TUI: true, Fix: true},
+			{RepoPath: "/test/path", TUI: true, DryRun: true},
+			{RepoPath: "/test/path", TUI: true, JSONOutput: true},
+		} {
+			if err := cfg.Validate(); err == nil {
+				t.Errorf("Expected error for --tui with %+v, got nil", cfg)
+			}
+		}
+
+		cfg := &Config{RepoPath: "/test/path", TUI: true, Ref: "main"}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected no error for --tui --ref, got %v", err)
+		}
+	})
+
+	// Test --watch with options that do not report continuously
+	t.Run("watch", func(t *testing.T) {
+		for _, cfg := range []*Config{
+			{RepoPath: "/test/path", Watch: true, Fix: true},
+			{RepoPath: "/test/path", Watch: true, JSONOutput: true},
+			{RepoPath: "/test/path", Watch: true, Ref: "main"},
+			{RepoPath: "/test/path.tar.gz", Watch: true},
+		} {
+			if err := cfg.Validate(); err == nil {
+				t.Errorf("Expected error for --watch with %+v, got nil", cfg)
+			}
+		}
+	})
+
+	// Test --fix on a revision
+	t.Run("fix with ref", func(t *testing.T) {
+		cfg := &Config{RepoPath: "/test/path", Ref: "main", Fix: true}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected error for --fix with --ref, got nil")
+		}
+
+		cfg = &Config{RepoPath: "/test/path", Ref: "main", Fix: true, Diff: true}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected no error for --fix --diff with --ref, got %v", err)
+		}
+	})
+
+	// Test --fix on an archive
+	t.Run("fix with archive", func(t *testing.T) {
+		cfg := &Config{RepoPath: "/test/release.tar.gz", Fix: true}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected error for --fix on an archive, got nil")
+		}
+
+		cfg = &Config{RepoPath: "/test/release.zip", Fix: true, PatchFile: "fix.patch"}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected no error for --fix --patch on an archive, got %v", err)
+		}
+
+		cfg = &Config{RepoPath: "/test/release.zip", Ref: "main"}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected error for --ref with an archive, got nil")
+		}
+	})
+
 	// Test JSON output with interactive mode
 	t.Run("json with interactive", func(t *testing.T) {
 		cfg := &Config{
@@ -271,3 +376,42 @@ func TestGetGeneralShouldHaveFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestPolicyRequirements(t *testing.T) {
+	cfg := &Config{Policy: &policy.Policy{Requirements: []policy.Requirement{
+		{Path: "AUTHORS", Priority: PriorityNiceToHave, Template: "AUTHORS.tmpl"},
+		{Path: "CHANGELOG.md", Priority: PriorityShouldHave, Description: "Release notes"},
+	}}}
+
+	reqs := GetAllFileRequirements(cfg)
+	if len(reqs) != len(GetCoreFiles())+1 {
+		t.Fatalf("Expected the core files and one more requirement, got %d", len(reqs))
+	}
+
+	authors := reqs.Filter(func(r FileRequirement) bool { return r.Path == "AUTHORS" })[0]
+	if authors.Priority != PriorityNiceToHave || authors.TemplatePath != "AUTHORS.tmpl" || authors.Description == "" {
+		t.Errorf("Expected AUTHORS to be overridden keeping its description, got %+v", authors)
+	}
+
+	changelog := reqs[len(reqs)-1]
+	if changelog.Path != "CHANGELOG.md" || changelog.Priority != PriorityShouldHave || changelog.Category != CategoryGeneral {
+		t.Errorf("Expected CHANGELOG.md to be added as a general should-have file, got %+v", changelog)
+	}
+
+	// Built-in requirements are not changed
+	if GetGeneralShouldHaveFiles()[0].Priority != PriorityShouldHave {
+		t.Error("Expected the built-in requirements to stay unchanged")
+	}
+}
+
+func TestPolicyGroups(t *testing.T) {
+	cfg := &Config{Policy: &policy.Policy{Groups: []string{"docker"}}}
+
+	reqs := GetAllFileRequirements(cfg)
+	if len(reqs) != len(GetCoreFiles())+len(GetDockerFiles()) {
+		t.Errorf("Expected the core and Docker files, got %d requirements", len(reqs))
+	}
+	if cfg.CheckDocker {
+		t.Error("Expected the policy not to change the command line flags")
+	}
+}
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 )
 
@@ -71,3 +72,24 @@ func NewMissingMustHaveFilesError(summary string) *MissingMustHaveFilesError {
 		Summary: summary,
 	}
 }
+
+// Type returns the name of the error type of err, such as PathError, or Other for errors of
+// other types
+func Type(err error) string {
+	var pathErr *PathError
+	var accessErr *FileAccessError
+	var configErr *InvalidConfigError
+	var missingErr *MissingMustHaveFilesError
+	switch {
+	case stderrors.As(err, &pathErr):
+		return "PathError"
+	case stderrors.As(err, &accessErr):
+		return "FileAccessError"
+	case stderrors.As(err, &configErr):
+		return "InvalidConfigError"
+	case stderrors.As(err, &missingErr):
+		return "MissingMustHaveFilesError"
+	default:
+		return "Other"
+	}
+}
@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"os"
 	"testing"
 )
 
@@ -91,3 +92,22 @@ func TestMissingMustHaveFilesError(t *testing.T) {
 		t.Errorf("Expected summary %q, got %q", summary, missingErr.Summary)
 	}
 }
+
+func TestType(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{NewPathError("/path", os.ErrNotExist), "PathError"},
+		{NewFileAccessError("/path", os.ErrPermission), "FileAccessError"},
+		{fmt.Errorf("wrapped: %w", NewInvalidConfigError("bad")), "InvalidConfigError"},
+		{NewMissingMustHaveFilesError("summary"), "MissingMustHaveFilesError"},
+		{os.ErrNotExist, "Other"},
+	}
+
+	for _, tt := range tests {
+		if got := Type(tt.err); got != tt.want {
+			t.Errorf("Expected %s for %v, got %s", tt.want, tt.err, got)
+		}
+	}
+}
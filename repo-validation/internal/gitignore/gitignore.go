@@ -0,0 +1,181 @@
+package gitignore
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Stacks that have an ignore fragment, in the order they are composed
+const (
+	StackGo     = "go"
+	StackNode   = "node"
+	StackPython = "python"
+	StackNix    = "nix"
+	StackDevEnv = "devenv"
+)
+
+// stacks lists the known stacks in composition order
+var stacks = []string{StackGo, StackNode, StackPython, StackNix, StackDevEnv}
+
+// fragments contains the ignore fragment of every stack, plus the common fragment
+//
+//go:embed fragments/*.gitignore
+var fragments embed.FS
+
+// markers are the files that identify each stack when present in the repository root
+var markers = map[string][]string{
+	StackGo:     {"go.mod", "go.work"},
+	StackNode:   {"package.json"},
+	StackPython: {"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "Pipfile"},
+	StackNix:    {"flake.nix", "default.nix", "shell.nix"},
+	StackDevEnv: {"devenv.nix"},
+}
+
+// required are the entries that must be ignored for each stack, stacks without entries only
+// contribute their fragment to the generated file
+var required = map[string][]string{
+	StackNode:   {"node_modules/"},
+	StackDevEnv: {".env", ".direnv/", "result"},
+}
+
+// HeaderRequired precedes entries appended by Append
+const HeaderRequired = "# Required by repo-validation"
+
+// Stacks returns the known stacks in composition order
+func Stacks() []string {
+	return append([]string(nil), stacks...)
+}
+
+// Detect returns the stacks whose marker files exist in the root of fsys
+func Detect(fsys fs.FS) []string {
+	var detected []string
+	for _, stack := range stacks {
+		for _, marker := range markers[stack] {
+			if _, err := fs.Stat(fsys, marker); err == nil {
+				detected = append(detected, stack)
+				break
+			}
+		}
+	}
+	return detected
+}
+
+// Normalize sorts stacks into composition order and removes duplicates, it fails on
+// unknown stacks
+func Normalize(names []string) ([]string, error) {
+	wanted := map[string]bool{}
+	for _, name := range names {
+		if _, ok := markers[name]; !ok {
+			return nil, fmt.Errorf("unknown stack %q, expected one of %s", name, strings.Join(stacks, ", "))
+		}
+		wanted[name] = true
+	}
+
+	var result []string
+	for _, stack := range stacks {
+		if wanted[stack] {
+			result = append(result, stack)
+		}
+	}
+	return result, nil
+}
+
+// Compose returns an ignore file made of the common fragment followed by the fragment of
+// each stack. Patterns that an earlier fragment already contains are left out.
+func Compose(names []string) (string, error) {
+	names, err := Normalize(names)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	seen := map[string]bool{}
+	for _, name := range append([]string{"common"}, names...) {
+		data, err := fragments.ReadFile("fragments/" + name + ".gitignore")
+		if err != nil {
+			return "", err
+		}
+
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		for _, line := range strings.SplitAfter(string(data), "\n") {
+			pattern := strings.TrimSpace(line)
+			if pattern != "" && !strings.HasPrefix(pattern, "#") {
+				if seen[pattern] {
+					continue
+				}
+				seen[pattern] = true
+			}
+			b.WriteString(line)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// Required returns the entries that must be ignored by a repository with the given stacks
+func Required(names []string) []string {
+	result := []string{}
+	for _, stack := range stacks {
+		for _, name := range names {
+			if name == stack {
+				result = append(result, required[stack]...)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Missing returns the required entries that content does not contain. Entries match
+// regardless of leading or trailing slashes, so /node_modules matches node_modules/.
+func Missing(content []byte, entries []string) []string {
+	present := map[string]bool{}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		present[normalizeEntry(line)] = true
+	}
+
+	var missing []string
+	for _, entry := range entries {
+		if !present[normalizeEntry(entry)] {
+			missing = append(missing, entry)
+		}
+	}
+	return missing
+}
+
+// Append adds entries to the end of content under a header comment
+func Append(content []byte, entries []string) []byte {
+	if len(entries) == 0 {
+		return content
+	}
+
+	var b bytes.Buffer
+	b.Write(content)
+	if len(content) > 0 {
+		if content[len(content)-1] != '\n' {
+			b.WriteByte('\n')
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(HeaderRequired + "\n")
+	for _, entry := range entries {
+		b.WriteString(entry + "\n")
+	}
+	return b.Bytes()
+}
+
+// normalizeEntry strips the parts of a pattern that do not change what it matches at the
+// repository root
+func normalizeEntry(entry string) string {
+	entry = strings.TrimPrefix(entry, "**/")
+	return strings.Trim(entry, "/")
+}
@@ -0,0 +1,68 @@
+package gitignore
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDetect(t *testing.T) {
+	fsys := fstest.MapFS{
+		"go.mod":       {Data: []byte("module demo")},
+		"package.json": {Data: []byte("{}")},
+		"devenv.nix":   {Data: []byte("{}")},
+	}
+
+	if got := strings.Join(Detect(fsys), ","); got != "go,node,devenv" {
+		t.Errorf("Expected go,node,devenv, got %s", got)
+	}
+}
+
+func TestCompose(t *testing.T) {
+	t.Run("common and stack fragments", func(t *testing.T) {
+		content, err := Compose([]string{StackNode, StackGo})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		goIndex := strings.Index(content, "go.work")
+		nodeIndex := strings.Index(content, "node_modules/")
+		if !strings.HasPrefix(content, "# IDE specific files") || goIndex < 0 || nodeIndex < goIndex {
+			t.Errorf("Expected common, go and node fragments in order, got:\n%s", content)
+		}
+		if strings.Count(content, "/dist/\n") != 1 {
+			t.Errorf("Expected duplicate patterns to be left out, got:\n%s", content)
+		}
+	})
+
+	t.Run("unknown stack", func(t *testing.T) {
+		if _, err := Compose([]string{"cobol"}); err == nil {
+			t.Errorf("Expected error for unknown stack, got nil")
+		}
+	})
+}
+
+func TestMissing(t *testing.T) {
+	required := Required([]string{StackDevEnv, StackNode})
+	if got := strings.Join(required, ","); got != "node_modules/,.env,.direnv/,result" {
+		t.Fatalf("Expected node_modules/,.env,.direnv/,result, got %s", got)
+	}
+
+	content := []byte("# .env\n/node_modules\n**/.direnv\n!result\n")
+	if got := strings.Join(Missing(content, required), ","); got != ".env,result" {
+		t.Errorf("Expected .env,result to be missing, got %s", got)
+	}
+
+	// Stacks other than Node and devenv only contribute to the generated file
+	if got := Required([]string{StackGo, StackPython, StackNix}); len(got) != 0 {
+		t.Errorf("Expected no required entries for go, python and nix, got %v", got)
+	}
+	if got := Missing([]byte("*.log\n"), Required(nil)); len(got) != 0 {
+		t.Errorf("Expected nothing to be required without stacks, got %v", got)
+	}
+
+	appended := Append([]byte("*.log"), []string{".env"})
+	if string(appended) != "*.log\n\n"+HeaderRequired+"\n.env\n" {
+		t.Errorf("Unexpected appended content %q", appended)
+	}
+}
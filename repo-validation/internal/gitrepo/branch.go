@@ -0,0 +1,162 @@
+package gitrepo
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Errors returned by CommitToBranch when a working tree is not safe to change
+var (
+	// ErrDirty means the working tree has uncommitted changes or untracked files
+	ErrDirty = errors.New("working tree has uncommitted changes")
+	// ErrBranchExists means the branch to commit to already exists
+	ErrBranchExists = errors.New("branch already exists")
+	// ErrDetached means HEAD does not point at a branch
+	ErrDetached = errors.New("HEAD is detached")
+	// ErrNoAuthor means no author was given and git has no user.name and user.email configured
+	ErrNoAuthor = errors.New("no commit author, configure git user.name and user.email")
+)
+
+// Author is the author and committer of commits
+type Author struct {
+	// Name is the name of the author
+	Name string
+	// Email is the email address of the author
+	Email string
+}
+
+// DefaultAuthor returns the user.name and user.email of the repository containing p, falling
+// back to the global and system git configuration
+func DefaultAuthor(p string) (Author, error) {
+	repo, err := git.PlainOpenWithOptions(p, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return Author{}, fmt.Errorf("error opening git repository: %w", err)
+	}
+
+	cfg, err := repo.ConfigScoped(gitconfig.SystemScope)
+	if err != nil {
+		return Author{}, fmt.Errorf("error reading git config: %w", err)
+	}
+	if cfg.User.Name == "" || cfg.User.Email == "" {
+		return Author{}, ErrNoAuthor
+	}
+
+	return Author{Name: cfg.User.Name, Email: cfg.User.Email}, nil
+}
+
+// CommitToBranch creates branch at HEAD of the clean working tree containing p, checks it out
+// and calls write, which changes files and returns their paths relative to p. The files are
+// committed to the branch and the original branch is checked out again, so the working tree
+// is left as it was. If write leaves changes it did not report, CommitToBranch stays on the
+// branch rather than discard them. Nothing is ever pushed. It returns the hash of the commit.
+func CommitToBranch(p, branch, message string, author Author, write func() ([]string, error)) (string, error) {
+	repo, err := git.PlainOpenWithOptions(p, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", fmt.Errorf("error opening git repository: %w", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("error opening git worktree: %w", err)
+	}
+
+	// Status reads .gitignore and .git/info/exclude but not the global excludes, without which
+	// files git ignores would count as untracked
+	global, err := globalPatterns()
+	if err != nil {
+		return "", fmt.Errorf("error reading global git excludes: %w", err)
+	}
+	wt.Excludes = append(wt.Excludes, global...)
+
+	status, err := wt.Status()
+	if err != nil {
+		return "", fmt.Errorf("error reading git status: %w", err)
+	}
+	if !status.IsClean() {
+		return "", ErrDirty
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("error reading HEAD: %w", err)
+	}
+	if !head.Name().IsBranch() {
+		return "", ErrDetached
+	}
+
+	name := plumbing.NewBranchReferenceName(branch)
+	if _, err := repo.Reference(name, false); err == nil {
+		return "", ErrBranchExists
+	}
+
+	prefix, err := relPath(wt.Filesystem.Root(), p)
+	if err != nil {
+		return "", err
+	}
+
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: name, Create: true, Hash: head.Hash()}); err != nil {
+		return "", fmt.Errorf("error creating branch %s: %w", branch, err)
+	}
+
+	// Return to the original branch unless write left changes behind that checking it out would
+	// lose, and drop the branch unless it was committed to
+	hash, err := commitChanges(wt, prefix, message, author, write)
+	if checkoutErr := checkoutClean(wt, head.Name()); checkoutErr != nil {
+		return "", errors.Join(err, fmt.Errorf("staying on branch %s: %w", branch, checkoutErr))
+	}
+	if hash == "" {
+		_ = repo.Storer.RemoveReference(name)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return hash, nil
+}
+
+// commitChanges calls write and commits the files it changed, it returns "" if nothing changed
+func commitChanges(wt *git.Worktree, prefix, message string, author Author, write func() ([]string, error)) (string, error) {
+	paths, err := write()
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 0 {
+		return "", nil
+	}
+
+	for _, p := range paths {
+		if _, err := wt.Add(path.Join(filepath.ToSlash(prefix), p)); err != nil {
+			return "", fmt.Errorf("error adding %s: %w", p, err)
+		}
+	}
+
+	sig := &object.Signature{Name: author.Name, Email: author.Email, When: time.Now()}
+	hash, err := wt.Commit(message, &git.CommitOptions{Author: sig, Committer: sig})
+	if err != nil {
+		return "", fmt.Errorf("error committing: %w", err)
+	}
+
+	return hash.String(), nil
+}
+
+// checkoutClean checks out branch if the working tree has no uncommitted changes
+func checkoutClean(wt *git.Worktree, branch plumbing.ReferenceName) error {
+	status, err := wt.Status()
+	if err != nil {
+		return fmt.Errorf("error reading git status: %w", err)
+	}
+	if !status.IsClean() {
+		return fmt.Errorf("uncommitted changes would be lost checking out %s", branch.Short())
+	}
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: branch}); err != nil {
+		return fmt.Errorf("error checking out %s: %w", branch.Short(), err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package gitrepo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestCommitToBranch(t *testing.T) {
+	root := t.TempDir()
+	commitFiles(t, root, map[string]string{"README.md": "readme"})
+	author := Author{Name: "Bot", Email: "bot@example.com"}
+
+	write := func() ([]string, error) {
+		if err := os.WriteFile(filepath.Join(root, "LICENSE.md"), []byte("license"), 0644); err != nil {
+			return nil, err
+		}
+		return []string{"LICENSE.md"}, nil
+	}
+
+	hash, err := CommitToBranch(root, "chore/standards", "Add license", author, write)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	repo, err := git.PlainOpen(root)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+
+	t.Run("commit on branch", func(t *testing.T) {
+		ref, err := repo.Reference(plumbing.NewBranchReferenceName("chore/standards"), false)
+		if err != nil {
+			t.Fatalf("Expected branch to exist, got %v", err)
+		}
+		if ref.Hash().String() != hash {
+			t.Errorf("Expected branch at %s, got %s", hash, ref.Hash())
+		}
+
+		commit, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			t.Fatalf("Failed to read commit: %v", err)
+		}
+		if commit.Author.Email != author.Email || commit.Message != "Add license" {
+			t.Errorf("Expected commit by %s with message %q, got %s %q", author.Email, "Add license", commit.Author.Email, commit.Message)
+		}
+		if _, err := commit.File("LICENSE.md"); err != nil {
+			t.Errorf("Expected LICENSE.md in the commit, got %v", err)
+		}
+	})
+
+	t.Run("original branch checked out", func(t *testing.T) {
+		head, err := repo.Head()
+		if err != nil {
+			t.Fatalf("Failed to read HEAD: %v", err)
+		}
+		if head.Name() != plumbing.Master {
+			t.Errorf("Expected %s to be checked out, got %s", plumbing.Master, head.Name())
+		}
+		if _, err := os.Stat(filepath.Join(root, "LICENSE.md")); !os.IsNotExist(err) {
+			t.Errorf("Expected LICENSE.md to be absent from the working tree, got %v", err)
+		}
+	})
+
+	t.Run("branch exists", func(t *testing.T) {
+		if _, err := CommitToBranch(root, "chore/standards", "Add license", author, write); !errors.Is(err, ErrBranchExists) {
+			t.Errorf("Expected ErrBranchExists, got %v", err)
+		}
+	})
+
+	t.Run("nothing written", func(t *testing.T) {
+		hash, err := CommitToBranch(root, "chore/empty", "Nothing", author, func() ([]string, error) { return nil, nil })
+		if err != nil || hash != "" {
+			t.Fatalf("Expected no commit and no error, got %q (%v)", hash, err)
+		}
+		if _, err := repo.Reference(plumbing.NewBranchReferenceName("chore/empty"), false); err == nil {
+			t.Errorf("Expected the unused branch to be removed")
+		}
+	})
+
+	t.Run("unreported changes", func(t *testing.T) {
+		hash, err := CommitToBranch(root, "chore/partial", "Add license", author, func() ([]string, error) {
+			if err := os.WriteFile(filepath.Join(root, "README.md"), []byte("edited by hand"), 0644); err != nil {
+				return nil, err
+			}
+			return write()
+		})
+		if err == nil || hash != "" {
+			t.Fatalf("Expected an error, got %q (%v)", hash, err)
+		}
+
+		head, err := repo.Head()
+		if err != nil {
+			t.Fatalf("Failed to read HEAD: %v", err)
+		}
+		if head.Name() != plumbing.NewBranchReferenceName("chore/partial") {
+			t.Errorf("Expected to stay on chore/partial, got %s", head.Name())
+		}
+		if content, err := os.ReadFile(filepath.Join(root, "README.md")); err != nil || string(content) != "edited by hand" {
+			t.Errorf("Expected the change to README.md to be kept, got %q (%v)", content, err)
+		}
+
+		wt, err := repo.Worktree()
+		if err != nil {
+			t.Fatalf("Failed to open worktree: %v", err)
+		}
+		if err := wt.Checkout(&git.CheckoutOptions{Branch: plumbing.Master, Force: true}); err != nil {
+			t.Fatalf("Failed to check out %s: %v", plumbing.Master, err)
+		}
+	})
+
+	t.Run("globally ignored files", func(t *testing.T) {
+		home, xdg := t.TempDir(), t.TempDir()
+		t.Setenv("HOME", home)
+		t.Setenv("XDG_CONFIG_HOME", xdg)
+		writeFile(t, filepath.Join(xdg, "git", "ignore"), ".idea/\n")
+		writeFile(t, filepath.Join(root, ".idea", "workspace.xml"), "workspace")
+
+		hash, err := CommitToBranch(root, "chore/ignored", "Add notes", author, func() ([]string, error) {
+			writeFile(t, filepath.Join(root, ".idea", "notes.xml"), "notes")
+			writeFile(t, filepath.Join(root, "NOTES.md"), "notes")
+			return []string{"NOTES.md"}, nil
+		})
+		if err != nil || hash == "" {
+			t.Fatalf("Expected a commit, got %q (%v)", hash, err)
+		}
+		head, err := repo.Head()
+		if err != nil || head.Name() != plumbing.Master {
+			t.Errorf("Expected %s to be checked out, got %v (%v)", plumbing.Master, head, err)
+		}
+		if err := os.RemoveAll(filepath.Join(root, ".idea")); err != nil {
+			t.Fatalf("Failed to remove .idea: %v", err)
+		}
+	})
+
+	t.Run("dirty working tree", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(root, "README.md"), []byte("edited"), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if _, err := CommitToBranch(root, "chore/dirty", "Add license", author, write); !errors.Is(err, ErrDirty) {
+			t.Errorf("Expected ErrDirty, got %v", err)
+		}
+	})
+}
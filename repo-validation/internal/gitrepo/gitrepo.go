@@ -0,0 +1,240 @@
+package gitrepo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// Git statuses of a file
+const (
+	// Tracked means the file is in the index
+	Tracked = "tracked"
+	// Untracked means the file exists but is neither in the index nor ignored
+	Untracked = "untracked"
+	// Ignored means the file exists but matches an ignore pattern and is not in the index
+	Ignored = "ignored"
+)
+
+// Repo reads the index and ignore files of a local git repository. It never touches the
+// network or runs git.
+type Repo struct {
+	// Root is the root of the working tree
+	Root string
+
+	prefix  string
+	index   map[string]bool
+	dirs    map[string]bool
+	global  []gitignore.Pattern
+	ignores map[string][]gitignore.Pattern
+}
+
+// Open opens the git repository containing path, or returns nil if path is not inside a
+// working tree
+func Open(p string) (*Repo, error) {
+	repo, err := git.PlainOpenWithOptions(p, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		if errors.Is(err, git.ErrRepositoryNotExists) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error opening git repository: %w", err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		if errors.Is(err, git.ErrIsBareRepository) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error opening git worktree: %w", err)
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("error reading git index: %w", err)
+	}
+
+	root := wt.Filesystem.Root()
+	prefix, err := relPath(root, p)
+	if err != nil {
+		return nil, err
+	}
+
+	global, err := globalPatterns()
+	if err != nil {
+		return nil, fmt.Errorf("error reading global git excludes: %w", err)
+	}
+
+	r := &Repo{
+		Root:    root,
+		prefix:  filepath.ToSlash(prefix),
+		index:   map[string]bool{},
+		dirs:    map[string]bool{},
+		global:  global,
+		ignores: map[string][]gitignore.Pattern{},
+	}
+	for _, entry := range idx.Entries {
+		r.index[entry.Name] = true
+		for dir := path.Dir(entry.Name); dir != "."; dir = path.Dir(dir) {
+			r.dirs[dir] = true
+		}
+	}
+
+	return r, nil
+}
+
+// Head returns the commit HEAD points at in the git repository containing p, or "" if p is not
+// inside a repository or the repository has no commits yet
+func Head(p string) (string, error) {
+	repo, err := git.PlainOpenWithOptions(p, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		if errors.Is(err, git.ErrRepositoryNotExists) {
+			return "", nil
+		}
+		return "", fmt.Errorf("error opening git repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("error reading HEAD: %w", err)
+	}
+
+	return head.Hash().String(), nil
+}
+
+// Status returns the git status of a file or directory, relative to the path the repository
+// was opened at. A directory is tracked if any file below it is.
+func (r *Repo) Status(relPath string, isDir bool) (string, error) {
+	name := path.Join(r.prefix, filepath.ToSlash(relPath))
+
+	if r.index[name] || (isDir && r.dirs[name]) {
+		return Tracked, nil
+	}
+
+	ignored, err := r.isIgnored(name, isDir)
+	if err != nil {
+		return "", err
+	}
+	if ignored {
+		return Ignored, nil
+	}
+
+	return Untracked, nil
+}
+
+// isIgnored matches a path against the global excludes, .git/info/exclude and the .gitignore
+// files of its parent directories
+func (r *Repo) isIgnored(name string, isDir bool) (bool, error) {
+	parts := strings.Split(name, "/")
+
+	exclude, err := r.patterns(filepath.Join(".git", "info", "exclude"), nil)
+	if err != nil {
+		return false, err
+	}
+	patterns := append(slices.Clip(r.global), exclude...)
+	for i := 0; i < len(parts); i++ {
+		domain := parts[:i:i]
+		ps, err := r.patterns(filepath.Join(append(domain, ".gitignore")...), domain)
+		if err != nil {
+			return false, err
+		}
+		patterns = append(patterns, ps...)
+	}
+
+	return gitignore.NewMatcher(patterns).Match(parts, isDir), nil
+}
+
+// patterns reads an ignore file relative to the working tree root, caching the result
+func (r *Repo) patterns(file string, domain []string) ([]gitignore.Pattern, error) {
+	if ps, ok := r.ignores[file]; ok {
+		return ps, nil
+	}
+
+	ps, err := readPatterns(filepath.Join(r.Root, file), domain)
+	if err != nil {
+		return nil, err
+	}
+
+	r.ignores[file] = ps
+	return ps, nil
+}
+
+// globalPatterns reads the excludes files of the system and user git configuration, falling back
+// to $XDG_CONFIG_HOME/git/ignore like git does if the user configuration sets none
+func globalPatterns() ([]gitignore.Pattern, error) {
+	fs := osfs.New("/")
+	patterns, err := gitignore.LoadSystemPatterns(fs)
+	if err != nil {
+		return nil, err
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		// Without a home directory there is no user configuration
+		return patterns, nil
+	}
+	global, err := gitignore.LoadGlobalPatterns(fs)
+	if err != nil {
+		return nil, err
+	}
+	if global == nil {
+		dir := os.Getenv("XDG_CONFIG_HOME")
+		if dir == "" {
+			dir = filepath.Join(home, ".config")
+		}
+		if global, err = readPatterns(filepath.Join(dir, "git", "ignore"), nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return append(patterns, global...), nil
+}
+
+// readPatterns reads an ignore file, a missing file has no patterns
+func readPatterns(name string, domain []string) ([]gitignore.Pattern, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var ps []gitignore.Pattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		ps = append(ps, gitignore.ParsePattern(line, append([]string(nil), domain...)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+// relPath returns target relative to base, resolving symlinks so both are comparable
+func relPath(base, target string) (string, error) {
+	if resolved, err := filepath.EvalSymlinks(base); err == nil {
+		base = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+	return filepath.Rel(base, target)
+}
@@ -0,0 +1,170 @@
+package gitrepo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestStatus(t *testing.T) {
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatalf("Failed to init repository: %v", err)
+	}
+
+	files := map[string]string{
+		".gitignore":           "/build/\n*.log\n",
+		"README.md":            "readme",
+		"SECURITY.md":          "security",
+		"debug.log":            "log",
+		"build/LICENSE.md":     "license",
+		"docs/.gitignore":      "draft.md\n",
+		"docs/draft.md":        "draft",
+		"docs/guide.md":        "guide",
+		".github/CODEOWNERS":   "* @acme",
+		"sub/project/go.mod":   "module sub",
+		"sub/project/NOTES.md": "notes",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to open worktree: %v", err)
+	}
+	for _, name := range []string{"README.md", ".github/CODEOWNERS", "sub/project/go.mod"} {
+		if _, err := wt.Add(name); err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+	}
+
+	r, err := Open(root)
+	if err != nil || r == nil {
+		t.Fatalf("Expected repository, got %v (%v)", r, err)
+	}
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  string
+	}{
+		{path: "README.md", want: Tracked},
+		{path: "SECURITY.md", want: Untracked},
+		{path: "debug.log", want: Ignored},
+		{path: "build/LICENSE.md", want: Ignored},
+		{path: "docs/draft.md", want: Ignored},
+		{path: "docs/guide.md", want: Untracked},
+		{path: ".github", isDir: true, want: Tracked},
+		{path: "docs", isDir: true, want: Untracked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			status, err := r.Status(tt.path, tt.isDir)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if status != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, status)
+			}
+		})
+	}
+
+	t.Run("subdirectory", func(t *testing.T) {
+		sub, err := Open(filepath.Join(root, "sub", "project"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for name, want := range map[string]string{"go.mod": Tracked, "NOTES.md": Untracked} {
+			if status, _ := sub.Status(name, false); status != want {
+				t.Errorf("Expected %s to be %s, got %s", name, want, status)
+			}
+		}
+	})
+
+	t.Run("not a repository", func(t *testing.T) {
+		if r, err := Open(t.TempDir()); r != nil || err != nil {
+			t.Errorf("Expected nil repository without error, got %v (%v)", r, err)
+		}
+	})
+}
+
+func TestGlobalExcludes(t *testing.T) {
+	root := t.TempDir()
+	if _, err := git.PlainInit(root, false); err != nil {
+		t.Fatalf("Failed to init repository: %v", err)
+	}
+	for _, name := range []string{"notes.txt", ".idea/workspace.xml", "README.md"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, home, xdg string)
+		ignored string
+	}{
+		{
+			name: "core.excludesFile",
+			setup: func(t *testing.T, home, xdg string) {
+				excludes := filepath.Join(home, "excludes")
+				writeFile(t, filepath.Join(home, ".gitconfig"), "[core]\n\texcludesFile = "+excludes+"\n")
+				writeFile(t, excludes, "notes.txt\n")
+			},
+			ignored: "notes.txt",
+		},
+		{
+			name: "XDG default",
+			setup: func(t *testing.T, home, xdg string) {
+				writeFile(t, filepath.Join(xdg, "git", "ignore"), ".idea/\n")
+			},
+			ignored: ".idea",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home, xdg := t.TempDir(), t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", xdg)
+			tt.setup(t, home, xdg)
+
+			r, err := Open(root)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			for _, name := range []string{"notes.txt", ".idea", "README.md"} {
+				want := Untracked
+				if name == tt.ignored {
+					want = Ignored
+				}
+				if status, _ := r.Status(name, name == ".idea"); status != want {
+					t.Errorf("Expected %s to be %s, got %s", name, want, status)
+				}
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package gitrepo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// OpenTree opens the tree of a revision of the git repository containing path, which may be
+// a bare repository. The tree is rooted at path when path is a subdirectory of a working
+// tree. It returns the tree and the commit the revision resolved to.
+func OpenTree(p, rev string) (fs.FS, string, error) {
+	// Bare repositories are only found when opening them directly
+	repo, err := git.PlainOpen(p)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		repo, err = git.PlainOpenWithOptions(p, &git.PlainOpenOptions{DetectDotGit: true})
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("error opening git repository: %w", err)
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, "", fmt.Errorf("error resolving %s: %w", rev, err)
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading commit %s: %w", hash, err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading tree of %s: %w", hash, err)
+	}
+
+	var fsys fs.FS = &TreeFS{tree: tree, modTime: commit.Committer.When}
+
+	// Root the tree at path when validating a subdirectory of a working tree
+	if wt, err := repo.Worktree(); err == nil {
+		prefix, err := relPath(wt.Filesystem.Root(), p)
+		if err != nil {
+			return nil, "", err
+		}
+		if prefix = path.Clean(filepath.ToSlash(prefix)); prefix != "." {
+			if fsys, err = fs.Sub(fsys, prefix); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	return fsys, hash.String(), nil
+}
+
+// TreeFS is a read-only fs.FS over a git tree. Blobs are read from the object store when
+// they are opened.
+type TreeFS struct {
+	tree    *object.Tree
+	modTime time.Time
+}
+
+// Open opens a file or directory of the tree
+func (t *TreeFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		return t.openDir(name, t.tree)
+	}
+
+	entry, err := t.tree.FindEntry(name)
+	if err != nil {
+		if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	switch entry.Mode {
+	case filemode.Dir:
+		subtree, err := t.tree.Tree(name)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return t.openDir(name, subtree)
+	case filemode.Submodule:
+		// Submodules are not part of the tree, they show up as empty directories
+		return &treeDir{info: t.info(path.Base(name), entry.Mode, 0)}, nil
+	}
+
+	file, err := t.tree.TreeEntryFile(entry)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+
+	return &treeFile{info: t.info(path.Base(name), entry.Mode, int64(len(content))), Reader: bytes.NewReader(content)}, nil
+}
+
+// openDir returns a directory listing of a tree
+func (t *TreeFS) openDir(name string, tree *object.Tree) (fs.File, error) {
+	entries := make([]fs.DirEntry, 0, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		size := int64(0)
+		if entry.Mode.IsFile() {
+			if size, _ = tree.Size(entry.Name); size < 0 {
+				size = 0
+			}
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(t.info(entry.Name, entry.Mode, size)))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	return &treeDir{info: t.info(path.Base(name), filemode.Dir, 0), entries: entries}, nil
+}
+
+// info describes a tree entry
+func (t *TreeFS) info(name string, mode filemode.FileMode, size int64) *treeInfo {
+	var fsMode fs.FileMode
+	switch mode {
+	case filemode.Dir, filemode.Submodule:
+		fsMode = fs.ModeDir | 0755
+	case filemode.Executable:
+		fsMode = 0755
+	case filemode.Symlink:
+		fsMode = fs.ModeSymlink | 0777
+	default:
+		fsMode = 0644
+	}
+	return &treeInfo{name: name, size: size, mode: fsMode, modTime: t.modTime}
+}
+
+// treeInfo implements fs.FileInfo for tree entries
+type treeInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i *treeInfo) Name() string       { return i.name }
+func (i *treeInfo) Size() int64        { return i.size }
+func (i *treeInfo) Mode() fs.FileMode  { return i.mode }
+func (i *treeInfo) ModTime() time.Time { return i.modTime }
+func (i *treeInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *treeInfo) Sys() interface{}   { return nil }
+
+// treeFile is an opened blob
+type treeFile struct {
+	*bytes.Reader
+	info *treeInfo
+}
+
+func (f *treeFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *treeFile) Close() error               { return nil }
+
+// treeDir is an opened tree
+type treeDir struct {
+	info    *treeInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *treeDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *treeDir) Close() error               { return nil }
+
+func (d *treeDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir returns the next n entries of the directory, or all remaining entries if n <= 0
+func (d *treeDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
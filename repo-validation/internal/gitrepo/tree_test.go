@@ -0,0 +1,106 @@
+package gitrepo
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitFiles writes files into a new repository and commits them
+func commitFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	repo, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatalf("Failed to init repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to open worktree: %v", err)
+	}
+
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if _, err := wt.Add(name); err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+	}
+
+	sig := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Unix(1700000000, 0)}
+	if _, err := wt.Commit("initial", &git.CommitOptions{Author: sig, Committer: sig}); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+}
+
+func TestOpenTree(t *testing.T) {
+	root := t.TempDir()
+	commitFiles(t, root, map[string]string{
+		"README.md":              "readme",
+		".github/CODEOWNERS":     "* @acme",
+		"sub/project/LICENSE.md": "license",
+	})
+
+	// Uncommitted changes are not part of the tree
+	if err := os.WriteFile(filepath.Join(root, "SECURITY.md"), []byte("security"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	t.Run("worktree", func(t *testing.T) {
+		fsys, hash, err := OpenTree(root, "HEAD")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(hash) != 40 {
+			t.Errorf("Expected a commit hash, got %q", hash)
+		}
+		if err := fstest.TestFS(fsys, "README.md", ".github/CODEOWNERS", "sub/project/LICENSE.md"); err != nil {
+			t.Errorf("Expected a valid fs.FS, got %v", err)
+		}
+		if _, err := fs.Stat(fsys, "SECURITY.md"); err == nil {
+			t.Errorf("Expected uncommitted SECURITY.md to be missing from the tree")
+		}
+	})
+
+	t.Run("subdirectory", func(t *testing.T) {
+		fsys, _, err := OpenTree(filepath.Join(root, "sub", "project"), "HEAD")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if content, err := fs.ReadFile(fsys, "LICENSE.md"); err != nil || string(content) != "license" {
+			t.Errorf("Expected LICENSE.md relative to the subdirectory, got %q (%v)", content, err)
+		}
+	})
+
+	t.Run("bare repository", func(t *testing.T) {
+		bare := filepath.Join(t.TempDir(), "mirror.git")
+		if _, err := git.PlainClone(bare, true, &git.CloneOptions{URL: root}); err != nil {
+			t.Fatalf("Failed to clone: %v", err)
+		}
+
+		fsys, _, err := OpenTree(bare, "master")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if content, err := fs.ReadFile(fsys, "README.md"); err != nil || string(content) != "readme" {
+			t.Errorf("Expected README.md from the bare repository, got %q (%v)", content, err)
+		}
+	})
+
+	t.Run("unknown revision", func(t *testing.T) {
+		if _, _, err := OpenTree(root, "does-not-exist"); err == nil {
+			t.Errorf("Expected error for unknown revision, got nil")
+		}
+	})
+}
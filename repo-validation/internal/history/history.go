@@ -0,0 +1,166 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/scan"
+)
+
+// FileName is the name of the history file in the user config directory
+const FileName = "history.jsonl"
+
+// File statuses of a record
+const (
+	StatusPresent = checker.StatusPresent
+	StatusMissing = checker.StatusMissing
+	StatusWaived  = checker.StatusWaived
+	StatusError   = checker.StatusError
+)
+
+// ErrNoHistory is returned when no results have been recorded yet
+var ErrNoHistory = errors.New("no history recorded, validate with --record first")
+
+// Record is the result of validating a repository at a point in time. Records are keyed by
+// repository, commit and time.
+type Record struct {
+	// Time is when the repository was validated
+	Time time.Time `json:"time"`
+	// Repository is the absolute path of the repository
+	Repository string `json:"repository"`
+	// Name is the name of the repository
+	Name string `json:"name"`
+	// Commit is the commit that was validated, "" if the repository is not a git repository
+	Commit string `json:"commit,omitempty"`
+	// Passed indicates no must-have file was missing
+	Passed bool `json:"passed"`
+	// Score is the percentage of requirements present, weighted by priority
+	Score int `json:"score"`
+	// Files are the states of the required files
+	Files []File `json:"files"`
+}
+
+// File is the state of a required file in a record
+type File struct {
+	// Path is the path of the required file
+	Path string `json:"path"`
+	// Priority is the priority of the requirement
+	Priority string `json:"priority"`
+	// Category is the category of the requirement
+	Category string `json:"category,omitempty"`
+	// Status is present, missing, waived or error
+	Status string `json:"status"`
+}
+
+// DefaultPath returns the history file in the user config directory
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "repo-validation", FileName), nil
+}
+
+// NewRecord creates a record from the result of validating a repository at commit
+func NewRecord(result scan.Result, commit string, at time.Time) Record {
+	r := Record{
+		Time:       at.UTC().Truncate(time.Second),
+		Repository: result.Path,
+		Name:       result.Name,
+		Commit:     commit,
+		Passed:     result.Passed,
+		Score:      result.Score,
+		Files:      make([]File, 0, len(result.Results)),
+	}
+
+	for _, res := range result.Results {
+		r.Files = append(r.Files, File{
+			Path:     res.Requirement.Path,
+			Priority: res.Requirement.Priority,
+			Category: res.Requirement.Category,
+			Status:   res.Status(),
+		})
+	}
+
+	return r
+}
+
+// Status returns the status of a file in the record, or "" if the file was not checked
+func (r Record) Status(path string) string {
+	for _, f := range r.Files {
+		if f.Path == path {
+			return f.Status
+		}
+	}
+	return ""
+}
+
+// Append appends records to the history file, creating it and its directory if needed. The
+// file is only ever appended to, one JSON record per line.
+func Append(file string, records ...Record) error {
+	var buf bytes.Buffer
+	for _, r := range records {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("error marshaling record of %s: %w", r.Repository, err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("error creating history directory: %w", err)
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	// A single write keeps the records of concurrent runs on separate lines
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads all records of the history file in the order they were recorded. It returns
+// ErrNoHistory if the file does not exist.
+func Load(file string) ([]Record, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoHistory
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("error parsing %s line %d: %w", file, line, err)
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
@@ -0,0 +1,221 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/scan"
+)
+
+// record creates a record of a repository with the given file statuses
+func record(repo string, at time.Time, score int, files map[string]string) Record {
+	r := Record{Time: at, Repository: "/src/" + repo, Name: repo, Commit: at.Format("0102"), Score: score, Passed: true}
+	for _, path := range []string{"LICENSE.md", "README.md", "SECURITY.md"} {
+		if status, ok := files[path]; ok {
+			r.Files = append(r.Files, File{Path: path, Priority: config.PriorityMustHave, Status: status})
+		}
+	}
+	return r
+}
+
+func TestNewRecord(t *testing.T) {
+	result := scan.NewResult("/src/api", []checker.ValidationResult{
+		{Requirement: config.FileRequirement{Path: "README.md", Priority: config.PriorityMustHave}, Exists: true},
+		{Requirement: config.FileRequirement{Path: "LICENSE.md", Priority: config.PriorityMustHave}},
+		{Requirement: config.FileRequirement{Path: "SECURITY.md", Priority: config.PriorityShouldHave}, Waived: true},
+		{Requirement: config.FileRequirement{Path: "AUTHORS", Priority: config.PriorityNiceToHave}, Error: errors.New("permission denied")},
+	})
+
+	r := NewRecord(result, "abc123", time.Date(2026, 3, 2, 10, 30, 15, 500, time.FixedZone("CET", 3600)))
+	if r.Repository != "/src/api" || r.Name != "api" || r.Commit != "abc123" || r.Passed {
+		t.Errorf("Unexpected record: %+v", r)
+	}
+	if want := time.Date(2026, 3, 2, 9, 30, 15, 0, time.UTC); !r.Time.Equal(want) || r.Time.Location() != time.UTC {
+		t.Errorf("Expected time %v, got %v", want, r.Time)
+	}
+
+	want := map[string]string{"README.md": StatusPresent, "LICENSE.md": StatusMissing, "SECURITY.md": StatusWaived, "AUTHORS": StatusError}
+	for path, status := range want {
+		if got := r.Status(path); got != status {
+			t.Errorf("Expected %s to be %s, got %q", path, status, got)
+		}
+	}
+	if got := r.Status("CHANGELOG.md"); got != "" {
+		t.Errorf("Expected unchecked file to have no status, got %q", got)
+	}
+}
+
+func TestAppendLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "state", FileName)
+
+	if _, err := Load(file); !errors.Is(err, ErrNoHistory) {
+		t.Fatalf("Expected ErrNoHistory, got %v", err)
+	}
+
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	first := record("api", monday, 50, map[string]string{"README.md": StatusPresent})
+	second := record("web", monday, 100, map[string]string{"README.md": StatusPresent})
+	third := record("api", monday.Add(time.Hour), 100, map[string]string{"README.md": StatusPresent})
+
+	if err := Append(file, first, second); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := Append(file, third); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	records, err := Load(file)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := []Record{first, second, third}; !reflect.DeepEqual(records, want) {
+		t.Errorf("Expected %+v, got %+v", want, records)
+	}
+
+	if err := os.WriteFile(file, []byte("{\"time\": \"yesterday\"}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write history: %v", err)
+	}
+	if _, err := Load(file); err == nil {
+		t.Error("Expected an error for a malformed record")
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	records := []Record{
+		record("web", monday, 50, map[string]string{"README.md": StatusPresent, "LICENSE.md": StatusMissing, "SECURITY.md": StatusMissing}),
+		record("api", monday, 100, map[string]string{"README.md": StatusPresent, "LICENSE.md": StatusPresent}),
+		record("web", monday.Add(2*day), 75, map[string]string{"README.md": StatusPresent, "LICENSE.md": StatusPresent, "SECURITY.md": StatusMissing}),
+		record("api", monday.Add(7*day), 50, map[string]string{"README.md": StatusPresent, "LICENSE.md": StatusMissing}),
+		record("web", monday.Add(8*day), 100, map[string]string{"README.md": StatusPresent, "LICENSE.md": StatusPresent, "SECURITY.md": StatusPresent}),
+		record("api", monday.Add(9*day), 50, map[string]string{"README.md": StatusPresent, "LICENSE.md": StatusMissing}),
+	}
+
+	report := Analyze(records)
+
+	t.Run("trends", func(t *testing.T) {
+		if len(report.Repositories) != 2 {
+			t.Fatalf("Expected 2 repositories, got %d", len(report.Repositories))
+		}
+		api, web := report.Repositories[0], report.Repositories[1]
+		if api.Name != "api" || api.Runs != 3 || api.FirstScore != 100 || api.Score != 50 || api.Change != -50 {
+			t.Errorf("Unexpected trend of api: %+v", api)
+		}
+		if web.Name != "web" || web.Change != 50 || !web.Latest.Equal(monday.Add(8*day)) {
+			t.Errorf("Unexpected trend of web: %+v", web)
+		}
+	})
+
+	t.Run("weeks", func(t *testing.T) {
+		week := WeekStart(monday)
+		want := []Week{
+			{Start: week, Repositories: 2, Passed: 2, Score: 88},
+			{Start: week.Add(7 * day), Repositories: 2, Passed: 2, Score: 75},
+		}
+		if !reflect.DeepEqual(report.Weeks, want) {
+			t.Errorf("Expected %+v, got %+v", want, report.Weeks)
+		}
+	})
+
+	t.Run("regressions", func(t *testing.T) {
+		if len(report.Regressions) != 1 {
+			t.Fatalf("Expected 1 regression, got %+v", report.Regressions)
+		}
+		r := report.Regressions[0]
+		if r.Name != "api" || r.Path != "LICENSE.md" || !r.LastPresent.Equal(monday) || !r.MissingSince.Equal(monday.Add(7*day)) || r.Commit != "0309" {
+			t.Errorf("Unexpected regression: %+v", r)
+		}
+	})
+
+	t.Run("regressions through errors", func(t *testing.T) {
+		records := []Record{
+			record("api", monday, 100, map[string]string{"LICENSE.md": StatusPresent, "SECURITY.md": StatusPresent}),
+			record("api", monday.Add(day), 50, map[string]string{"LICENSE.md": StatusError, "SECURITY.md": StatusMissing}),
+			record("api", monday.Add(2*day), 0, map[string]string{"LICENSE.md": StatusMissing, "SECURITY.md": StatusError}),
+			record("api", monday.Add(3*day), 0, map[string]string{"LICENSE.md": StatusMissing, "SECURITY.md": StatusMissing}),
+		}
+
+		regressions := Analyze(records).Regressions
+		if len(regressions) != 2 {
+			t.Fatalf("Expected 2 regressions, got %+v", regressions)
+		}
+		want := map[string]time.Time{"LICENSE.md": monday.Add(2 * day), "SECURITY.md": monday.Add(day)}
+		for _, r := range regressions {
+			if !r.LastPresent.Equal(monday) || !r.MissingSince.Equal(want[r.Path]) {
+				t.Errorf("Unexpected regression: %+v", r)
+			}
+		}
+	})
+
+	t.Run("time to fix", func(t *testing.T) {
+		want := []FixTime{
+			{Path: "LICENSE.md", Fixed: 1, Open: 1, Mean: 2 * day, Median: 2 * day},
+			{Path: "SECURITY.md", Fixed: 1, Mean: 8 * day, Median: 8 * day},
+		}
+		if !reflect.DeepEqual(report.TimeToFix, want) {
+			t.Errorf("Expected %+v, got %+v", want, report.TimeToFix)
+		}
+	})
+}
+
+func TestFilter(t *testing.T) {
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	records := []Record{
+		record("api", monday, 50, nil),
+		record("web", monday, 50, nil),
+		record("api", monday.Add(48*time.Hour), 50, nil),
+	}
+
+	if got := Filter(records, "api", time.Time{}); len(got) != 2 {
+		t.Errorf("Expected 2 records of api, got %d", len(got))
+	}
+	if got := Filter(records, "/src/web", time.Time{}); len(got) != 1 {
+		t.Errorf("Expected 1 record of /src/web, got %d", len(got))
+	}
+	if got := Filter(records, "", monday.Add(time.Hour)); len(got) != 1 {
+		t.Errorf("Expected 1 record since monday, got %d", len(got))
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"", time.Time{}},
+		{"2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"720h", now.Add(-720 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseSince(tt.value, now)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := ParseSince("last week", now); err == nil {
+		t.Error("Expected an error for an invalid value")
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	sunday := time.Date(2026, 3, 8, 23, 0, 0, 0, time.UTC)
+	if got, want := WeekStart(sunday), time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
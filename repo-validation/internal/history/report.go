@@ -0,0 +1,326 @@
+package history
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report summarises the history of many repositories
+type Report struct {
+	// Repositories are the score trends of each repository, ordered by path
+	Repositories []Trend
+	// Weeks are the aggregate scores of each week, oldest first
+	Weeks []Week
+	// Regressions are files that used to be present and are missing in the latest record
+	Regressions []Regression
+	// TimeToFix is how long each requirement stayed missing before it was added, ordered by path
+	TimeToFix []FixTime
+}
+
+// Trend is the score trend of a repository
+type Trend struct {
+	// Repository is the absolute path of the repository
+	Repository string
+	// Name is the name of the repository
+	Name string
+	// Runs is the number of records of the repository
+	Runs int
+	// First is when the repository was first recorded
+	First time.Time
+	// Latest is when the repository was last recorded
+	Latest time.Time
+	// FirstScore is the score of the first record
+	FirstScore int
+	// Score is the score of the latest record
+	Score int
+	// Change is the score change between the first and the latest record
+	Change int
+	// Passed indicates the latest record passed
+	Passed bool
+	// Commit is the commit of the latest record
+	Commit string
+}
+
+// Week is the aggregate of the latest record of every repository recorded in a week
+type Week struct {
+	// Start is the Monday the week starts on, in UTC
+	Start time.Time
+	// Repositories is the number of repositories recorded that week
+	Repositories int
+	// Passed is the number of those repositories that passed
+	Passed int
+	// Score is the average score of those repositories
+	Score int
+}
+
+// Regression is a file that used to be present in a repository and is missing now
+type Regression struct {
+	// Repository is the absolute path of the repository
+	Repository string
+	// Name is the name of the repository
+	Name string
+	// Path is the path of the missing file
+	Path string
+	// Priority is the priority of the requirement
+	Priority string
+	// LastPresent is when the file was last recorded as present
+	LastPresent time.Time
+	// MissingSince is when the file was first recorded as missing again
+	MissingSince time.Time
+	// Commit is the first commit the file was recorded as missing in
+	Commit string
+}
+
+// FixTime is how long a requirement stays missing before repositories add the file
+type FixTime struct {
+	// Path is the path of the required file
+	Path string
+	// Fixed is the number of times a missing file was added
+	Fixed int
+	// Open is the number of repositories the file is missing in now
+	Open int
+	// Mean is the mean time between a file first being recorded as missing and as present
+	Mean time.Duration
+	// Median is the median of those times
+	Median time.Duration
+}
+
+// Filter returns the records of the repository matching repo by name or path, or of every
+// repository if repo is empty, recorded at or after since
+func Filter(records []Record, repo string, since time.Time) []Record {
+	var filtered []Record
+	for _, r := range records {
+		if repo != "" && r.Name != repo && r.Repository != repo {
+			continue
+		}
+		if r.Time.Before(since) {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	return filtered
+}
+
+// Analyze computes trends, regressions and time-to-fix from records
+func Analyze(records []Record) *Report {
+	repos := byRepository(records)
+	report := &Report{}
+
+	fixes := map[string][]time.Duration{}
+	open := map[string]int{}
+	for _, history := range repos {
+		report.Repositories = append(report.Repositories, trend(history))
+		report.Regressions = append(report.Regressions, regressions(history)...)
+		timeToFix(history, fixes, open)
+	}
+
+	var paths []string
+	for path := range fixes {
+		paths = append(paths, path)
+	}
+	for path := range open {
+		if _, ok := fixes[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		report.TimeToFix = append(report.TimeToFix, fixTime(path, fixes[path], open[path]))
+	}
+
+	report.Weeks = weeks(records)
+	return report
+}
+
+// byRepository groups records by repository in chronological order, ordered by path
+func byRepository(records []Record) [][]Record {
+	index := map[string]int{}
+	var repos [][]Record
+	for _, r := range records {
+		i, ok := index[r.Repository]
+		if !ok {
+			i = len(repos)
+			index[r.Repository] = i
+			repos = append(repos, nil)
+		}
+		repos[i] = append(repos[i], r)
+	}
+
+	for _, history := range repos {
+		sort.SliceStable(history, func(i, j int) bool { return history[i].Time.Before(history[j].Time) })
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i][0].Repository < repos[j][0].Repository })
+	return repos
+}
+
+// trend summarises the chronological records of a repository
+func trend(history []Record) Trend {
+	first, latest := history[0], history[len(history)-1]
+	return Trend{
+		Repository: latest.Repository,
+		Name:       latest.Name,
+		Runs:       len(history),
+		First:      first.Time,
+		Latest:     latest.Time,
+		FirstScore: first.Score,
+		Score:      latest.Score,
+		Change:     latest.Score - first.Score,
+		Passed:     latest.Passed,
+		Commit:     latest.Commit,
+	}
+}
+
+// regressions returns the files missing in the latest record of a repository that were present
+// right before they went missing
+func regressions(history []Record) []Regression {
+	latest := history[len(history)-1]
+
+	var result []Regression
+	for _, f := range latest.Files {
+		if f.Status != StatusMissing {
+			continue
+		}
+
+		// Walk back to the start of the current missing streak, which errors do not interrupt
+		start, prev := len(history)-1, len(history)-2
+	walk:
+		for ; prev >= 0; prev-- {
+			switch history[prev].Status(f.Path) {
+			case StatusMissing:
+				start = prev
+			case StatusError:
+			default:
+				break walk
+			}
+		}
+		if prev < 0 || history[prev].Status(f.Path) != StatusPresent {
+			continue
+		}
+
+		result = append(result, Regression{
+			Repository:   latest.Repository,
+			Name:         latest.Name,
+			Path:         f.Path,
+			Priority:     f.Priority,
+			LastPresent:  history[prev].Time,
+			MissingSince: history[start].Time,
+			Commit:       history[start].Commit,
+		})
+	}
+	return result
+}
+
+// timeToFix adds the time each file of a repository stayed missing until it was present to
+// fixes, and counts the files still missing in open. Errors do not interrupt a missing streak,
+// waiving a file or no longer checking it ends the streak without a fix.
+func timeToFix(history []Record, fixes map[string][]time.Duration, open map[string]int) {
+	missingSince := map[string]time.Time{}
+	for _, r := range history {
+		checked := map[string]bool{}
+		for _, f := range r.Files {
+			checked[f.Path] = true
+			since, missing := missingSince[f.Path]
+			switch f.Status {
+			case StatusMissing:
+				if !missing {
+					missingSince[f.Path] = r.Time
+				}
+			case StatusPresent:
+				if missing {
+					fixes[f.Path] = append(fixes[f.Path], r.Time.Sub(since))
+					delete(missingSince, f.Path)
+				}
+			case StatusWaived:
+				delete(missingSince, f.Path)
+			}
+		}
+		for path := range missingSince {
+			if !checked[path] {
+				delete(missingSince, path)
+			}
+		}
+	}
+
+	for path := range missingSince {
+		open[path]++
+	}
+}
+
+// fixTime computes the mean and median of the times a requirement took to fix
+func fixTime(path string, durations []time.Duration, open int) FixTime {
+	ft := FixTime{Path: path, Fixed: len(durations), Open: open}
+	if len(durations) == 0 {
+		return ft
+	}
+
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+	ft.Mean = sum / time.Duration(len(sorted))
+
+	mid := len(sorted) / 2
+	ft.Median = sorted[mid]
+	if len(sorted)%2 == 0 {
+		ft.Median = (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return ft
+}
+
+// weeks aggregates the latest record of every repository in each week
+func weeks(records []Record) []Week {
+	latest := map[time.Time]map[string]Record{}
+	for _, r := range records {
+		start := WeekStart(r.Time)
+		if latest[start] == nil {
+			latest[start] = map[string]Record{}
+		}
+		if prev, ok := latest[start][r.Repository]; !ok || !r.Time.Before(prev.Time) {
+			latest[start][r.Repository] = r
+		}
+	}
+
+	var result []Week
+	for start, repos := range latest {
+		week := Week{Start: start, Repositories: len(repos)}
+		sum := 0
+		for _, r := range repos {
+			if r.Passed {
+				week.Passed++
+			}
+			sum += r.Score
+		}
+		week.Score = int(math.Round(float64(sum) / float64(len(repos))))
+		result = append(result, week)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Start.Before(result[j].Start) })
+	return result
+}
+
+// WeekStart returns midnight UTC of the Monday of the week t falls in
+func WeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// ParseSince parses a date (2006-01-02) or a duration before now (720h) into the earliest
+// time records are kept from
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(strings.TrimPrefix(value, "-"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(-d), nil
+}
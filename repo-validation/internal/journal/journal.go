@@ -0,0 +1,227 @@
+package journal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dir is the directory journals are stored in, relative to the repository root
+const Dir = ".repo-validation/journal"
+
+// Actions recorded in a journal
+const (
+	ActionCreated  = "created"
+	ActionModified = "modified"
+)
+
+// ErrNoJournal is returned when there is no journal to undo
+var ErrNoJournal = errors.New("no journal to undo")
+
+// Journal is the audit record of a set of changes applied to a repository
+type Journal struct {
+	// ID identifies the journal, journals sort chronologically by ID
+	ID string `json:"id"`
+	// CreatedAt is when the changes were applied
+	CreatedAt time.Time `json:"createdAt"`
+	// UndoneAt is when the changes were reverted, if they were
+	UndoneAt *time.Time `json:"undoneAt,omitempty"`
+	// Entries are the files that were written, in the order they were written
+	Entries []Entry `json:"entries"`
+	// CreatedDirs are the directories that were created, parents first
+	CreatedDirs []string `json:"createdDirs,omitempty"`
+
+	root string
+}
+
+// Entry records a single file written by a journal
+type Entry struct {
+	// Path is the path of the file, relative to the repository root, using forward slashes
+	Path string `json:"path"`
+	// Action is either created or modified
+	Action string `json:"action"`
+	// OldSHA256 is the checksum of the content before the change, for modified files
+	OldSHA256 string `json:"oldSha256,omitempty"`
+	// NewSHA256 is the checksum of the content written
+	NewSHA256 string `json:"newSha256"`
+	// Old is the content before the change, for modified files, so it can be restored
+	Old []byte `json:"old,omitempty"`
+	// Mode is the permission bits of modified files, kept when writing and restoring them
+	Mode fs.FileMode `json:"mode,omitempty"`
+}
+
+// perm returns the permission bits to write the file with, 0644 for created files and
+// journals written before modes were recorded
+func (e Entry) perm() fs.FileMode {
+	if e.Mode == 0 {
+		return 0644
+	}
+	return e.Mode
+}
+
+// Checksum returns the hex-encoded SHA-256 checksum of data
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Paths returns the paths of the journal entries
+func (j *Journal) Paths() []string {
+	paths := make([]string, 0, len(j.Entries))
+	for _, entry := range j.Entries {
+		paths = append(paths, entry.Path)
+	}
+	return paths
+}
+
+// file returns the location of the journal file
+func (j *Journal) file() string {
+	return filepath.Join(j.root, Dir, j.ID+".json")
+}
+
+// Save writes the journal to the journal directory
+func (j *Journal) Save() error {
+	dir := filepath.Join(j.root, Dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating journal directory: %w", err)
+	}
+
+	// Journals are local state and should not be committed
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		if err := os.WriteFile(ignore, []byte("*\n"), 0644); err != nil {
+			return fmt.Errorf("error writing %s: %w", ignore, err)
+		}
+	}
+
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling journal: %w", err)
+	}
+
+	return WriteFileAtomic(j.file(), append(data, '\n'), 0644)
+}
+
+// Load reads a journal file
+func Load(root, file string) (*Journal, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var j Journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("error parsing journal %s: %w", file, err)
+	}
+	j.root = root
+
+	return &j, nil
+}
+
+// List returns all journals of a repository, oldest first
+func List(root string) ([]*Journal, error) {
+	entries, err := os.ReadDir(filepath.Join(root, Dir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	journals := make([]*Journal, 0, len(names))
+	for _, name := range names {
+		j, err := Load(root, filepath.Join(root, Dir, name))
+		if err != nil {
+			return nil, err
+		}
+		journals = append(journals, j)
+	}
+
+	return journals, nil
+}
+
+// Last returns the most recent journal that has not been undone
+func Last(root string) (*Journal, error) {
+	journals, err := List(root)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(journals) - 1; i >= 0; i-- {
+		if journals[i].UndoneAt == nil {
+			return journals[i], nil
+		}
+	}
+
+	return nil, ErrNoJournal
+}
+
+// Undo reverts the most recent journal of a repository: created files are removed and
+// modified files are restored. Files changed since the journal was written are only
+// reverted when force is set.
+func Undo(root string, force bool) (*Journal, error) {
+	j, err := Last(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if !force {
+		for _, entry := range j.Entries {
+			content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(entry.Path)))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			if err != nil || Checksum(content) != entry.NewSHA256 {
+				return nil, fmt.Errorf("%s has changed since journal %s was written, use --force to undo anyway", entry.Path, j.ID)
+			}
+		}
+	}
+
+	for i := len(j.Entries) - 1; i >= 0; i-- {
+		entry := j.Entries[i]
+		target := filepath.Join(root, filepath.FromSlash(entry.Path))
+
+		switch entry.Action {
+		case ActionCreated:
+			if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("error removing %s: %w", entry.Path, err)
+			}
+		case ActionModified:
+			if err := WriteFileAtomic(target, entry.Old, entry.perm()); err != nil {
+				return nil, fmt.Errorf("error restoring %s: %w", entry.Path, err)
+			}
+		}
+	}
+	removeEmptyDirs(root, j.CreatedDirs)
+
+	now := time.Now().UTC()
+	j.UndoneAt = &now
+	if err := j.Save(); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// removeEmptyDirs removes the given directories, deepest first, if they are empty
+func removeEmptyDirs(root string, dirs []string) {
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(filepath.Join(root, filepath.FromSlash(dirs[i]))) // fails harmlessly if not empty
+	}
+}
@@ -0,0 +1,165 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommitAndUndo(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "README.md"), []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := Begin(root)
+	tx.Stage("README.md", []byte("new\n"))
+	tx.Stage("docs/guide/intro.md", []byte("intro\n"))
+
+	j, err := tx.Commit()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	t.Run("writes files", func(t *testing.T) {
+		content, _ := os.ReadFile(filepath.Join(root, "README.md"))
+		if string(content) != "new\n" {
+			t.Errorf("Expected README.md to be rewritten, got %q", content)
+		}
+		if _, err := os.Stat(filepath.Join(root, "docs", "guide", "intro.md")); err != nil {
+			t.Errorf("Expected docs/guide/intro.md to exist, got %v", err)
+		}
+	})
+
+	t.Run("records journal", func(t *testing.T) {
+		last, err := Last(root)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if last.ID != j.ID || len(last.Entries) != 2 {
+			t.Fatalf("Expected journal %s with 2 entries, got %s with %d", j.ID, last.ID, len(last.Entries))
+		}
+		if last.Entries[0].Action != ActionModified || last.Entries[0].OldSHA256 != Checksum([]byte("old\n")) {
+			t.Errorf("Expected README.md to be recorded as modified, got %+v", last.Entries[0])
+		}
+		if last.Entries[1].Action != ActionCreated || last.Entries[1].NewSHA256 != Checksum([]byte("intro\n")) {
+			t.Errorf("Expected intro.md to be recorded as created, got %+v", last.Entries[1])
+		}
+		if len(last.CreatedDirs) != 2 {
+			t.Errorf("Expected 2 created directories, got %v", last.CreatedDirs)
+		}
+	})
+
+	t.Run("undo refuses changed files", func(t *testing.T) {
+		path := filepath.Join(root, "docs", "guide", "intro.md")
+		if err := os.WriteFile(path, []byte("edited\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Undo(root, false); err == nil {
+			t.Errorf("Expected error for changed file, got nil")
+		}
+		if err := os.WriteFile(path, []byte("intro\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("undo reverts", func(t *testing.T) {
+		undone, err := Undo(root, false)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if undone.ID != j.ID {
+			t.Errorf("Expected journal %s to be undone, got %s", j.ID, undone.ID)
+		}
+
+		content, _ := os.ReadFile(filepath.Join(root, "README.md"))
+		if string(content) != "old\n" {
+			t.Errorf("Expected README.md to be restored, got %q", content)
+		}
+		if _, err := os.Stat(filepath.Join(root, "docs")); !os.IsNotExist(err) {
+			t.Errorf("Expected docs to be removed, got %v", err)
+		}
+		if _, err := Undo(root, false); err != ErrNoJournal {
+			t.Errorf("Expected ErrNoJournal, got %v", err)
+		}
+	})
+}
+
+func TestCommitRollback(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "README.md"), []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "blocker"), []byte("file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := Begin(root)
+	tx.Stage("README.md", []byte("new\n"))
+	tx.Stage("a/b.txt", []byte("b\n"))
+	tx.Stage("blocker/c.txt", []byte("c\n")) // parent is a file, so staging fails
+
+	if _, err := tx.Commit(); err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+
+	content, _ := os.ReadFile(filepath.Join(root, "README.md"))
+	if string(content) != "old\n" {
+		t.Errorf("Expected README.md to be unchanged, got %q", content)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("Expected only README.md and blocker to remain, got %v", names)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "file.txt")
+
+	for _, content := range []string{"first\n", "second\n"} {
+		if err := WriteFileAtomic(name, []byte(content), 0644); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		got, _ := os.ReadFile(name)
+		if string(got) != content {
+			t.Errorf("Expected %q, got %q", content, got)
+		}
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(name))
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files to remain, got %d entries", len(entries))
+	}
+}
+
+func TestCommitKeepsMode(t *testing.T) {
+	root := t.TempDir()
+	script := filepath.Join(root, "setup.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := Begin(root)
+	tx.Stage("setup.sh", []byte("#!/bin/sh\nset -e\n"))
+	if _, err := tx.Commit(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if info, _ := os.Stat(script); info.Mode().Perm() != 0755 {
+		t.Errorf("Expected setup.sh to stay executable, got %v", info.Mode())
+	}
+
+	if _, err := Undo(root, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if info, _ := os.Stat(script); info.Mode().Perm() != 0755 {
+		t.Errorf("Expected setup.sh to be restored executable, got %v", info.Mode())
+	}
+}
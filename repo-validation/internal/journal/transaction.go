@@ -0,0 +1,199 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WriteFileAtomic writes data to a temporary file next to name and renames it into place,
+// so readers never observe a partially written file
+func WriteFileAtomic(name string, data []byte, perm fs.FileMode) error {
+	tmp, err := writeTemp(name, data, perm)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+// writeTemp writes data to a temporary file in the directory of name and returns its path
+func writeTemp(name string, data []byte, perm fs.FileMode) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := os.Chmod(f.Name(), perm); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// staged is a file waiting to be committed
+type staged struct {
+	path    string
+	content []byte
+	temp    string
+}
+
+// Transaction stages file writes and commits them all or none
+type Transaction struct {
+	root   string
+	staged []staged
+}
+
+// Begin starts a transaction for the repository at root
+func Begin(root string) *Transaction {
+	return &Transaction{root: root}
+}
+
+// Stage adds a file write to the transaction, path is relative to the repository root
+func (t *Transaction) Stage(path string, content []byte) {
+	t.staged = append(t.staged, staged{path: filepath.ToSlash(path), content: content})
+}
+
+// Commit writes every staged file to a temporary file, then renames them into place and
+// records the journal. If any step fails, all changes made so far are rolled back and the
+// repository is left as it was.
+func (t *Transaction) Commit() (*Journal, error) {
+	j := &Journal{
+		ID:        time.Now().UTC().Format("20060102T150405.000000000Z"),
+		CreatedAt: time.Now().UTC(),
+		root:      t.root,
+	}
+	if len(t.staged) == 0 {
+		return j, nil
+	}
+
+	// Stage every file next to its target
+	for i := range t.staged {
+		s := &t.staged[i]
+		target := filepath.Join(t.root, filepath.FromSlash(s.path))
+
+		created, err := mkdirAll(t.root, filepath.Dir(target))
+		j.CreatedDirs = append(j.CreatedDirs, created...)
+		if err != nil {
+			t.rollback(j, nil)
+			return nil, fmt.Errorf("error creating directory for %s: %w", s.path, err)
+		}
+
+		entry := Entry{Path: s.path, Action: ActionCreated, NewSHA256: Checksum(s.content)}
+		old, err := os.ReadFile(target)
+		if err == nil {
+			info, err := os.Stat(target)
+			if err != nil {
+				t.rollback(j, nil)
+				return nil, fmt.Errorf("error reading %s: %w", s.path, err)
+			}
+			entry.Action = ActionModified
+			entry.Old = old
+			entry.OldSHA256 = Checksum(old)
+			entry.Mode = info.Mode().Perm()
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			t.rollback(j, nil)
+			return nil, fmt.Errorf("error reading %s: %w", s.path, err)
+		}
+
+		if s.temp, err = writeTemp(target, s.content, entry.perm()); err != nil {
+			t.rollback(j, nil)
+			return nil, fmt.Errorf("error staging %s: %w", s.path, err)
+		}
+		j.Entries = append(j.Entries, entry)
+	}
+
+	// Move the staged files into place
+	var applied []Entry
+	for i, s := range t.staged {
+		target := filepath.Join(t.root, filepath.FromSlash(s.path))
+		if err := os.Rename(s.temp, target); err != nil {
+			t.rollback(j, applied)
+			return nil, fmt.Errorf("error writing %s: %w", s.path, err)
+		}
+		t.staged[i].temp = ""
+		applied = append(applied, j.Entries[i])
+	}
+
+	if err := j.Save(); err != nil {
+		t.rollback(j, applied)
+		return nil, fmt.Errorf("error writing journal: %w", err)
+	}
+
+	return j, nil
+}
+
+// rollback removes staged temporary files, reverts applied entries and removes created directories
+func (t *Transaction) rollback(j *Journal, applied []Entry) {
+	for _, s := range t.staged {
+		if s.temp != "" {
+			os.Remove(s.temp)
+		}
+	}
+
+	for i := len(applied) - 1; i >= 0; i-- {
+		entry := applied[i]
+		target := filepath.Join(t.root, filepath.FromSlash(entry.Path))
+		if entry.Action == ActionCreated {
+			os.Remove(target)
+		} else {
+			WriteFileAtomic(target, entry.Old, entry.perm())
+		}
+	}
+
+	removeEmptyDirs(t.root, j.CreatedDirs)
+}
+
+// mkdirAll creates dir and any missing parents, returning the directories it created
+// relative to root, parents first
+func mkdirAll(root, dir string) ([]string, error) {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		missing = append(missing, d)
+		if d == root || d == filepath.Dir(d) {
+			break
+		}
+	}
+
+	var created []string
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Mkdir(missing[i], 0755); err != nil {
+			return created, err
+		}
+		rel, err := filepath.Rel(root, missing[i])
+		if err != nil {
+			return created, err
+		}
+		created = append(created, filepath.ToSlash(rel))
+	}
+
+	return created, nil
+}
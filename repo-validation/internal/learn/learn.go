@@ -0,0 +1,326 @@
+package learn
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/managed"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+	"github.com/LarsArtmann/templates/repo-validation/internal/templates"
+	"gopkg.in/yaml.v3"
+)
+
+// MaxTemplateSize is the size above which files are not captured as templates
+const MaxTemplateSize = 1 << 20
+
+// Sources of a learned file
+const (
+	// SourceCore means the file is a requirement checked in every repository
+	SourceCore = "core"
+	// SourceGroup means the file is a requirement of an optional file group
+	SourceGroup = "group"
+	// SourceExtra means the file follows a known convention without a built-in requirement
+	SourceExtra = "extra"
+)
+
+// ErrExists is returned when the output would overwrite an existing policy file
+var ErrExists = errors.New("policy file already exists")
+
+// extras are well-known files without a built-in requirement, with the proposed priority
+var extras = []config.FileRequirement{
+	{Path: "CHANGELOG.md", Category: config.CategoryGeneral, Priority: config.PriorityShouldHave, Description: "Notable changes of each release"},
+	{Path: "SUPPORT.md", Category: config.CategoryPublic, Priority: config.PriorityNiceToHave, Description: "Where to get help with the project"},
+	{Path: "GOVERNANCE.md", Category: config.CategoryPublic, Priority: config.PriorityNiceToHave, Description: "How decisions about the project are made"},
+	{Path: ".gitattributes", Category: config.CategoryGeneral, Priority: config.PriorityNiceToHave, Description: "Git attributes such as line endings and linguist overrides"},
+	{Path: ".pre-commit-config.yaml", Category: config.CategoryGeneral, Priority: config.PriorityNiceToHave, Description: "Hooks run by pre-commit before each commit"},
+	{Path: "renovate.json", Category: config.CategoryGeneral, Priority: config.PriorityNiceToHave, Description: "Renovate dependency update configuration"},
+	{Path: ".golangci.yml", Category: config.CategoryGeneral, Priority: config.PriorityNiceToHave, Description: "golangci-lint configuration"},
+	{Path: ".nvmrc", Category: config.CategoryJavaScript, Priority: config.PriorityNiceToHave, Description: "Node.js version used by the project"},
+	{Path: ".tool-versions", Category: config.CategoryGeneral, Priority: config.PriorityNiceToHave, Description: "Tool versions used by asdf and mise"},
+	{Path: "Makefile", Category: config.CategoryGeneral, Priority: config.PriorityNiceToHave, Description: "Common development tasks"},
+	{Path: "Justfile", Category: config.CategoryGeneral, Priority: config.PriorityNiceToHave, Description: "Common development tasks"},
+}
+
+// File is a file found in the golden repository
+type File struct {
+	// Requirement is the proposed requirement of the file
+	Requirement config.FileRequirement
+	// Source is core, group or extra
+	Source string
+	// Template is the name of the captured template, "" if the file was not captured
+	Template string
+	// Note explains why a file was not captured, if it was not
+	Note string
+}
+
+// Result is a policy and templates learned from a golden repository
+type Result struct {
+	// Name is the name of the golden repository
+	Name string
+	// Policy is the proposed policy
+	Policy *policy.Policy
+	// Files are the files found in the golden repository, in requirement order
+	Files []File
+	// Templates maps template file paths, relative to the template directory, to their content
+	Templates map[string][]byte
+}
+
+// Learn inventories the files of a golden repository that match a built-in requirement or a
+// known convention, proposes a priority for each and captures them as templates. Core files
+// the golden repository does not have are waived.
+func Learn(repoPath string) (*Result, error) {
+	fsys := os.DirFS(repoPath)
+
+	existing, err := policy.Load(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(repoPath)
+	r := &Result{
+		Name:      name,
+		Policy:    &policy.Policy{Variables: existing.Variables},
+		Templates: map[string][]byte{},
+	}
+
+	core := map[string]bool{}
+	for _, req := range config.GetCoreFiles() {
+		core[req.Path] = true
+	}
+
+	all := &config.Config{}
+	config.WithFileGroup("all", true)(all)
+	candidates := append(config.GetAllFileRequirements(all), extras...)
+
+	for _, req := range candidates {
+		source := SourceExtra
+		if core[req.Path] {
+			source = SourceCore
+		} else if !isExtra(req.Path) {
+			source = SourceGroup
+		}
+
+		stat, err := fs.Stat(fsys, req.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			if source == SourceCore {
+				r.Policy.Waivers = append(r.Policy.Waivers, policy.Waiver{
+					Path:   req.Path,
+					Reason: fmt.Sprintf("not present in the golden repository %s", name),
+				})
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		file := File{Requirement: req, Source: source}
+		if err := r.capture(fsys, &file, stat.IsDir()); err != nil {
+			return nil, err
+		}
+		r.Files = append(r.Files, file)
+
+		// Core files only need a policy entry when their template changes
+		builtin := templates.Normalize(req.TemplatePath)
+		if req.TemplatePath == "" {
+			builtin = ""
+		}
+		if source == SourceCore && file.Template == builtin {
+			continue
+		}
+
+		// Other files are added by the policy when their group is not enabled, so they name
+		// their template even if it is the built-in one
+		pr := policy.Requirement{Path: req.Path, Priority: req.Priority}
+		if source != SourceCore {
+			pr.Category, pr.Description, pr.Template = req.Category, req.Description, file.Template
+		} else if file.Template != builtin {
+			pr.Template = file.Template
+		}
+		r.Policy.Requirements = append(r.Policy.Requirements, pr)
+	}
+
+	return r, nil
+}
+
+// isExtra reports whether a path is one of the known conventions without a built-in requirement
+func isExtra(p string) bool {
+	for _, extra := range extras {
+		if extra.Path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// capture captures a file or directory of the golden repository as a template. Files that
+// cannot be captured keep their built-in template, if any, and get a note.
+func (r *Result) capture(fsys fs.FS, file *File, isDir bool) error {
+	req := file.Requirement
+	name := templates.Normalize(req.Path)
+	if req.TemplatePath != "" {
+		name = templates.Normalize(req.TemplatePath)
+	}
+	keep := ""
+	if req.TemplatePath != "" {
+		keep = name
+	}
+
+	if isDir {
+		files, err := r.captureTree(fsys, req.Path, name)
+		if err != nil {
+			return err
+		}
+		if files == 0 {
+			file.Template, file.Note = keep, "directory has no text files"
+			return nil
+		}
+		file.Template = name
+		return nil
+	}
+
+	content, note, err := r.read(fsys, req.Path)
+	if err != nil {
+		return err
+	}
+	if note != "" {
+		file.Template, file.Note = keep, note
+		return nil
+	}
+
+	// Only the managed block of managed files is owned by the template
+	if req.Managed {
+		body, found, err := managed.Content(content)
+		if err != nil || !found {
+			file.Template, file.Note = keep, "no managed block to capture"
+			return nil
+		}
+		content = body
+	}
+
+	r.Templates[name] = r.template(content)
+	file.Template = name
+	return nil
+}
+
+// captureTree captures every text file below dir as a directory template and returns the
+// number of files captured
+func (r *Result) captureTree(fsys fs.FS, dir, name string) (int, error) {
+	captured := 0
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel := strings.TrimPrefix(p, dir+"/")
+		// File names are rendered as templates too
+		if strings.Contains(rel, "{{") {
+			return nil
+		}
+
+		content, note, err := r.read(fsys, p)
+		if err != nil || note != "" {
+			return err
+		}
+
+		r.Templates[path.Join(name, rel+templates.TemplateExt)] = r.template(content)
+		captured++
+		return nil
+	})
+	return captured, err
+}
+
+// read reads a file to capture, or returns a note why it is not captured
+func (r *Result) read(fsys fs.FS, p string) ([]byte, string, error) {
+	stat, err := fs.Stat(fsys, p)
+	if err != nil {
+		return nil, "", err
+	}
+	if !stat.Mode().IsRegular() {
+		return nil, "not a regular file", nil
+	}
+	if stat.Size() > MaxTemplateSize {
+		return nil, "file too large to capture", nil
+	}
+
+	content, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return nil, "", err
+	}
+	if bytes.IndexByte(content, 0) >= 0 {
+		return nil, "binary file", nil
+	}
+	return content, "", nil
+}
+
+// template turns the content of a golden file into a template: template actions are escaped
+// and the name of the golden repository is replaced with the repository name variable
+func (r *Result) template(content []byte) []byte {
+	escaped := strings.ReplaceAll(string(content), "{{", `{{ "{{" }}`)
+
+	if len(r.Name) >= 3 {
+		pattern := regexp.MustCompile(`\b` + regexp.QuoteMeta(r.Name) + `\b`)
+		var b strings.Builder
+		last := 0
+		for _, loc := range pattern.FindAllStringIndex(escaped, -1) {
+			b.WriteString(escaped[last:loc[0]])
+			b.WriteString("{{ .RepoName }}")
+			last = loc[1]
+		}
+		b.WriteString(escaped[last:])
+		escaped = b.String()
+	}
+
+	return []byte(escaped)
+}
+
+// Write writes the policy file and the templates into dir, laid out like a repository root
+// so the directory can be copied into any repository. It refuses to overwrite an existing
+// policy file unless force is set, and returns the paths written relative to dir.
+func (r *Result) Write(dir string, force bool) ([]string, error) {
+	policyFile := filepath.Join(dir, policy.FileName)
+	if _, err := os.Stat(policyFile); err == nil && !force {
+		return nil, fmt.Errorf("%w: %s", ErrExists, policyFile)
+	}
+
+	data, err := yaml.Marshal(r.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling policy: %w", err)
+	}
+	header := fmt.Sprintf("# Learned from the golden repository %s by repo-validate policy learn.\n# Review the priorities, waivers and templates in %s before committing.\n", r.Name, templates.RepoTemplateDir)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(policyFile, append([]byte(header), data...), 0644); err != nil {
+		return nil, err
+	}
+	written := []string{policy.FileName}
+
+	names := make([]string, 0, len(r.Templates))
+	for name := range r.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		target := filepath.Join(dir, templates.RepoTemplateDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(target, r.Templates[name], 0644); err != nil {
+			return nil, err
+		}
+		written = append(written, path.Join(templates.RepoTemplateDir, name))
+	}
+
+	return written, nil
+}
@@ -0,0 +1,152 @@
+package learn
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/managed"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+)
+
+// writeFiles creates files below dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestLearn(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "golden-service")
+	writeFiles(t, golden, map[string]string{
+		"README.md":                            "# golden-service\n\nRun `make {{target}}`.\n",
+		"LICENSE.md":                           "MIT License\n",
+		"SECURITY.md":                          "Report issues to security@example.com\n",
+		".gitignore":                           "bin/\n",
+		".editorconfig":                        string(managed.Wrap([]byte("root = true\n"))),
+		"AUTHORS":                              "Jane Doe\n",
+		"Dockerfile":                           "FROM scratch\n",
+		"CHANGELOG.md":                         "# Changelog\n",
+		".github/workflows/ci.yml":             "name: golden-service CI\n",
+		".github/ISSUE_TEMPLATE/bug_report.md": "Describe the bug\n",
+		".github/logo.png":                     "\x89PNG\x00\x00",
+	})
+
+	r, err := Learn(golden)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	t.Run("templates", func(t *testing.T) {
+		want := map[string]string{
+			"README.md.tmpl":                                 "# {{ .RepoName }}\n\nRun `make {{ \"{{\" }}target}}`.\n",
+			"AUTHORS.tmpl":                                   "Jane Doe\n",
+			".editorconfig.tmpl":                             "root = true\n",
+			"Dockerfile.tmpl":                                "FROM scratch\n",
+			"CHANGELOG.md.tmpl":                              "# Changelog\n",
+			".github.tmpl/workflows/ci.yml.tmpl":             "name: {{ .RepoName }} CI\n",
+			".github.tmpl/ISSUE_TEMPLATE/bug_report.md.tmpl": "Describe the bug\n",
+		}
+		for name, content := range want {
+			if got := string(r.Templates[name]); got != content {
+				t.Errorf("Expected template %s to be %q, got %q", name, content, got)
+			}
+		}
+		if _, ok := r.Templates[".github.tmpl/logo.png.tmpl"]; ok {
+			t.Error("Expected binary files not to be captured")
+		}
+		if _, ok := r.Templates[".gitignore.tmpl"]; ok {
+			t.Error("Expected a managed file without managed block not to be captured")
+		}
+	})
+
+	t.Run("policy", func(t *testing.T) {
+		reqs := map[string]policy.Requirement{}
+		for _, req := range r.Policy.Requirements {
+			reqs[req.Path] = req
+		}
+
+		// Core files with a built-in template need no entry
+		if _, ok := reqs["README.md"]; ok {
+			t.Errorf("Expected no requirement for README.md, got %+v", reqs["README.md"])
+		}
+		if req := reqs["AUTHORS"]; req.Priority != config.PriorityShouldHave || req.Template != "AUTHORS.tmpl" {
+			t.Errorf("Expected AUTHORS to use the captured template, got %+v", req)
+		}
+		if req := reqs["Dockerfile"]; req.Priority != config.PriorityMustHave || req.Category != config.CategoryDocker || req.Template != "Dockerfile.tmpl" {
+			t.Errorf("Expected Dockerfile to be required, got %+v", req)
+		}
+		if req := reqs["CHANGELOG.md"]; req.Priority != config.PriorityShouldHave || req.Template != "CHANGELOG.md.tmpl" {
+			t.Errorf("Expected CHANGELOG.md to be required, got %+v", req)
+		}
+		if req, ok := reqs[".github"]; !ok || req.Template != ".github.tmpl" {
+			t.Errorf("Expected .github to be required with its template, got %+v", req)
+		}
+		if _, ok := reqs["package.json"]; ok {
+			t.Error("Expected no requirement for files the golden repository does not have")
+		}
+
+		if w := r.Policy.Waiver("CODEOWNERS"); w == nil || !strings.Contains(w.Reason, "golden-service") {
+			t.Errorf("Expected missing core files to be waived, got %v", w)
+		}
+		if w := r.Policy.Waiver("Dockerfile"); w != nil {
+			t.Errorf("Expected no waiver for present files, got %v", w)
+		}
+	})
+
+	t.Run("write and validate", func(t *testing.T) {
+		out := t.TempDir()
+		written, err := r.Write(out, false)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if written[0] != policy.FileName || len(written) != len(r.Templates)+1 {
+			t.Errorf("Expected the policy file and every template, got %v", written)
+		}
+
+		if _, err := r.Write(out, false); !errors.Is(err, ErrExists) {
+			t.Errorf("Expected ErrExists, got %v", err)
+		}
+
+		// A repository with the learned policy and templates renders the golden files
+		repo := filepath.Join(t.TempDir(), "payments")
+		if err := os.CopyFS(repo, os.DirFS(out)); err != nil {
+			t.Fatalf("Failed to copy output: %v", err)
+		}
+		pol, err := policy.Load(repo)
+		if err != nil {
+			t.Fatalf("Expected the learned policy to load, got %v", err)
+		}
+
+		chk := checker.NewChecker(&config.Config{RepoPath: repo, Fix: true, Policy: pol})
+		results, err := chk.CheckRepository()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, _, err := chk.FixMissingFiles(results); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		for name, want := range map[string]string{
+			"README.md":                "# payments\n\nRun `make {{target}}`.\n",
+			"Dockerfile":               "FROM scratch\n",
+			".github/workflows/ci.yml": "name: payments CI\n",
+		} {
+			got, err := os.ReadFile(filepath.Join(repo, name))
+			if err != nil || string(got) != want {
+				t.Errorf("Expected %s to be %q, got %q (%v)", name, want, got, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package lock
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the lock file, relative to the repository root
+const FileName = ".repo-validation/lock.yaml"
+
+// Drift statuses of a generated file
+const (
+	// DriftCurrent means neither the file nor its template changed since it was generated
+	DriftCurrent = "current"
+	// DriftEdited means the file was edited, but its template did not change
+	DriftEdited = "edited"
+	// DriftBehind means the template changed, but the file was not edited
+	DriftBehind = "behind"
+	// DriftDiverged means both the file and its template changed
+	DriftDiverged = "diverged"
+)
+
+// Lock records the template every generated file was rendered from
+type Lock struct {
+	// Files maps the path of each generated file, using forward slashes, to its stamp
+	Files map[string]Entry `yaml:"files"`
+}
+
+// Entry stamps a generated file with the template it was rendered from
+type Entry struct {
+	// Requirement is the path of the requirement the file was generated for
+	Requirement string `yaml:"requirement"`
+	// Template is the name of the template, or of the directory template containing it
+	Template string `yaml:"template"`
+	// Source is the kind of source the template was resolved from
+	Source string `yaml:"source"`
+	// Version is the version of the template pack the template came from, or a content hash
+	// of the template for other sources
+	Version string `yaml:"version,omitempty"`
+	// Base is the content originally rendered, used as the common ancestor when upgrading
+	Base string `yaml:"base"`
+}
+
+// New creates an empty lock
+func New() *Lock {
+	return &Lock{Files: map[string]Entry{}}
+}
+
+// Load reads the lock file from the repository root, an absent file yields an empty lock
+func Load(repoPath string) (*Lock, error) {
+	return LoadFS(os.DirFS(repoPath))
+}
+
+// LoadFS reads the lock file from the root of a repository tree, an absent file yields an
+// empty lock
+func LoadFS(fsys fs.FS) (*Lock, error) {
+	data, err := fs.ReadFile(fsys, FileName)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return New(), nil
+		}
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// Parse decodes a lock document
+func Parse(data []byte) (*Lock, error) {
+	l := New()
+	if err := yaml.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", FileName, err)
+	}
+	if l.Files == nil {
+		l.Files = map[string]Entry{}
+	}
+	return l, nil
+}
+
+// Marshal encodes the lock
+func (l *Lock) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(l); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Paths returns the stamped file paths in sorted order
+func (l *Lock) Paths() []string {
+	paths := make([]string, 0, len(l.Files))
+	for p := range l.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Drift compares the current content of a file and the current rendering of its template
+// against the stamped base
+func Drift(entry Entry, current, rendered []byte) string {
+	edited := string(current) != entry.Base
+	behind := string(rendered) != entry.Base
+
+	switch {
+	case edited && behind:
+		return DriftDiverged
+	case behind:
+		return DriftBehind
+	case edited:
+		return DriftEdited
+	default:
+		return DriftCurrent
+	}
+}
+
+// Worse returns the drift status that needs more attention
+func Worse(a, b string) string {
+	rank := map[string]int{"": 0, DriftCurrent: 1, DriftEdited: 2, DriftBehind: 3, DriftDiverged: 4}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
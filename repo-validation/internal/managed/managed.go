@@ -0,0 +1,110 @@
+package managed
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Markers delimiting the region of a file owned by repo-validation
+const (
+	Begin = "# BEGIN repo-validation"
+	End   = "# END repo-validation"
+)
+
+// shortEnd is also accepted as the end marker when reading files
+const shortEnd = "# END"
+
+// Find locates the managed block in content and returns the byte offsets of its body, the
+// lines between the markers. found is false if content has no managed block.
+func Find(content []byte) (start, end int, found bool, err error) {
+	begin := -1
+	offset := 0
+
+	for offset < len(content) {
+		lineEnd := bytes.IndexByte(content[offset:], '\n')
+		next := len(content)
+		if lineEnd >= 0 {
+			next = offset + lineEnd + 1
+		}
+		line := string(bytes.TrimSpace(content[offset:next]))
+
+		switch {
+		case line == Begin:
+			if begin >= 0 {
+				return 0, 0, false, fmt.Errorf("nested %q marker", Begin)
+			}
+			if found {
+				return 0, 0, false, fmt.Errorf("more than one managed block")
+			}
+			begin = next
+		case (line == End || line == shortEnd) && begin >= 0:
+			start, end, found = begin, offset, true
+			begin = -1
+		}
+
+		offset = next
+	}
+
+	if begin >= 0 {
+		return 0, 0, false, fmt.Errorf("%q marker without %q", Begin, End)
+	}
+
+	return start, end, found, nil
+}
+
+// Content returns the body of the managed block in content
+func Content(content []byte) ([]byte, bool, error) {
+	start, end, found, err := Find(content)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	return content[start:end], true, nil
+}
+
+// Wrap surrounds body with the managed block markers
+func Wrap(body []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(Begin + "\n")
+	b.Write(body)
+	if len(body) > 0 && body[len(body)-1] != '\n' {
+		b.WriteByte('\n')
+	}
+	b.WriteString(End + "\n")
+	return b.Bytes()
+}
+
+// Update replaces the body of the managed block in content, leaving everything outside the
+// markers untouched. Content without a managed block is hand-written and is not changed, Update
+// fails for it.
+func Update(content, body []byte) ([]byte, error) {
+	if len(body) > 0 && body[len(body)-1] != '\n' {
+		body = append(append([]byte(nil), body...), '\n')
+	}
+
+	start, end, found, err := Find(content)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no managed block")
+	}
+
+	var b bytes.Buffer
+	b.Write(content[:start])
+	b.Write(body)
+	b.Write(content[end:])
+	return b.Bytes(), nil
+}
+
+// Matches reports whether the managed block of content has the given body
+func Matches(content, body []byte) (bool, error) {
+	current, found, err := Content(content)
+	if err != nil || !found {
+		return false, err
+	}
+
+	if len(body) > 0 && body[len(body)-1] != '\n' {
+		body = append(append([]byte(nil), body...), '\n')
+	}
+	return bytes.Equal(current, body), nil
+}
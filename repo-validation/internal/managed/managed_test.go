@@ -0,0 +1,72 @@
+package managed
+
+import (
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		body    string
+		found   bool
+		wantErr bool
+	}{
+		{name: "no block", content: "a\nb\n"},
+		{name: "block", content: "a\n" + Begin + "\nx\ny\n" + End + "\nb\n", body: "x\ny\n", found: true},
+		{name: "short end marker", content: Begin + "\nx\n# END\n", body: "x\n", found: true},
+		{name: "empty block", content: Begin + "\n" + End + "\n", found: true},
+		{name: "indented markers", content: "  " + Begin + "\nx\n  " + End, body: "x\n", found: true},
+		{name: "unterminated", content: Begin + "\nx\n", wantErr: true},
+		{name: "nested", content: Begin + "\n" + Begin + "\n" + End + "\n", wantErr: true},
+		{name: "two blocks", content: Begin + "\n" + End + "\n" + Begin + "\n" + End + "\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, found, err := Content([]byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if found != tt.found {
+				t.Errorf("Expected found to be %v, got %v", tt.found, found)
+			}
+			if string(body) != tt.body {
+				t.Errorf("Expected body %q, got %q", tt.body, body)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("replaces block only", func(t *testing.T) {
+		content := "before\n" + Begin + "\nold\n# END\nafter\n"
+		got, err := Update([]byte(content), []byte("new"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		want := "before\n" + Begin + "\nnew\n# END\nafter\n"
+		if string(got) != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("leaves files without block alone", func(t *testing.T) {
+		if got, err := Update([]byte("custom"), []byte("new\n")); err == nil {
+			t.Errorf("Expected an error for content without a managed block, got %q", got)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		got, err := Update(Wrap([]byte("old\n")), []byte("x\n"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if matches, err := Matches(got, []byte("x\n")); err != nil || !matches {
+			t.Errorf("Expected updated content to match, got %v (%v)", matches, err)
+		}
+		if matches, _ := Matches(got, []byte("y\n")); matches {
+			t.Errorf("Expected different body not to match")
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/reporter"
+	"github.com/LarsArtmann/templates/repo-validation/internal/server"
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Name is the name the server reports to clients
+const Name = "repo-validate"
+
+// Tool names
+const (
+	ToolValidate         = "validate_repository"
+	ToolListRequirements = "list_requirements"
+	ToolPreviewFix       = "preview_fix"
+	ToolApplyFix         = "apply_fix"
+)
+
+// instructions tell clients how the tools fit together
+const instructions = "Validate a repository against its standard files with validate_repository, " +
+	"see what it is validated against with list_requirements, render the file a requirement needs with preview_fix " +
+	"and write the missing files with apply_fix. Only repositories below the allowed base directories can be read, " +
+	"relative paths are resolved against the first of them."
+
+// RepositoryInput is the input of the tools that read a repository
+type RepositoryInput struct {
+	// Path is the path of the repository
+	Path string `json:"path" jsonschema:"path of the repository, absolute or relative to the first allowed base directory"`
+	// Groups are optional file groups to enable in addition to those of the policy
+	Groups []string `json:"groups,omitempty" jsonschema:"optional file groups to check in addition to those of the policy: augment, docker, typescript, devcontainer, devenv, github or all"`
+}
+
+// PreviewInput is the input of the preview_fix tool
+type PreviewInput struct {
+	RepositoryInput
+	// Requirement is the path of the requirement to render the template of
+	Requirement string `json:"requirement" jsonschema:"path of the required file, as listed by list_requirements"`
+	// Variables are template variables in addition to those of the policy
+	Variables map[string]string `json:"variables,omitempty" jsonschema:"template variables in addition to those of the policy file, e.g. {\"License\": \"MIT\"}"`
+}
+
+// FixInput is the input of the apply_fix tool
+type FixInput struct {
+	RepositoryInput
+	// Requirements are the paths of the requirements to fix, all fixable ones if empty
+	Requirements []string `json:"requirements,omitempty" jsonschema:"paths of the required files to fix, every missing or outdated file with a template if omitted"`
+	// Variables are template variables in addition to those of the policy
+	Variables map[string]string `json:"variables,omitempty" jsonschema:"template variables in addition to those of the policy file, e.g. {\"License\": \"MIT\"}"`
+}
+
+// FixResponse is the output of the apply_fix tool
+type FixResponse struct {
+	reporter.FixedFilesResult
+	// Validation is the result of validating the repository after the fix
+	Validation server.ValidateResponse `json:"validation"`
+}
+
+// tools implements the tools on top of the validation API, which resolves repositories below
+// its base directories
+type tools struct {
+	api *server.Server
+	// mu makes fixes run one at a time, so they do not write the same files concurrently. Other
+	// processes are not locked out, only one process may fix a repository at a time.
+	mu sync.Mutex
+}
+
+// NewServer creates an MCP server offering validation and fixing of the repositories below the
+// base directories of the API as tools. Tool results are the structured JSON output of the API.
+func NewServer(api *server.Server, version string) *sdk.Server {
+	t := &tools{api: api}
+	s := sdk.NewServer(&sdk.Implementation{Name: Name, Version: version}, &sdk.ServerOptions{Instructions: instructions})
+
+	local := false
+	sdk.AddTool(s, &sdk.Tool{
+		Name:        ToolValidate,
+		Description: "Validate a repository against the required files of its policy. Returns the output of repo-validate --json with the path, name and score of the repository.",
+		Annotations: &sdk.ToolAnnotations{Title: "Validate repository", ReadOnlyHint: true, OpenWorldHint: &local},
+	}, t.validate)
+	sdk.AddTool(s, &sdk.Tool{
+		Name:        ToolListRequirements,
+		Description: "List the policy file of a repository and the requirements it is validated against, with their priority, file group and template.",
+		Annotations: &sdk.ToolAnnotations{Title: "List requirements", ReadOnlyHint: true, OpenWorldHint: &local},
+	}, t.listRequirements)
+	sdk.AddTool(s, &sdk.Tool{
+		Name:        ToolPreviewFix,
+		Description: "Render the template of a requirement with the policy of a repository without writing it. Returns the content and the diff apply_fix would write, and the template variables that are not set.",
+		Annotations: &sdk.ToolAnnotations{Title: "Preview fix", ReadOnlyHint: true, OpenWorldHint: &local},
+	}, t.previewFix)
+	sdk.AddTool(s, &sdk.Tool{
+		Name:        ToolApplyFix,
+		Description: "Generate missing files and update outdated managed blocks from their templates, then validate the repository again. Fails without writing anything if template variables are not set. Undo with repo-validate fix --undo. Only one process may fix a repository at a time, do not run repo-validate fix on it concurrently.",
+		Annotations: &sdk.ToolAnnotations{Title: "Apply fix", DestructiveHint: &local, IdempotentHint: true, OpenWorldHint: &local},
+	}, t.applyFix)
+
+	return s
+}
+
+// validate implements the validate_repository tool
+func (t *tools) validate(ctx context.Context, req *sdk.CallToolRequest, in RepositoryInput) (*sdk.CallToolResult, server.ValidateResponse, error) {
+	resp, err := t.api.Validate(in.Path, in.Groups)
+	return nil, resp, err
+}
+
+// listRequirements implements the list_requirements tool
+func (t *tools) listRequirements(ctx context.Context, req *sdk.CallToolRequest, in RepositoryInput) (*sdk.CallToolResult, server.PolicyResponse, error) {
+	resp, err := t.api.Policy(in.Path, in.Groups)
+	return nil, resp, err
+}
+
+// previewFix implements the preview_fix tool
+func (t *tools) previewFix(ctx context.Context, req *sdk.CallToolRequest, in PreviewInput) (*sdk.CallToolResult, server.PreviewResponse, error) {
+	resp, err := t.api.Preview(server.PreviewRequest{
+		Path:        in.Path,
+		Requirement: in.Requirement,
+		Groups:      in.Groups,
+		Variables:   in.Variables,
+	})
+	return nil, resp, err
+}
+
+// applyFix implements the apply_fix tool
+func (t *tools) applyFix(ctx context.Context, req *sdk.CallToolRequest, in FixInput) (*sdk.CallToolResult, FixResponse, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	chk, err := t.api.Checker(in.Path, in.Groups)
+	if err != nil {
+		return nil, FixResponse{}, err
+	}
+	defer chk.Close()
+	chk.Variables = in.Variables
+
+	results, err := chk.CheckRepository()
+	if err != nil {
+		return nil, FixResponse{}, err
+	}
+	toFix, err := selectRequirements(results, in.Requirements)
+	if err != nil {
+		return nil, FixResponse{}, err
+	}
+	toFix = chk.Fixable(toFix)
+
+	// Files rendered with <Name> placeholders would have to be fixed by hand
+	var missing []string
+	for _, result := range toFix {
+		_, names, err := chk.Preview(result)
+		if err != nil {
+			return nil, FixResponse{}, err
+		}
+		missing = append(missing, names...)
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return nil, FixResponse{}, errors.NewInvalidConfigError(fmt.Sprintf("template variables %s are not set, pass them as variables or set them in the policy file", strings.Join(slices.Compact(missing), ", ")))
+	}
+
+	created, modified, err := chk.FixMissingFiles(toFix)
+	if err != nil {
+		return nil, FixResponse{}, fmt.Errorf("error fixing missing files: %w", err)
+	}
+	if created == nil {
+		created = []string{}
+	}
+
+	validation, err := t.api.Validate(in.Path, in.Groups)
+	if err != nil {
+		return nil, FixResponse{}, err
+	}
+
+	return nil, FixResponse{
+		FixedFilesResult: reporter.FixedFilesResult{CreatedFiles: created, ModifiedFiles: modified},
+		Validation:       validation,
+	}, nil
+}
+
+// selectRequirements returns the results of the requested requirements, all results if none
+// are requested
+func selectRequirements(results []checker.ValidationResult, requirements []string) ([]checker.ValidationResult, error) {
+	if len(requirements) == 0 {
+		return results, nil
+	}
+
+	var selected []checker.ValidationResult
+	for _, name := range requirements {
+		i := slices.IndexFunc(results, func(result checker.ValidationResult) bool {
+			return result.Requirement.Path == name
+		})
+		if i < 0 {
+			return nil, errors.NewInvalidConfigError(fmt.Sprintf("unknown requirement %q", name))
+		}
+		selected = append(selected, results[i])
+	}
+	return selected, nil
+}
@@ -0,0 +1,321 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+	"github.com/LarsArtmann/templates/repo-validation/internal/server"
+	"github.com/LarsArtmann/templates/repo-validation/internal/templates"
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// setupRoot creates a base directory with two repositories, one of them with a policy file
+func setupRoot(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	files := map[string]string{
+		"app/.git/HEAD":          "ref: refs/heads/main\n",
+		"app/README.md":          "# App\n",
+		"app/" + policy.FileName: "variables:\n  Org: acme\nwaivers:\n  - path: CODEOWNERS\n    reason: single maintainer\n",
+		"lib/.git/HEAD":          "ref: refs/heads/main\n",
+		"lib/README.md":          "# Lib\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+	return root
+}
+
+// connect starts the server for the repositories below root and connects an in-process client
+func connect(t *testing.T, root string) *sdk.ClientSession {
+	t.Helper()
+	api, err := server.NewServer([]string{root})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx := context.Background()
+	serverTransport, clientTransport := sdk.NewInMemoryTransports()
+	ss, err := NewServer(api, "test").Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect server: %v", err)
+	}
+	t.Cleanup(func() { ss.Close() })
+
+	cs, err := sdk.NewClient(&sdk.Implementation{Name: "test", Version: "test"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect client: %v", err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs
+}
+
+// call calls a tool and decodes its structured result into v, it returns the result so tests can
+// check for tool errors
+func call(t *testing.T, cs *sdk.ClientSession, name string, args any, v any) *sdk.CallToolResult {
+	t.Helper()
+	res, err := cs.CallTool(context.Background(), &sdk.CallToolParams{Name: name, Arguments: args})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res.IsError || v == nil {
+		return res
+	}
+
+	data, err := json.Marshal(res.StructuredContent)
+	if err != nil {
+		t.Fatalf("Failed to encode result: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	return res
+}
+
+// errorText returns the text of a tool error
+func errorText(res *sdk.CallToolResult) string {
+	var texts []string
+	for _, content := range res.Content {
+		if text, ok := content.(*sdk.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func TestListTools(t *testing.T) {
+	cs := connect(t, setupRoot(t))
+
+	res, err := cs.ListTools(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var names []string
+	for _, tool := range res.Tools {
+		names = append(names, tool.Name)
+		if tool.InputSchema == nil || tool.OutputSchema == nil {
+			t.Errorf("Expected %s to have input and output schemas", tool.Name)
+		}
+	}
+	slices.Sort(names)
+	if strings.Join(names, ",") != "apply_fix,list_requirements,preview_fix,validate_repository" {
+		t.Errorf("Expected the four tools, got %v", names)
+	}
+}
+
+func TestValidateRepository(t *testing.T) {
+	cs := connect(t, setupRoot(t))
+
+	var resp server.ValidateResponse
+	call(t, cs, ToolValidate, map[string]any{"path": "lib"}, &resp)
+	if resp.Name != "lib" || resp.Success || resp.Score == 0 {
+		t.Errorf("Expected the failed results of lib, got %+v", resp)
+	}
+	if !slices.Contains(resp.MissingMustHaveFiles, "SECURITY.md") {
+		t.Errorf("Expected SECURITY.md to be missing, got %v", resp.MissingMustHaveFiles)
+	}
+
+	t.Run("groups", func(t *testing.T) {
+		var resp server.ValidateResponse
+		call(t, cs, ToolValidate, map[string]any{"path": "lib", "groups": []string{"docker"}}, &resp)
+		if !slices.Contains(resp.MissingMustHaveFiles, "Dockerfile") {
+			t.Errorf("Expected Dockerfile to be checked, got %v", resp.MissingMustHaveFiles)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			args map[string]any
+			want string
+		}{
+			{"outside", map[string]any{"path": "/"}, "not below an allowed base directory"},
+			{"unknown group", map[string]any{"path": "lib", "groups": []string{"java"}}, "unknown group"},
+			{"missing path", map[string]any{}, "path"},
+			{"unknown argument", map[string]any{"path": "lib", "repo": "lib"}, "repo"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				res, err := cs.CallTool(context.Background(), &sdk.CallToolParams{Name: ToolValidate, Arguments: tt.args})
+				if err == nil && !res.IsError {
+					t.Fatal("Expected an error, got a result")
+				}
+				if err == nil && !strings.Contains(errorText(res), tt.want) {
+					t.Errorf("Expected the error to mention %q, got %q", tt.want, errorText(res))
+				}
+			})
+		}
+	})
+}
+
+func TestListRequirements(t *testing.T) {
+	cs := connect(t, setupRoot(t))
+
+	var resp server.PolicyResponse
+	call(t, cs, ToolListRequirements, map[string]any{"path": "app"}, &resp)
+	if resp.Policy.Variables["Org"] != "acme" || resp.Policy.Waivers["CODEOWNERS"] != "single maintainer" {
+		t.Errorf("Expected the policy of app, got %+v", resp.Policy)
+	}
+
+	i := slices.IndexFunc(resp.Requirements, func(req server.RequirementJSON) bool {
+		return req.Path == "LICENSE.md"
+	})
+	if i < 0 || resp.Requirements[i].Group != "Core" || resp.Requirements[i].Template == "" {
+		t.Errorf("Expected LICENSE.md with its group and template, got %+v", resp.Requirements)
+	}
+}
+
+func TestPreviewFix(t *testing.T) {
+	root := setupRoot(t)
+	cs := connect(t, root)
+
+	var resp server.PreviewResponse
+	call(t, cs, ToolPreviewFix, map[string]any{"path": "app", "requirement": "LICENSE.md", "variables": map[string]string{"License": "MIT"}}, &resp)
+	if !resp.Fixable || len(resp.Changes) != 1 || resp.Changes[0].Status != "new" {
+		t.Fatalf("Expected LICENSE.md to be created, got %+v", resp)
+	}
+	if !strings.Contains(resp.Changes[0].Content, "MIT License") || !strings.Contains(resp.Changes[0].Diff, "+++ b/LICENSE.md") {
+		t.Errorf("Expected the rendered license and its diff, got %+v", resp.Changes[0])
+	}
+	if _, err := os.Stat(filepath.Join(root, "app", "LICENSE.md")); !os.IsNotExist(err) {
+		t.Errorf("Expected the preview not to write LICENSE.md, got %v", err)
+	}
+
+	res := call(t, cs, ToolPreviewFix, map[string]any{"path": "app", "requirement": "nope"}, nil)
+	if !res.IsError || !strings.Contains(errorText(res), "unknown requirement") {
+		t.Errorf("Expected an unknown requirement error, got %+v", res)
+	}
+}
+
+func TestApplyFix(t *testing.T) {
+	root := setupRoot(t)
+	cs := connect(t, root)
+
+	var resp FixResponse
+	call(t, cs, ToolApplyFix, map[string]any{"path": "app", "requirements": []string{"LICENSE.md", "SECURITY.md"}}, &resp)
+	slices.Sort(resp.CreatedFiles)
+	if strings.Join(resp.CreatedFiles, ",") != "LICENSE.md,SECURITY.md" {
+		t.Fatalf("Expected LICENSE.md and SECURITY.md to be created, got %+v", resp)
+	}
+	if _, err := os.Stat(filepath.Join(root, "app", "SECURITY.md")); err != nil {
+		t.Errorf("Expected SECURITY.md to be written, got %v", err)
+	}
+	if slices.Contains(resp.Validation.MissingMustHaveFiles, "SECURITY.md") || !slices.Contains(resp.Validation.MissingMustHaveFiles, ".gitignore") {
+		t.Errorf("Expected the validation after the fix, got %v", resp.Validation.MissingMustHaveFiles)
+	}
+
+	t.Run("nothing to fix", func(t *testing.T) {
+		var resp FixResponse
+		call(t, cs, ToolApplyFix, map[string]any{"path": "app", "requirements": []string{"README.md"}}, &resp)
+		if len(resp.CreatedFiles) != 0 || len(resp.ModifiedFiles) != 0 {
+			t.Errorf("Expected nothing to be written, got %+v", resp.FixedFilesResult)
+		}
+	})
+
+	t.Run("variables", func(t *testing.T) {
+		override := filepath.Join(root, "lib", templates.RepoTemplateDir, "LICENSE.md.tmpl")
+		if err := os.MkdirAll(filepath.Dir(override), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(override, []byte("# License\n\nAsk {{ .Team }}.\n"), 0644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+
+		args := map[string]any{"path": "lib", "requirements": []string{"LICENSE.md"}}
+		res := call(t, cs, ToolApplyFix, args, nil)
+		if !res.IsError || !strings.Contains(errorText(res), "template variables Team are not set") {
+			t.Errorf("Expected a missing variable error, got %+v", res)
+		}
+		if _, err := os.Stat(filepath.Join(root, "lib", "LICENSE.md")); !os.IsNotExist(err) {
+			t.Errorf("Expected LICENSE.md not to be written, got %v", err)
+		}
+
+		args["variables"] = map[string]string{"Team": "platform"}
+		var resp FixResponse
+		call(t, cs, ToolApplyFix, args, &resp)
+		content, err := os.ReadFile(filepath.Join(root, "lib", "LICENSE.md"))
+		if err != nil || !strings.Contains(string(content), "Ask platform.") {
+			t.Errorf("Expected LICENSE.md rendered with the variables, got %q (%v)", content, err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		res := call(t, cs, ToolApplyFix, map[string]any{"path": "app", "requirements": []string{"nope"}}, nil)
+		if !res.IsError || !strings.Contains(errorText(res), "unknown requirement") {
+			t.Errorf("Expected an unknown requirement error, got %+v", res)
+		}
+
+		res = call(t, cs, ToolApplyFix, map[string]any{"path": "/"}, nil)
+		if !res.IsError {
+			t.Errorf("Expected an error for a path outside the base directory, got %+v", res)
+		}
+	})
+}
+
+func TestOutsideRoot(t *testing.T) {
+	root := setupRoot(t)
+	outside := t.TempDir()
+	template := "Secret {{ .Org }}\n"
+	manifest := fmt.Sprintf("name: acme\nversion: 1.0.0\ntemplates:\n  - name: LICENSE.md.tmpl\n    requirements: [LICENSE.md]\n    sha256: %s\n", templates.Checksum([]byte(template)))
+	files := map[string]string{
+		"secret.tmpl":                    template,
+		"pack/" + templates.ManifestName: manifest,
+		"pack/LICENSE.md.tmpl":           template,
+	}
+	for name, content := range files {
+		p := filepath.Join(outside, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	link := filepath.Join(root, "app", templates.RepoTemplateDir, "LICENSE.md.tmpl")
+	if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.tmpl"), link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	pol := "packs:\n  - path: " + filepath.Join(outside, "pack") + "\n"
+	if err := os.WriteFile(filepath.Join(root, "lib", policy.FileName), []byte(pol), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+
+	cs := connect(t, root)
+	for _, repo := range []string{"app", "lib"} {
+		t.Run(repo, func(t *testing.T) {
+			for _, name := range []string{ToolPreviewFix, ToolApplyFix} {
+				args := map[string]any{"path": repo, "requirement": "LICENSE.md"}
+				if name == ToolApplyFix {
+					args = map[string]any{"path": repo, "requirements": []string{"LICENSE.md"}}
+				}
+				res := call(t, cs, name, args, nil)
+				if !res.IsError || strings.Contains(errorText(res), "Secret") {
+					t.Errorf("Expected %s to fail without reading outside the root, got %+v", name, res)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(root, repo, "LICENSE.md")); !os.IsNotExist(err) {
+				t.Errorf("Expected LICENSE.md not to be written, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/scan"
+)
+
+// ContentType is the content type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Statuses are the statuses requirements are counted by, zero counts are written too so
+// alerts can compare them
+var Statuses = []string{checker.StatusPresent, checker.StatusMissing, checker.StatusWaived, checker.StatusError}
+
+// ErrorTypes are the types errors are counted by, the types of the errors package
+var ErrorTypes = []string{"PathError", "FileAccessError", "InvalidConfigError", "MissingMustHaveFilesError", "Other"}
+
+// Registry holds the latest results of every validated repository, the last scan and the
+// errors counted so far. It is safe for concurrent use.
+type Registry struct {
+	mu           sync.Mutex
+	repositories map[string]scan.Result
+	scanned      bool
+	scanDuration time.Duration
+	scanTime     time.Time
+	errors       map[string]int
+}
+
+// NewRegistry creates a new, empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		repositories: map[string]scan.Result{},
+		errors:       map[string]int{},
+	}
+}
+
+// ObserveRepository records the results of a repository, replacing its previous results. A
+// repository that could not be validated is counted as an error.
+func (r *Registry) ObserveRepository(result scan.Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.observe(result)
+}
+
+// ObserveScan records the results of a scan and how long it took
+func (r *Registry) ObserveScan(results []scan.Result, duration time.Duration, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, result := range results {
+		r.observe(result)
+	}
+	r.scanned = true
+	r.scanDuration = duration
+	r.scanTime = at
+}
+
+// ObserveError counts an error by its type
+func (r *Registry) ObserveError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.errors[errors.Type(err)]++
+}
+
+// observe records the results of a repository, the lock must be held
+func (r *Registry) observe(result scan.Result) {
+	r.repositories[result.Path] = result
+	if result.Error != nil {
+		r.errors[errors.Type(result.Error)]++
+	}
+	for _, res := range result.Results {
+		if res.Error != nil {
+			r.errors[errors.Type(res.Error)]++
+		}
+	}
+}
+
+// metric is a metric family with its samples
+type metric struct {
+	name    string
+	help    string
+	kind    string
+	samples []sample
+}
+
+// sample is a value of a metric with its labels
+type sample struct {
+	labels []string
+	value  float64
+}
+
+// add appends a sample with labels given as name, value pairs
+func (m *metric) add(value float64, labels ...string) {
+	m.samples = append(m.samples, sample{labels: labels, value: value})
+}
+
+// Write writes the metrics in the Prometheus text exposition format
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	metrics := r.collect()
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		if len(m.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", m.name, m.kind)
+		for _, s := range m.samples {
+			fmt.Fprintf(bw, "%s%s %s\n", m.name, formatLabels(s.labels), formatValue(s.value))
+		}
+	}
+	return bw.Flush()
+}
+
+// collect builds the metric families from the recorded results, the lock must be held
+func (r *Registry) collect() []*metric {
+	passed := &metric{name: "repo_validation_repository_passed", help: "Whether no must-have file is missing and every requirement was validated (1) or not (0)", kind: "gauge"}
+	score := &metric{name: "repo_validation_repository_score", help: "Percentage of requirements present, weighted by priority", kind: "gauge"}
+	requirements := &metric{name: "repo_validation_requirements", help: "Number of requirements of a repository by status", kind: "gauge"}
+	requirement := &metric{name: "repo_validation_requirement_status", help: "Status of a requirement of a repository, 1 for the current status", kind: "gauge"}
+	repositories := &metric{name: "repo_validation_repositories", help: "Number of validated repositories by status", kind: "gauge"}
+	duration := &metric{name: "repo_validation_scan_duration_seconds", help: "Duration of the last scan", kind: "gauge"}
+	timestamp := &metric{name: "repo_validation_scan_timestamp_seconds", help: "Time the last scan finished, in seconds since the epoch", kind: "gauge"}
+	errorCount := &metric{name: "repo_validation_errors_total", help: "Number of errors by type of the errors package", kind: "counter"}
+
+	paths := make([]string, 0, len(r.repositories))
+	for p := range r.repositories {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	counts := map[string]int{"pass": 0, "fail": 0, "error": 0}
+	for _, p := range paths {
+		result := r.repositories[p]
+		repo := []string{"repository", result.Name, "path", result.Path}
+		if result.Error != nil {
+			counts["error"]++
+			passed.add(0, repo...)
+			continue
+		}
+		if result.Passed {
+			counts["pass"]++
+		} else {
+			counts["fail"]++
+		}
+
+		passed.add(boolValue(result.Passed), repo...)
+		score.add(float64(result.Score), repo...)
+
+		byStatus := map[string]int{}
+		for _, res := range result.Results {
+			status := res.Status()
+			byStatus[status]++
+			requirement.add(1, append(repo, "requirement", res.Requirement.Path, "priority", res.Requirement.Priority, "status", status)...)
+		}
+		for _, status := range Statuses {
+			requirements.add(float64(byStatus[status]), append(repo, "status", status)...)
+		}
+	}
+	for _, status := range []string{"pass", "fail", "error"} {
+		repositories.add(float64(counts[status]), "status", status)
+	}
+
+	if r.scanned {
+		duration.add(r.scanDuration.Seconds())
+		timestamp.add(float64(r.scanTime.Unix()))
+	}
+	for _, kind := range ErrorTypes {
+		errorCount.add(float64(r.errors[kind]), "type", kind)
+	}
+
+	return []*metric{passed, score, requirements, requirement, repositories, duration, timestamp, errorCount}
+}
+
+// WriteFile writes the metrics to a file for the textfile collector of the node exporter. The
+// file is replaced atomically, so the collector never reads a partial file.
+func (r *Registry) WriteFile(name string) error {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := r.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// formatLabels formats label name, value pairs as {name="value",...}
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("{")
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// escapeLabel escapes backslashes, double quotes and line feeds in a label value
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// formatValue formats a sample value, integers without a fraction
+func formatValue(value float64) string {
+	if value == float64(int64(value)) {
+		return fmt.Sprintf("%d", int64(value))
+	}
+	return fmt.Sprintf("%g", value)
+}
+
+// boolValue converts a bool to 1 or 0
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/scan"
+)
+
+// testResults returns a scan with a complete repository, an incomplete one and one that could
+// not be validated
+func testResults() []scan.Result {
+	readme := config.FileRequirement{Path: "README.md", Priority: config.PriorityMustHave}
+	license := config.FileRequirement{Path: "LICENSE.md", Priority: config.PriorityMustHave}
+
+	return []scan.Result{
+		scan.NewResult("/src/app", []checker.ValidationResult{{Requirement: readme, Exists: true}, {Requirement: license, Exists: true}}),
+		scan.NewResult("/src/lib", []checker.ValidationResult{{Requirement: readme, Exists: true}, {Requirement: license}}),
+		{Path: "/src/gone", Name: "gone", Error: errors.NewFileAccessError("/src/gone", os.ErrNotExist)},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	registry := NewRegistry()
+	registry.ObserveScan(testResults(), 1500*time.Millisecond, time.Unix(1700000000, 0))
+	registry.ObserveError(errors.NewPathError("/etc", fmt.Errorf("not below an allowed base directory")))
+
+	var buf bytes.Buffer
+	if err := registry.Write(&buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE repo_validation_repository_score gauge\n",
+		`repo_validation_repository_score{repository="app",path="/src/app"} 100`,
+		`repo_validation_repository_passed{repository="lib",path="/src/lib"} 0`,
+		`repo_validation_repository_passed{repository="gone",path="/src/gone"} 0`,
+		`repo_validation_requirements{repository="lib",path="/src/lib",status="missing"} 1`,
+		`repo_validation_requirements{repository="lib",path="/src/lib",status="waived"} 0`,
+		`repo_validation_requirement_status{repository="lib",path="/src/lib",requirement="LICENSE.md",priority="Must-have",status="missing"} 1`,
+		`repo_validation_repositories{status="pass"} 1`,
+		`repo_validation_repositories{status="error"} 1`,
+		"repo_validation_scan_duration_seconds 1.5\n",
+		"repo_validation_scan_timestamp_seconds 1700000000\n",
+		"# TYPE repo_validation_errors_total counter\n",
+		`repo_validation_errors_total{type="FileAccessError"} 1`,
+		`repo_validation_errors_total{type="PathError"} 1`,
+		`repo_validation_errors_total{type="Other"} 0`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected the metrics to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	// Repositories that could not be validated have no score
+	if strings.Contains(out, `repo_validation_repository_score{repository="gone"`) {
+		t.Error("Expected no score for a repository that could not be validated")
+	}
+
+	t.Run("without scan", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := NewRegistry().Write(&buf); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if strings.Contains(buf.String(), "repo_validation_scan_duration_seconds") {
+			t.Errorf("Expected no scan duration before a scan, got:\n%s", buf.String())
+		}
+	})
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got, want := formatLabels([]string{"path", "C:\\src\\\"app\"\n"}), `{path="C:\\src\\\"app\"\n"}`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	registry := NewRegistry()
+	registry.ObserveScan(testResults(), time.Second, time.Now())
+
+	name := filepath.Join(t.TempDir(), "repo_validation.prom")
+	if err := registry.WriteFile(name); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	content, err := os.ReadFile(name)
+	if err != nil || !strings.Contains(string(content), "repo_validation_repository_score") {
+		t.Errorf("Expected the metrics file, got %q (%v)", content, err)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(name))
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files to be left, got %v", entries)
+	}
+}
@@ -0,0 +1,99 @@
+package patch
+
+import (
+	"strings"
+)
+
+// Conflict markers written by Merge
+const (
+	MarkerOurs   = "<<<<<<< local"
+	MarkerBase   = "======="
+	MarkerTheirs = ">>>>>>> template"
+)
+
+// Merge performs a line-based three-way merge of two texts derived from base. Changes made on
+// only one side are taken from that side, identical changes are taken once, and overlapping
+// changes are kept from both sides between conflict markers. It returns the merged text and
+// the number of conflicts.
+func Merge(base, ours, theirs string) (string, int) {
+	baseLines := splitLines(base)
+	ourLines := splitLines(ours)
+	theirLines := splitLines(theirs)
+
+	ourMatch := matches(len(baseLines), diffLines(baseLines, ourLines))
+	theirMatch := matches(len(baseLines), diffLines(baseLines, theirLines))
+
+	var b strings.Builder
+	conflicts := 0
+	i, j, k := 0, 0, 0
+
+	for i < len(baseLines) || j < len(ourLines) || k < len(theirLines) {
+		// Lines unchanged on both sides are copied as they are
+		if i < len(baseLines) && ourMatch[i] == j && theirMatch[i] == k {
+			b.WriteString(baseLines[i])
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+
+		// Find the next base line that both sides kept, the chunk before it differs
+		next := i
+		for next < len(baseLines) && (ourMatch[next] < 0 || theirMatch[next] < 0) {
+			next++
+		}
+		nextOurs, nextTheirs := len(ourLines), len(theirLines)
+		if next < len(baseLines) {
+			nextOurs, nextTheirs = ourMatch[next], theirMatch[next]
+		}
+
+		baseChunk := strings.Join(baseLines[i:next], "")
+		ourChunk := strings.Join(ourLines[j:nextOurs], "")
+		theirChunk := strings.Join(theirLines[k:nextTheirs], "")
+
+		switch {
+		case ourChunk == baseChunk:
+			b.WriteString(theirChunk)
+		case theirChunk == baseChunk || ourChunk == theirChunk:
+			b.WriteString(ourChunk)
+		default:
+			conflicts++
+			b.WriteString(MarkerOurs + "\n")
+			b.WriteString(terminate(ourChunk))
+			b.WriteString(MarkerBase + "\n")
+			b.WriteString(terminate(theirChunk))
+			b.WriteString(MarkerTheirs + "\n")
+		}
+
+		i, j, k = next, nextOurs, nextTheirs
+	}
+
+	return b.String(), conflicts
+}
+
+// matches maps each line of the original text of an edit script to the index of the same
+// line in the new text, or -1 if it was deleted
+func matches(n int, edits []edit) []int {
+	result := make([]int, n)
+	oldLine, newLine := 0, 0
+	for _, e := range edits {
+		switch e.op {
+		case ' ':
+			result[oldLine] = newLine
+			oldLine++
+			newLine++
+		case '-':
+			result[oldLine] = -1
+			oldLine++
+		case '+':
+			newLine++
+		}
+	}
+	return result
+}
+
+// terminate ensures a non-empty chunk ends with a newline so markers start on their own line
+func terminate(s string) string {
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		return s + "\n"
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package patch
+
+import (
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+
+	tests := []struct {
+		name      string
+		ours      string
+		theirs    string
+		want      string
+		conflicts int
+	}{
+		{
+			name:   "only theirs changed",
+			ours:   base,
+			theirs: "a\nB\nc\nd\ne\n",
+			want:   "a\nB\nc\nd\ne\n",
+		},
+		{
+			name:   "only ours changed",
+			ours:   "a\nb\nc\nd\nE\n",
+			theirs: base,
+			want:   "a\nb\nc\nd\nE\n",
+		},
+		{
+			name:   "separate changes",
+			ours:   "a\nb\nc\nd\nE\nf\n",
+			theirs: "A\nb\nc\nd\ne\n",
+			want:   "A\nb\nc\nd\nE\nf\n",
+		},
+		{
+			name:   "identical changes",
+			ours:   "a\nX\nc\nd\ne\n",
+			theirs: "a\nX\nc\nd\ne\n",
+			want:   "a\nX\nc\nd\ne\n",
+		},
+		{
+			name:      "overlapping changes",
+			ours:      "a\nours\nc\nd\ne\n",
+			theirs:    "a\ntheirs\nc\nd\ne\n",
+			want:      "a\n" + MarkerOurs + "\nours\n" + MarkerBase + "\ntheirs\n" + MarkerTheirs + "\nc\nd\ne\n",
+			conflicts: 1,
+		},
+		{
+			name:   "deletion and unrelated insertion",
+			ours:   "a\nc\nd\ne\n",
+			theirs: "a\nb\nc\nd\ne\nf\n",
+			want:   "a\nc\nd\ne\nf\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := Merge(base, tt.ours, tt.theirs)
+			if got != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+			if conflicts != tt.conflicts {
+				t.Errorf("Expected %d conflicts, got %d", tt.conflicts, conflicts)
+			}
+		})
+	}
+}
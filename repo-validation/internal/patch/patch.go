@@ -0,0 +1,238 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Context is the number of unchanged lines shown around each change
+const Context = 3
+
+// File is a change to a single file
+type File struct {
+	// Path is the path of the file, relative to the repository root, using forward slashes
+	Path string
+	// Old is the current content of the file, ignored for new files
+	Old []byte
+	// New is the content the file will have
+	New []byte
+	// IsNew indicates the file does not exist yet
+	IsNew bool
+}
+
+// Format returns a patch for the given files in the format produced by git diff, which can be
+// applied with git apply
+func Format(files []File) string {
+	var b strings.Builder
+	for _, f := range files {
+		b.WriteString(FormatFile(f))
+	}
+	return b.String()
+}
+
+// FormatFile returns the patch for a single file, or "" if the file does not change
+func FormatFile(f File) string {
+	old := f.Old
+	if f.IsNew {
+		old = nil
+	}
+	if !f.IsNew && string(old) == string(f.New) {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n", f.Path, f.Path)
+	if f.IsNew {
+		b.WriteString("new file mode 100644\n")
+		if len(f.New) == 0 {
+			return b.String()
+		}
+		b.WriteString("--- /dev/null\n")
+	} else {
+		fmt.Fprintf(&b, "--- a/%s\n", f.Path)
+	}
+	fmt.Fprintf(&b, "+++ b/%s\n", f.Path)
+	b.WriteString(Unified(string(old), string(f.New)))
+
+	return b.String()
+}
+
+// edit is a single line of an edit script
+type edit struct {
+	op   byte // ' ' for unchanged, '-' for deleted, '+' for inserted
+	line string
+}
+
+// Unified returns the hunks of a unified diff between two texts, without file headers
+func Unified(old, new string) string {
+	edits := diffLines(splitLines(old), splitLines(new))
+
+	var b strings.Builder
+	for _, h := range hunks(edits) {
+		b.WriteString(h)
+	}
+	return b.String()
+}
+
+// splitLines splits text into lines, each keeping its trailing newline
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes the shortest edit script from a to b using Myers' algorithm
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk the trace backwards to recover the edit script
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{op: ' ', line: a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{op: '+', line: b[y-1]})
+			} else {
+				edits = append(edits, edit{op: '-', line: a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// hunks groups an edit script into unified diff hunks with Context lines of context
+func hunks(edits []edit) []string {
+	var result []string
+
+	for start := 0; start < len(edits); {
+		// Find the next change
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+
+		// Extend the hunk while changes are close enough to share context
+		last := first
+		for i := first; i < len(edits); i++ {
+			if edits[i].op == ' ' {
+				continue
+			}
+			if i-last > 2*Context {
+				break
+			}
+			last = i
+		}
+
+		from := first - Context
+		if from < start {
+			from = start
+		}
+		if from < 0 {
+			from = 0
+		}
+		to := last + Context + 1
+		if to > len(edits) {
+			to = len(edits)
+		}
+
+		result = append(result, formatHunk(edits, from, to))
+		start = to
+	}
+
+	return result
+}
+
+// formatHunk formats edits[from:to] as a hunk, computing line numbers from the edits before it
+func formatHunk(edits []edit, from, to int) string {
+	oldLine, newLine := 0, 0
+	for _, e := range edits[:from] {
+		if e.op != '+' {
+			oldLine++
+		}
+		if e.op != '-' {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	var body strings.Builder
+	for _, e := range edits[from:to] {
+		if e.op != '+' {
+			oldCount++
+		}
+		if e.op != '-' {
+			newCount++
+		}
+		body.WriteByte(e.op)
+		body.WriteString(e.line)
+		if !strings.HasSuffix(e.line, "\n") {
+			body.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+
+	return fmt.Sprintf("@@ -%s +%s @@\n%s", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount), body.String())
+}
+
+// hunkRange formats the start and length of a hunk, an empty range starts at the preceding line
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
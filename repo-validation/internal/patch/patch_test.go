@@ -0,0 +1,135 @@
+package patch
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatFileNew(t *testing.T) {
+	got := FormatFile(File{Path: "docs/README.md", New: []byte("# Title\n\nBody\n"), IsNew: true})
+	want := `diff --git a/docs/README.md b/docs/README.md
+new file mode 100644
+--- /dev/null
++++ b/docs/README.md
+@@ -0,0 +1,3 @@
++# Title
++
++Body
+`
+	if got != want {
+		t.Errorf("Expected:\n%s\nGot:\n%s", want, got)
+	}
+}
+
+func TestFormatFileUnchanged(t *testing.T) {
+	if got := FormatFile(File{Path: "a", Old: []byte("x\n"), New: []byte("x\n")}); got != "" {
+		t.Errorf("Expected no patch for unchanged file, got %q", got)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{
+			name: "append line",
+			old:  "a\nb\n",
+			new:  "a\nb\nc\n",
+			want: "@@ -1,2 +1,3 @@\n a\n b\n+c\n",
+		},
+		{
+			name: "replace middle line with context",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "separate hunks",
+			old:  "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			new:  "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+			want: "@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n",
+		},
+		{
+			name: "missing trailing newline",
+			old:  "a\nb",
+			new:  "a\nb\n",
+			want: "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name: "delete everything",
+			old:  "a\n",
+			new:  "",
+			want: "@@ -1,1 +0,0 @@\n-a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified(tt.old, tt.new); got != tt.want {
+				t.Errorf("Expected:\n%s\nGot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatAppliesWithGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	old := "keep\nchange me\nkeep\nkeep\nkeep\nkeep\nkeep\nkeep\nend"
+	if err := os.WriteFile(filepath.Join(dir, "existing.txt"), []byte(old), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	files := []File{
+		{Path: "existing.txt", Old: []byte(old), New: []byte("keep\nchanged\nkeep\nkeep\nkeep\nkeep\nkeep\nkeep\nend\nmore\n")},
+		{Path: "nested/new.txt", New: []byte("hello\n"), IsNew: true},
+	}
+	patchFile := filepath.Join(t.TempDir(), "changes.patch")
+	if err := os.WriteFile(patchFile, []byte(Format(files)), 0644); err != nil {
+		t.Fatalf("Failed to write patch: %v", err)
+	}
+
+	cmd := exec.Command("git", "apply", "--unsafe-paths", "--directory=.", patchFile)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Expected git apply to succeed, got %v: %s", err, out)
+	}
+
+	for _, f := range files {
+		content, err := os.ReadFile(filepath.Join(dir, f.Path))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", f.Path, err)
+		}
+		if string(content) != string(f.New) {
+			t.Errorf("Expected %s to be %q, got %q", f.Path, f.New, content)
+		}
+	}
+}
+
+func TestDiffLinesRoundTrip(t *testing.T) {
+	a := strings.Split("the quick brown fox jumps over the lazy dog", " ")
+	b := strings.Split("a quick brown cat jumps over lazy dogs", " ")
+
+	var gotA, gotB []string
+	for _, e := range diffLines(a, b) {
+		if e.op != '+' {
+			gotA = append(gotA, e.line)
+		}
+		if e.op != '-' {
+			gotB = append(gotB, e.line)
+		}
+	}
+
+	if strings.Join(gotA, " ") != strings.Join(a, " ") || strings.Join(gotB, " ") != strings.Join(b, " ") {
+		t.Errorf("Expected edit script to reproduce both inputs, got %v and %v", gotA, gotB)
+	}
+}
@@ -0,0 +1,185 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the policy file, relative to the repository root
+const FileName = ".repo-validation.yaml"
+
+// Policy is the repository policy loaded from the policy file
+type Policy struct {
+	// Packs are the template packs to load into the template registry
+	Packs []PackRef `yaml:"packs,omitempty"`
+	// Variables are additional template variables, e.g. those required by pack templates
+	Variables map[string]string `yaml:"variables,omitempty"`
+	// Git configures how the git status of required files is evaluated
+	Git GitPolicy `yaml:"git,omitempty"`
+	// Waivers exempt required files the repository deliberately does not have
+	Waivers []Waiver `yaml:"waivers,omitempty"`
+	// Groups are the optional file groups checked in addition to the command line flags
+	Groups []string `yaml:"groups,omitempty"`
+	// Requirements add file requirements or override built-in ones
+	Requirements []Requirement `yaml:"requirements,omitempty"`
+}
+
+// GroupNames are the optional file groups a policy can enable, named like their command line flags
+var GroupNames = []string{"augment", "docker", "typescript", "devcontainer", "devenv", "github"}
+
+// Priorities a requirement can have, the same as the priorities of the built-in requirements
+var Priorities = []string{"Must-have", "Should-have", "Nice-to-have"}
+
+// Requirement adds a file requirement, or overrides the built-in requirement with the same path
+type Requirement struct {
+	// Path is the path of the file or directory, relative to the repository root
+	Path string `yaml:"path"`
+	// Priority is Must-have, Should-have or Nice-to-have
+	Priority string `yaml:"priority"`
+	// Category is the category of the file, General if empty for new requirements
+	Category string `yaml:"category,omitempty"`
+	// Description is a brief description of what the file is for
+	Description string `yaml:"description,omitempty"`
+	// Template is the name of the template that generates the file, e.g. CHANGELOG.md.tmpl
+	Template string `yaml:"template,omitempty"`
+}
+
+// Waiver exempts a required file, a waived file that is missing is not reported as missing
+type Waiver struct {
+	// Path is the path of the file requirement, relative to the repository root
+	Path string `yaml:"path"`
+	// Reason explains why the repository does not need the file
+	Reason string `yaml:"reason"`
+}
+
+// Untracked policies
+const (
+	// UntrackedPresent counts untracked files as present, the default
+	UntrackedPresent = "present"
+	// UntrackedMissing counts untracked files as missing, as a clean clone would
+	UntrackedMissing = "missing"
+)
+
+// GitPolicy configures how the git status of required files is evaluated
+type GitPolicy struct {
+	// Untracked is either present or missing, ignored files always count as missing
+	Untracked string `yaml:"untracked,omitempty"`
+}
+
+// PackRef references a template pack on the local filesystem
+type PackRef struct {
+	// Path is the path to a pack directory or archive, relative to the repository root
+	Path string `yaml:"path"`
+	// SHA256 is the expected checksum of a pack archive, if set it must match
+	SHA256 string `yaml:"sha256,omitempty"`
+	// Version is the expected pack version, if set it must match the manifest
+	Version string `yaml:"version,omitempty"`
+}
+
+// Load reads the policy file from the repository root, an absent file yields an empty policy
+func Load(repoPath string) (*Policy, error) {
+	return LoadFile(filepath.Join(repoPath, FileName))
+}
+
+// LoadFS reads the policy file from the root of a repository tree, an absent file yields an
+// empty policy
+func LoadFS(fsys fs.FS) (*Policy, error) {
+	data, err := fs.ReadFile(fsys, FileName)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &Policy{}, nil
+		}
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// LoadFile reads a policy file, an absent file yields an empty policy
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &Policy{}, nil
+		}
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// Parse decodes a policy document
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", FileName, err)
+	}
+
+	switch p.Git.Untracked {
+	case "", UntrackedPresent, UntrackedMissing:
+	default:
+		return nil, fmt.Errorf("error parsing %s: git.untracked must be %s or %s, got %q", FileName, UntrackedPresent, UntrackedMissing, p.Git.Untracked)
+	}
+
+	for i, pack := range p.Packs {
+		if pack.Path == "" {
+			return nil, fmt.Errorf("error parsing %s: packs[%d] has no path", FileName, i)
+		}
+	}
+
+	for i, waiver := range p.Waivers {
+		if waiver.Path == "" || waiver.Reason == "" {
+			return nil, fmt.Errorf("error parsing %s: waivers[%d] needs a path and a reason", FileName, i)
+		}
+	}
+
+	for _, group := range p.Groups {
+		if !slices.Contains(GroupNames, group) {
+			return nil, fmt.Errorf("error parsing %s: unknown group %q, expected one of %s", FileName, group, strings.Join(GroupNames, ", "))
+		}
+	}
+
+	for i, req := range p.Requirements {
+		if req.Path == "" {
+			return nil, fmt.Errorf("error parsing %s: requirements[%d] has no path", FileName, i)
+		}
+		if !slices.Contains(Priorities, req.Priority) {
+			return nil, fmt.Errorf("error parsing %s: requirements[%d] priority must be one of %s, got %q", FileName, i, strings.Join(Priorities, ", "), req.Priority)
+		}
+	}
+
+	return &p, nil
+}
+
+// Waiver returns the waiver for a requirement path, or nil if the requirement is not waived
+func (p *Policy) Waiver(path string) *Waiver {
+	if p == nil {
+		return nil
+	}
+	for i := range p.Waivers {
+		if p.Waivers[i].Path == path {
+			return &p.Waivers[i]
+		}
+	}
+	return nil
+}
+
+// HasGroup reports whether the policy enables an optional file group
+func (p *Policy) HasGroup(name string) bool {
+	return p != nil && slices.Contains(p.Groups, name)
+}
+
+// ResolvePath resolves a path from the policy file relative to the repository root
+func ResolvePath(repoPath, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(repoPath, path)
+}
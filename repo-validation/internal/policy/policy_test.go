@@ -0,0 +1,108 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("missing policy file", func(t *testing.T) {
+		p, err := Load(t.TempDir())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(p.Packs) != 0 {
+			t.Errorf("Expected empty policy, got %v", p)
+		}
+	})
+
+	t.Run("policy with packs", func(t *testing.T) {
+		dir := t.TempDir()
+		content := `packs:
+  - path: ../standards/acme.tar.gz
+    sha256: abc123
+    version: 1.2.0
+variables:
+  Org: Acme
+`
+		if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write policy: %v", err)
+		}
+
+		p, err := Load(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(p.Packs) != 1 || p.Packs[0].Path != "../standards/acme.tar.gz" || p.Packs[0].SHA256 != "abc123" || p.Packs[0].Version != "1.2.0" {
+			t.Errorf("Unexpected packs: %+v", p.Packs)
+		}
+		if p.Variables["Org"] != "Acme" {
+			t.Errorf("Expected variable Org=Acme, got %v", p.Variables)
+		}
+	})
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"invalid yaml":             "packs: [",
+		"pack without path":        "packs:\n  - sha256: abc\n",
+		"unknown untracked":        "git:\n  untracked: maybe\n",
+		"waiver without reason":    "waivers:\n  - path: CODEOWNERS\n",
+		"requirement without path": "requirements:\n  - priority: Must-have\n",
+		"unknown priority":         "requirements:\n  - path: CHANGELOG.md\n    priority: must\n",
+		"unknown group":            "groups: [docker, rust]\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse([]byte(content)); err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestWaiver(t *testing.T) {
+	p, err := Parse([]byte("waivers:\n  - path: CODEOWNERS\n    reason: single maintainer\n"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if w := p.Waiver("CODEOWNERS"); w == nil || w.Reason != "single maintainer" {
+		t.Errorf("Expected CODEOWNERS to be waived, got %v", w)
+	}
+	if w := p.Waiver("README.md"); w != nil {
+		t.Errorf("Expected README.md not to be waived, got %v", w)
+	}
+
+	var empty *Policy
+	if w := empty.Waiver("CODEOWNERS"); w != nil {
+		t.Errorf("Expected no waiver without a policy, got %v", w)
+	}
+}
+
+func TestHasGroup(t *testing.T) {
+	p, err := Parse([]byte("groups: [docker, github]\n"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !p.HasGroup("docker") || p.HasGroup("typescript") {
+		t.Errorf("Expected only the listed groups to be enabled, got %v", p.Groups)
+	}
+
+	var empty *Policy
+	if empty.HasGroup("docker") {
+		t.Error("Expected no groups without a policy")
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	if got := ResolvePath("/repo", "packs/acme.zip"); got != filepath.Join("/repo", "packs/acme.zip") {
+		t.Errorf("Expected relative path to be resolved against the repository, got %s", got)
+	}
+	if got := ResolvePath("/repo", "/opt/acme.zip"); got != "/opt/acme.zip" {
+		t.Errorf("Expected absolute path to be kept, got %s", got)
+	}
+}
@@ -0,0 +1,157 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/compare"
+)
+
+// FormatText is the plain text format of comparisons
+const FormatText = "text"
+
+// CompareJSONResult represents the JSON output of a comparison
+type CompareJSONResult struct {
+	// Before is the first side, a result file or repository path
+	Before string `json:"before"`
+	// After is the second side, a result file or repository path
+	After string `json:"after"`
+	// Counts maps each class to the number of requirements in it
+	Counts map[string]int `json:"counts"`
+	// Requirements are all compared requirements, including unchanged ones
+	Requirements []CompareJSONRequirement `json:"requirements"`
+}
+
+// CompareJSONRequirement represents the JSON output of a compared requirement
+type CompareJSONRequirement struct {
+	// Path is the path of the required file
+	Path string `json:"path"`
+	// Priority is the priority of the requirement
+	Priority string `json:"priority"`
+	// Category is the category of the requirement
+	Category string `json:"category,omitempty"`
+	// Class is fixed, regressed, unchanged, new or dropped
+	Class string `json:"class"`
+	// Before is the status on the first side
+	Before string `json:"before,omitempty"`
+	// After is the status on the second side
+	After string `json:"after,omitempty"`
+}
+
+// WriteComparison writes a comparison as text, JSON or Markdown. Unchanged requirements are
+// only listed in text and Markdown if unchanged is true, JSON always lists them.
+func WriteComparison(w io.Writer, format string, result *compare.Result, unchanged bool) error {
+	switch format {
+	case FormatText:
+		return writeComparisonText(w, result, unchanged)
+	case FormatJSON:
+		return writeComparisonJSON(w, result)
+	case FormatMarkdown:
+		return writeComparisonMarkdown(w, result, unchanged)
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s, %s, %s", format, FormatText, FormatJSON, FormatMarkdown)
+}
+
+// comparisonSummary counts the requirements of each class
+func comparisonSummary(result *compare.Result) string {
+	parts := make([]string, 0, len(compare.Classes))
+	for _, class := range compare.Classes {
+		parts = append(parts, fmt.Sprintf("%d %s", result.Count(class), class))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// listedChanges returns the changes to list, grouped by class
+func listedChanges(result *compare.Result, unchanged bool) []compare.Change {
+	var changes []compare.Change
+	for _, class := range compare.Classes {
+		if class == compare.Unchanged && !unchanged {
+			continue
+		}
+		for _, change := range result.Changes {
+			if change.Class == class {
+				changes = append(changes, change)
+			}
+		}
+	}
+	return changes
+}
+
+// statusOrDash returns the status, or "-" if the requirement does not apply
+func statusOrDash(status string) string {
+	if status == "" {
+		return "-"
+	}
+	return status
+}
+
+// writeComparisonText writes a comparison as an aligned table followed by a summary line
+func writeComparisonText(w io.Writer, result *compare.Result, unchanged bool) error {
+	fmt.Fprintf(w, "--- %s\n+++ %s\n\n", result.Before, result.After)
+
+	changes := listedChanges(result, unchanged)
+	if len(changes) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "CLASS\tFILE\tPRIORITY\tBEFORE\tAFTER")
+		for _, c := range changes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Class, c.Path, c.Priority, statusOrDash(c.Before), statusOrDash(c.After))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+
+	_, err := fmt.Fprintln(w, comparisonSummary(result))
+	return err
+}
+
+// writeComparisonJSON writes a comparison as a JSON document
+func writeComparisonJSON(w io.Writer, result *compare.Result) error {
+	out := CompareJSONResult{
+		Before:       result.Before,
+		After:        result.After,
+		Counts:       map[string]int{},
+		Requirements: make([]CompareJSONRequirement, 0, len(result.Changes)),
+	}
+	for _, class := range compare.Classes {
+		out.Counts[class] = result.Count(class)
+	}
+	for _, c := range result.Changes {
+		out.Requirements = append(out.Requirements, CompareJSONRequirement{
+			Path:     c.Path,
+			Priority: c.Priority,
+			Category: c.Category,
+			Class:    c.Class,
+			Before:   c.Before,
+			After:    c.After,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// writeComparisonMarkdown writes a comparison as a Markdown table, for pull request descriptions
+func writeComparisonMarkdown(w io.Writer, result *compare.Result, unchanged bool) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "**Before:** `%s`  \n**After:** `%s`\n\n", result.Before, result.After)
+
+	if changes := listedChanges(result, unchanged); len(changes) > 0 {
+		b.WriteString("| Class | File | Priority | Before | After |\n|---|---|---|---|---|\n")
+		for _, c := range changes {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", c.Class, escapeMarkdown(c.Path), c.Priority, statusOrDash(c.Before), statusOrDash(c.After))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(comparisonSummary(result) + "\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,65 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/compare"
+)
+
+func TestWriteComparison(t *testing.T) {
+	result := &compare.Result{Before: "template", After: "derived", Changes: []compare.Change{
+		{Path: "README.md", Priority: "Must-have", Class: compare.Unchanged, Before: "present", After: "present"},
+		{Path: "LICENSE.md", Priority: "Must-have", Class: compare.Regressed, Before: "present", After: "missing"},
+		{Path: "package.json", Priority: "Must-have", Class: compare.New, After: "present"},
+	}}
+
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteComparison(&buf, FormatText, result, false); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		out := buf.String()
+		for _, want := range []string{"--- template", "+++ derived", "regressed", "LICENSE.md", "1 regressed, 0 fixed, 1 new, 0 dropped, 1 unchanged"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Expected text to contain %q, got:\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, "README.md") {
+			t.Errorf("Expected unchanged requirements to be left out, got:\n%s", out)
+		}
+		// Regressions are listed first
+		if strings.Index(out, "LICENSE.md") > strings.Index(out, "package.json") {
+			t.Errorf("Expected regressions before new requirements, got:\n%s", out)
+		}
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteComparison(&buf, FormatMarkdown, result, true); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		out := buf.String()
+		for _, want := range []string{"| Class | File |", "| regressed | LICENSE.md | Must-have | present | missing |", "| new | package.json | Must-have | - | present |", "README.md"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Expected Markdown to contain %q, got:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteComparison(&buf, FormatJSON, result, false); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		var out CompareJSONResult
+		if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		if len(out.Requirements) != 3 || out.Counts[compare.Regressed] != 1 {
+			t.Errorf("Expected all 3 requirements and 1 regression, got %+v", out)
+		}
+	})
+}
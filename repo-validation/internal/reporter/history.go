@@ -0,0 +1,214 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/history"
+)
+
+// HistoryJSONResult represents the JSON output of the history command
+type HistoryJSONResult struct {
+	// Weeks are the aggregate scores of each week, oldest first
+	Weeks []HistoryJSONWeek `json:"weeks"`
+	// Repositories are the score trends of each repository
+	Repositories []HistoryJSONTrend `json:"repositories"`
+	// Regressions are files that used to be present and are missing now
+	Regressions []HistoryJSONRegression `json:"regressions"`
+	// TimeToFix is how long each requirement stayed missing before it was added
+	TimeToFix []HistoryJSONFixTime `json:"timeToFix"`
+}
+
+// HistoryJSONWeek represents the JSON output of a week of history
+type HistoryJSONWeek struct {
+	// Start is the Monday the week starts on
+	Start string `json:"start"`
+	// Repositories is the number of repositories recorded that week
+	Repositories int `json:"repositories"`
+	// Passed is the number of those repositories that passed
+	Passed int `json:"passed"`
+	// Score is the average score of those repositories
+	Score int `json:"score"`
+}
+
+// HistoryJSONTrend represents the JSON output of the score trend of a repository
+type HistoryJSONTrend struct {
+	// Path is the absolute path of the repository
+	Path string `json:"path"`
+	// Name is the name of the repository
+	Name string `json:"name"`
+	// Runs is the number of records of the repository
+	Runs int `json:"runs"`
+	// First is when the repository was first recorded
+	First time.Time `json:"first"`
+	// Latest is when the repository was last recorded
+	Latest time.Time `json:"latest"`
+	// FirstScore is the score of the first record
+	FirstScore int `json:"firstScore"`
+	// Score is the score of the latest record
+	Score int `json:"score"`
+	// Change is the score change between the first and the latest record
+	Change int `json:"change"`
+	// Passed indicates the latest record passed
+	Passed bool `json:"passed"`
+	// Commit is the commit of the latest record
+	Commit string `json:"commit,omitempty"`
+}
+
+// HistoryJSONRegression represents the JSON output of a regression
+type HistoryJSONRegression struct {
+	// Path is the absolute path of the repository
+	Path string `json:"path"`
+	// Name is the name of the repository
+	Name string `json:"name"`
+	// File is the path of the missing file
+	File string `json:"file"`
+	// Priority is the priority of the requirement
+	Priority string `json:"priority"`
+	// LastPresent is when the file was last recorded as present
+	LastPresent time.Time `json:"lastPresent"`
+	// MissingSince is when the file was first recorded as missing again
+	MissingSince time.Time `json:"missingSince"`
+	// Commit is the first commit the file was recorded as missing in
+	Commit string `json:"commit,omitempty"`
+}
+
+// HistoryJSONFixTime represents the JSON output of the time-to-fix of a requirement
+type HistoryJSONFixTime struct {
+	// File is the path of the required file
+	File string `json:"file"`
+	// Fixed is the number of times a missing file was added
+	Fixed int `json:"fixed"`
+	// Open is the number of repositories the file is missing in now
+	Open int `json:"open"`
+	// MeanSeconds is the mean time to fix in seconds
+	MeanSeconds int64 `json:"meanSeconds"`
+	// MedianSeconds is the median time to fix in seconds
+	MedianSeconds int64 `json:"medianSeconds"`
+}
+
+// WriteHistory writes a history report as tables or JSON
+func WriteHistory(w io.Writer, format string, report *history.Report) error {
+	switch format {
+	case FormatTable:
+		return writeHistoryTable(w, report)
+	case FormatJSON:
+		return writeHistoryJSON(w, report)
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s, %s", format, FormatTable, FormatJSON)
+}
+
+// writeHistoryTable writes a history report as aligned tables, one per section
+func writeHistoryTable(w io.Writer, report *history.Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "WEEK\tREPOSITORIES\tPASSED\tSCORE")
+	for _, week := range report.Weeks {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", week.Start.Format(time.DateOnly), week.Repositories, week.Passed, week.Score)
+	}
+
+	fmt.Fprintln(tw, "\nREPOSITORY\tRUNS\tFIRST\tLATEST\tSCORE\tCHANGE")
+	for _, t := range report.Repositories {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%d\t%+d\n", t.Name, t.Runs, t.First.Format(time.DateOnly), t.Latest.Format(time.DateOnly), t.Score, t.Change)
+	}
+
+	if len(report.Regressions) > 0 {
+		fmt.Fprintln(tw, "\nREGRESSION\tFILE\tPRIORITY\tLAST PRESENT\tMISSING SINCE\tCOMMIT")
+		for _, r := range report.Regressions {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Name, r.Path, r.Priority, r.LastPresent.Format(time.DateOnly), r.MissingSince.Format(time.DateOnly), shortHash(r.Commit))
+		}
+	}
+
+	if len(report.TimeToFix) > 0 {
+		fmt.Fprintln(tw, "\nFILE\tFIXED\tOPEN\tMEAN TIME TO FIX\tMEDIAN TIME TO FIX")
+		for _, ft := range report.TimeToFix {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", ft.Path, ft.Fixed, ft.Open, formatDuration(ft.Mean, ft.Fixed), formatDuration(ft.Median, ft.Fixed))
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d repositories, %d regressions\n", len(report.Repositories), len(report.Regressions))
+	return err
+}
+
+// formatDuration formats a time to fix in days and hours, or "-" if nothing was fixed
+func formatDuration(d time.Duration, fixed int) string {
+	if fixed == 0 {
+		return "-"
+	}
+
+	days := int(d / (24 * time.Hour))
+	hours := int((d % (24 * time.Hour)) / time.Hour)
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 || days == 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	return strings.Join(parts, " ")
+}
+
+// writeHistoryJSON writes a history report as a JSON document
+func writeHistoryJSON(w io.Writer, report *history.Report) error {
+	out := HistoryJSONResult{
+		Weeks:        make([]HistoryJSONWeek, 0, len(report.Weeks)),
+		Repositories: make([]HistoryJSONTrend, 0, len(report.Repositories)),
+		Regressions:  make([]HistoryJSONRegression, 0, len(report.Regressions)),
+		TimeToFix:    make([]HistoryJSONFixTime, 0, len(report.TimeToFix)),
+	}
+
+	for _, week := range report.Weeks {
+		out.Weeks = append(out.Weeks, HistoryJSONWeek{
+			Start:        week.Start.Format(time.DateOnly),
+			Repositories: week.Repositories,
+			Passed:       week.Passed,
+			Score:        week.Score,
+		})
+	}
+	for _, t := range report.Repositories {
+		out.Repositories = append(out.Repositories, HistoryJSONTrend{
+			Path:       t.Repository,
+			Name:       t.Name,
+			Runs:       t.Runs,
+			First:      t.First,
+			Latest:     t.Latest,
+			FirstScore: t.FirstScore,
+			Score:      t.Score,
+			Change:     t.Change,
+			Passed:     t.Passed,
+			Commit:     t.Commit,
+		})
+	}
+	for _, r := range report.Regressions {
+		out.Regressions = append(out.Regressions, HistoryJSONRegression{
+			Path:         r.Repository,
+			Name:         r.Name,
+			File:         r.Path,
+			Priority:     r.Priority,
+			LastPresent:  r.LastPresent,
+			MissingSince: r.MissingSince,
+			Commit:       r.Commit,
+		})
+	}
+	for _, ft := range report.TimeToFix {
+		out.TimeToFix = append(out.TimeToFix, HistoryJSONFixTime{
+			File:          ft.Path,
+			Fixed:         ft.Fixed,
+			Open:          ft.Open,
+			MeanSeconds:   int64(ft.Mean / time.Second),
+			MedianSeconds: int64(ft.Median / time.Second),
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,62 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/history"
+)
+
+func TestWriteHistory(t *testing.T) {
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	report := &history.Report{
+		Weeks: []history.Week{{Start: monday, Repositories: 2, Passed: 1, Score: 75}},
+		Repositories: []history.Trend{
+			{Repository: "/src/api", Name: "api", Runs: 3, First: monday, Latest: monday.Add(72 * time.Hour), FirstScore: 100, Score: 50, Change: -50},
+		},
+		Regressions: []history.Regression{
+			{Repository: "/src/api", Name: "api", Path: "LICENSE.md", Priority: "Must-have", LastPresent: monday, MissingSince: monday.Add(48 * time.Hour), Commit: "0123456789abcdef"},
+		},
+		TimeToFix: []history.FixTime{
+			{Path: "SECURITY.md", Fixed: 2, Mean: 50 * time.Hour, Median: 26 * time.Hour},
+			{Path: "LICENSE.md", Open: 1},
+		},
+	}
+
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteHistory(&buf, FormatTable, report); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		out := buf.String()
+		for _, want := range []string{"2026-03-02", "-50", "LICENSE.md", "0123456", "2d 2h", "1d 2h", "1 repositories, 1 regressions"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Expected table to contain %q, got:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteHistory(&buf, FormatJSON, report); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		var out HistoryJSONResult
+		if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		if len(out.Regressions) != 1 || out.Regressions[0].File != "LICENSE.md" {
+			t.Errorf("Expected the regression of LICENSE.md, got %+v", out.Regressions)
+		}
+		if out.TimeToFix[0].MeanSeconds != 50*3600 {
+			t.Errorf("Expected a mean of %d seconds, got %d", 50*3600, out.TimeToFix[0].MeanSeconds)
+		}
+	})
+
+	if err := WriteHistory(&bytes.Buffer{}, FormatCSV, report); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,79 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/learn"
+)
+
+// LearnJSONResult represents the JSON output of policy learn
+type LearnJSONResult struct {
+	// Repository is the name of the golden repository
+	Repository string `json:"repository"`
+	// Files are the files found in the golden repository
+	Files []LearnJSONFile `json:"files"`
+	// Waived are the core files the golden repository does not have
+	Waived []string `json:"waived,omitempty"`
+	// Written are the files written, relative to the output directory
+	Written []string `json:"written"`
+}
+
+// LearnJSONFile represents the JSON output of a file found in a golden repository
+type LearnJSONFile struct {
+	// Path is the path of the file
+	Path string `json:"path"`
+	// Priority is the proposed priority
+	Priority string `json:"priority"`
+	// Source is core, group or extra
+	Source string `json:"source"`
+	// Template is the captured template, if any
+	Template string `json:"template,omitempty"`
+	// Note explains why the file was not captured
+	Note string `json:"note,omitempty"`
+}
+
+// WriteLearned writes what policy learn found in a golden repository and the files it wrote
+func WriteLearned(w io.Writer, format string, result *learn.Result, written []string) error {
+	out := LearnJSONResult{Repository: result.Name, Files: make([]LearnJSONFile, 0, len(result.Files)), Written: written}
+	for _, f := range result.Files {
+		out.Files = append(out.Files, LearnJSONFile{
+			Path:     f.Requirement.Path,
+			Priority: f.Requirement.Priority,
+			Source:   f.Source,
+			Template: f.Template,
+			Note:     f.Note,
+		})
+	}
+	for _, waiver := range result.Policy.Waivers {
+		out.Waived = append(out.Waived, waiver.Path)
+	}
+
+	switch format {
+	case FormatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "FILE\tPRIORITY\tSOURCE\tTEMPLATE")
+		for _, f := range out.Files {
+			template := f.Template
+			if f.Note != "" {
+				template = fmt.Sprintf("%s (%s)", statusOrDash(template), f.Note)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", f.Path, f.Priority, f.Source, statusOrDash(template))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		for _, path := range out.Waived {
+			fmt.Fprintf(w, "waived %s, not present in %s\n", path, result.Name)
+		}
+		_, err := fmt.Fprintf(w, "\nWrote %d files, review them before committing\n", len(written))
+		return err
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s, %s", format, FormatTable, FormatJSON)
+}
@@ -0,0 +1,51 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/learn"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+)
+
+func TestWriteLearned(t *testing.T) {
+	result := &learn.Result{
+		Name:   "golden",
+		Policy: &policy.Policy{Waivers: []policy.Waiver{{Path: "CODEOWNERS", Reason: "not present"}}},
+		Files: []learn.File{
+			{Requirement: config.FileRequirement{Path: "README.md", Priority: config.PriorityMustHave}, Source: learn.SourceCore, Template: "README.md.tmpl"},
+			{Requirement: config.FileRequirement{Path: "logo.png", Priority: config.PriorityNiceToHave}, Source: learn.SourceExtra, Note: "binary file"},
+		},
+	}
+	written := []string{".repo-validation.yaml", ".repo-validation/templates/README.md.tmpl"}
+
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteLearned(&buf, FormatTable, result, written); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		out := buf.String()
+		for _, want := range []string{"README.md.tmpl", "- (binary file)", "waived CODEOWNERS", "Wrote 2 files"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Expected table to contain %q, got:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteLearned(&buf, FormatJSON, result, written); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		var out LearnJSONResult
+		if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		if len(out.Files) != 2 || out.Files[1].Note != "binary file" || len(out.Waived) != 1 || len(out.Written) != 2 {
+			t.Errorf("Expected files, waivers and written paths, got %+v", out)
+		}
+	})
+}
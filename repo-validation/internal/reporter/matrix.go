@@ -0,0 +1,275 @@
+package reporter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/scan"
+)
+
+// Matrix report formats, FormatCSV is shared with the scan report
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Matrix cell states
+const (
+	CellPresent = "present"
+	CellMissing = "missing"
+	CellWaived  = "waived"
+	// CellNone means the requirement was not checked in the repository
+	CellNone = ""
+)
+
+// Matrix is a compliance matrix with repositories as rows and requirements as columns
+type Matrix struct {
+	// Requirements are the columns, in the order they were first validated
+	Requirements []config.FileRequirement
+	// Rows are the repositories that were validated, in the order of the scan results
+	Rows []MatrixRow
+	// Adoption maps each requirement path to the percentage of repositories that have the
+	// file, repositories that waive it or do not check it are left out
+	Adoption map[string]int
+}
+
+// MatrixRow is a repository in a compliance matrix
+type MatrixRow struct {
+	// Name is the name of the repository
+	Name string
+	// Path is the absolute path of the repository
+	Path string
+	// Score is the score of the repository
+	Score int
+	// Cells maps each requirement path to its state in the repository
+	Cells map[string]string
+}
+
+// MatrixOption is a function that configures a Matrix
+type MatrixOption func(*matrixOptions)
+
+// matrixOptions are the requirement filters of a Matrix
+type matrixOptions struct {
+	category string
+	priority string
+}
+
+// WithCategory keeps only the requirements of a category
+func WithCategory(category string) MatrixOption {
+	return func(o *matrixOptions) {
+		o.category = category
+	}
+}
+
+// WithPriority keeps only the requirements of a priority
+func WithPriority(priority string) MatrixOption {
+	return func(o *matrixOptions) {
+		o.priority = priority
+	}
+}
+
+// NewMatrix builds a compliance matrix from the results of a scan. Repositories that could not
+// be validated are left out.
+func NewMatrix(results []scan.Result, opts ...MatrixOption) *Matrix {
+	o := &matrixOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	m := &Matrix{Adoption: map[string]int{}}
+	seen := map[string]bool{}
+	for _, result := range results {
+		if result.Error != nil {
+			continue
+		}
+
+		row := MatrixRow{Name: result.Name, Path: result.Path, Score: result.Score, Cells: map[string]string{}}
+		for _, r := range result.Results {
+			req := r.Requirement
+			if (o.category != "" && !strings.EqualFold(req.Category, o.category)) || (o.priority != "" && !strings.EqualFold(req.Priority, o.priority)) {
+				continue
+			}
+			if !seen[req.Path] {
+				seen[req.Path] = true
+				m.Requirements = append(m.Requirements, req)
+			}
+
+			switch {
+			case r.Exists && r.Error == nil:
+				row.Cells[req.Path] = CellPresent
+			case r.Waived:
+				row.Cells[req.Path] = CellWaived
+			default:
+				row.Cells[req.Path] = CellMissing
+			}
+		}
+		m.Rows = append(m.Rows, row)
+	}
+
+	for _, req := range m.Requirements {
+		present, total := 0, 0
+		for _, row := range m.Rows {
+			switch row.Cells[req.Path] {
+			case CellPresent:
+				present++
+				total++
+			case CellMissing:
+				total++
+			}
+		}
+		if total > 0 {
+			m.Adoption[req.Path] = int(math.Round(100 * float64(present) / float64(total)))
+		}
+	}
+
+	return m
+}
+
+// WriteMatrix writes a compliance matrix in the given format
+func WriteMatrix(w io.Writer, format string, m *Matrix) error {
+	switch format {
+	case FormatMarkdown:
+		return writeMatrixMarkdown(w, m)
+	case FormatHTML:
+		return writeMatrixHTML(w, m)
+	case FormatCSV:
+		return writeMatrixCSV(w, m)
+	}
+	return fmt.Errorf("unknown matrix format %q, expected one of %s, %s, %s", format, FormatMarkdown, FormatHTML, FormatCSV)
+}
+
+// cellSymbols are the symbols used for cell states in Markdown
+var cellSymbols = map[string]string{
+	CellPresent: "✅",
+	CellMissing: "❌",
+	CellWaived:  "➖",
+	CellNone:    "",
+}
+
+// writeMatrixMarkdown writes a compliance matrix as a Markdown table with an adoption row
+func writeMatrixMarkdown(w io.Writer, m *Matrix) error {
+	var b strings.Builder
+
+	b.WriteString("| Repository | Score |")
+	for _, req := range m.Requirements {
+		b.WriteString(" " + escapeMarkdown(req.Path) + " |")
+	}
+	b.WriteString("\n|---|---:|")
+	for range m.Requirements {
+		b.WriteString(":---:|")
+	}
+	b.WriteString("\n")
+
+	for _, row := range m.Rows {
+		fmt.Fprintf(&b, "| %s | %d |", escapeMarkdown(row.Name), row.Score)
+		for _, req := range m.Requirements {
+			b.WriteString(" " + cellSymbols[row.Cells[req.Path]] + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("| **Adoption** | |")
+	for _, req := range m.Requirements {
+		b.WriteString(" " + adoption(m, req.Path) + " |")
+	}
+	b.WriteString("\n\n✅ present · ❌ missing · ➖ waived\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeMarkdown escapes characters that would break a Markdown table cell
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// adoption formats the adoption percentage of a requirement, or "-" if no repository counts
+func adoption(m *Matrix, path string) string {
+	if pct, ok := m.Adoption[path]; ok {
+		return strconv.Itoa(pct) + "%"
+	}
+	return "-"
+}
+
+// matrixTemplate renders a compliance matrix as a self-contained HTML page
+var matrixTemplate = template.Must(template.New("matrix").Funcs(template.FuncMap{
+	"adoption": adoption,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Repository compliance</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: center; }
+th.repo, td.repo { text-align: left; }
+td.present { background: #d4edda; }
+td.missing { background: #f8d7da; }
+td.waived { background: #e2e3e5; }
+</style>
+</head>
+<body>
+<h1>Repository compliance</h1>
+<table>
+<thead>
+<tr><th class="repo">Repository</th><th>Score</th>{{ range .Requirements }}<th title="{{ .Priority }} · {{ .Category }}">{{ .Path }}</th>{{ end }}</tr>
+</thead>
+<tbody>
+{{- $m := . }}
+{{- range $row := .Rows }}
+<tr><td class="repo" title="{{ $row.Path }}">{{ $row.Name }}</td><td>{{ $row.Score }}</td>{{ range $m.Requirements }}{{ $cell := index $row.Cells .Path }}<td class="{{ $cell }}">{{ $cell }}</td>{{ end }}</tr>
+{{- end }}
+</tbody>
+<tfoot>
+<tr><th class="repo">Adoption</th><th></th>{{ range .Requirements }}<th>{{ adoption $m .Path }}</th>{{ end }}</tr>
+</tfoot>
+</table>
+</body>
+</html>
+`))
+
+// writeMatrixHTML writes a compliance matrix as an HTML page
+func writeMatrixHTML(w io.Writer, m *Matrix) error {
+	return matrixTemplate.Execute(w, m)
+}
+
+// writeMatrixCSV writes a compliance matrix as CSV with an adoption row
+func writeMatrixCSV(w io.Writer, m *Matrix) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"repository", "score"}
+	for _, req := range m.Requirements {
+		header = append(header, req.Path)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range m.Rows {
+		record := []string{row.Name, strconv.Itoa(row.Score)}
+		for _, req := range m.Requirements {
+			record = append(record, row.Cells[req.Path])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	record := []string{"adoption", ""}
+	for _, req := range m.Requirements {
+		record = append(record, adoption(m, req.Path))
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
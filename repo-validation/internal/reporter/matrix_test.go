@@ -0,0 +1,139 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/scan"
+)
+
+// matrixResults returns scan results of three repositories, one of which failed to validate
+func matrixResults() []scan.Result {
+	readme := config.FileRequirement{Path: "README.md", Category: config.CategoryGeneral, Priority: config.PriorityMustHave}
+	codeowners := config.FileRequirement{Path: "CODEOWNERS", Category: config.CategoryGeneral, Priority: config.PriorityShouldHave}
+	dockerfile := config.FileRequirement{Path: "Dockerfile", Category: config.CategoryDocker, Priority: config.PriorityMustHave}
+
+	return []scan.Result{
+		{Name: "api", Path: "/src/org/api", Score: 100, Results: []checker.ValidationResult{
+			{Requirement: readme, Exists: true},
+			{Requirement: codeowners, Exists: true},
+			{Requirement: dockerfile, Exists: true},
+		}},
+		{Name: "web", Path: "/src/org/web", Score: 60, Results: []checker.ValidationResult{
+			{Requirement: readme, Exists: true},
+			{Requirement: codeowners, Waived: true},
+		}},
+		{Name: "cli", Path: "/src/org/cli", Score: 50, Results: []checker.ValidationResult{
+			{Requirement: readme, Exists: false},
+			{Requirement: codeowners, Exists: false},
+		}},
+		{Name: "old", Path: "/src/org/old", Error: errors.New("permission denied")},
+	}
+}
+
+func TestNewMatrix(t *testing.T) {
+	m := NewMatrix(matrixResults())
+
+	if len(m.Rows) != 3 {
+		t.Fatalf("Expected 3 rows without the repository that failed, got %d", len(m.Rows))
+	}
+	if len(m.Requirements) != 3 {
+		t.Fatalf("Expected 3 requirements, got %d", len(m.Requirements))
+	}
+
+	tests := []struct {
+		repo  int
+		path  string
+		state string
+	}{
+		{0, "README.md", CellPresent},
+		{1, "CODEOWNERS", CellWaived},
+		{1, "Dockerfile", CellNone},
+		{2, "README.md", CellMissing},
+	}
+	for _, tt := range tests {
+		if got := m.Rows[tt.repo].Cells[tt.path]; got != tt.state {
+			t.Errorf("Expected %s of %s to be %q, got %q", tt.path, m.Rows[tt.repo].Name, tt.state, got)
+		}
+	}
+
+	// Waived and unchecked requirements do not count towards adoption
+	adoptions := map[string]int{"README.md": 67, "CODEOWNERS": 50, "Dockerfile": 100}
+	for path, want := range adoptions {
+		if got := m.Adoption[path]; got != want {
+			t.Errorf("Expected adoption of %s to be %d, got %d", path, want, got)
+		}
+	}
+
+	t.Run("filter by category", func(t *testing.T) {
+		m := NewMatrix(matrixResults(), WithCategory("docker"))
+		if len(m.Requirements) != 1 || m.Requirements[0].Path != "Dockerfile" {
+			t.Errorf("Expected only Dockerfile, got %v", m.Requirements)
+		}
+	})
+
+	t.Run("filter by priority", func(t *testing.T) {
+		m := NewMatrix(matrixResults(), WithPriority(config.PriorityShouldHave))
+		if len(m.Requirements) != 1 || m.Requirements[0].Path != "CODEOWNERS" {
+			t.Errorf("Expected only CODEOWNERS, got %v", m.Requirements)
+		}
+	})
+}
+
+func TestWriteMatrix(t *testing.T) {
+	m := NewMatrix(matrixResults())
+
+	t.Run("markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteMatrix(&buf, FormatMarkdown, m); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		out := buf.String()
+		for _, want := range []string{"| Repository | Score | README.md | CODEOWNERS | Dockerfile |", "| web | 60 | ✅ | ➖ |  |", "| **Adoption** | | 67% | 50% | 100% |"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Expected Markdown to contain %q, got:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteMatrix(&buf, FormatHTML, m); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		out := buf.String()
+		for _, want := range []string{"<td class=\"waived\">waived</td>", "<td class=\"missing\">missing</td>", "<th>67%</th>"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Expected HTML to contain %q, got:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteMatrix(&buf, FormatCSV, m); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		rows, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("Expected valid CSV, got %v", err)
+		}
+		if got := strings.Join(rows[3], ","); got != "cli,50,missing,missing," {
+			t.Errorf("Expected the row of cli, got %s", got)
+		}
+		if got := strings.Join(rows[4], ","); got != "adoption,,67%,50%,100%" {
+			t.Errorf("Expected the adoption row, got %s", got)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if err := WriteMatrix(&bytes.Buffer{}, "pdf", m); err == nil {
+			t.Errorf("Expected error for unknown format, got nil")
+		}
+	})
+}
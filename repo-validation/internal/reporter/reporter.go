@@ -8,6 +8,9 @@ import (
 	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
 	"github.com/LarsArtmann/templates/repo-validation/internal/config"
 	"github.com/LarsArtmann/templates/repo-validation/internal/exitcode"
+	"github.com/LarsArtmann/templates/repo-validation/internal/gitrepo"
+	"github.com/LarsArtmann/templates/repo-validation/internal/journal"
+	"github.com/LarsArtmann/templates/repo-validation/internal/lock"
 	"github.com/charmbracelet/log"
 )
 
@@ -32,8 +35,35 @@ type JSONResult struct {
 	MissingMustHaveFiles []string `json:"missingMustHaveFiles,omitempty"`
 	// MissingShouldHaveFiles is the list of should-have files that are missing
 	MissingShouldHaveFiles []string `json:"missingShouldHaveFiles,omitempty"`
+	// OutdatedFiles is the list of files whose managed block does not match its template
+	OutdatedFiles []string `json:"outdatedFiles,omitempty"`
+	// Git maps required files that exist but are not tracked by git to their git status
+	Git map[string]string `json:"git,omitempty"`
+	// MissingEntries maps files to the entries their content rule expects but they lack
+	MissingEntries map[string][]string `json:"missingEntries,omitempty"`
+	// Drift maps generated files to their drift status against the current templates
+	Drift map[string]string `json:"drift,omitempty"`
+	// PlaceholderFiles is the list of files that still contain the placeholder they were
+	// generated with and count as missing
+	PlaceholderFiles []string `json:"placeholderFiles,omitempty"`
+	// WaivedFiles is the list of missing files the policy exempts the repository from
+	WaivedFiles []string `json:"waivedFiles,omitempty"`
 	// Errors is the list of errors that occurred during validation
 	Errors []string `json:"errors,omitempty"`
+	// Requirements are all validated requirements with their status
+	Requirements []JSONRequirement `json:"requirements,omitempty"`
+}
+
+// JSONRequirement represents the JSON output of a validated requirement
+type JSONRequirement struct {
+	// Path is the path of the required file
+	Path string `json:"path"`
+	// Priority is the priority of the requirement
+	Priority string `json:"priority"`
+	// Category is the category of the requirement
+	Category string `json:"category,omitempty"`
+	// Status is present, missing, waived or error
+	Status string `json:"status"`
 }
 
 // ReportResults reports the validation results
@@ -53,7 +83,7 @@ func (r *Reporter) processResults(results []checker.ValidationResult) (missingMu
 			continue
 		}
 
-		if !result.Exists {
+		if !result.Exists && !result.Waived {
 			if result.Requirement.Priority == config.PriorityMustHave {
 				missingMustHave = append(missingMustHave, result.Requirement.Path)
 			} else if result.Requirement.Priority == config.PriorityShouldHave {
@@ -64,9 +94,88 @@ func (r *Reporter) processResults(results []checker.ValidationResult) (missingMu
 	return missingMustHave, missingShouldHave, errors
 }
 
+// untrackedFiles maps each required file that exists but is not tracked to its git status
+func untrackedFiles(results []checker.ValidationResult) map[string]string {
+	var untracked map[string]string
+	for _, result := range results {
+		if result.Git != gitrepo.Untracked && result.Git != gitrepo.Ignored {
+			continue
+		}
+		if untracked == nil {
+			untracked = map[string]string{}
+		}
+		untracked[result.Requirement.Path] = result.Git
+	}
+	return untracked
+}
+
+// missingEntries maps each file to the entries its content rule expects but it lacks
+func missingEntries(results []checker.ValidationResult) map[string][]string {
+	var missing map[string][]string
+	for _, result := range results {
+		if len(result.MissingEntries) == 0 {
+			continue
+		}
+		if missing == nil {
+			missing = map[string][]string{}
+		}
+		missing[result.Requirement.Path] = result.MissingEntries
+	}
+	return missing
+}
+
+// driftStatuses maps each requirement with generated files to its drift status
+func driftStatuses(results []checker.ValidationResult) map[string]string {
+	var drift map[string]string
+	for _, result := range results {
+		if result.Drift == "" {
+			continue
+		}
+		if drift == nil {
+			drift = map[string]string{}
+		}
+		drift[result.Requirement.Path] = result.Drift
+	}
+	return drift
+}
+
+// outdatedFiles returns the files whose managed block does not match its template
+func outdatedFiles(results []checker.ValidationResult) []string {
+	var outdated []string
+	for _, result := range results {
+		if result.Error == nil && result.Exists && result.Outdated {
+			outdated = append(outdated, result.Requirement.Path)
+		}
+	}
+	return outdated
+}
+
+// placeholderFiles returns the files that still contain the placeholder they were generated with
+func placeholderFiles(results []checker.ValidationResult) []string {
+	var placeholders []string
+	for _, result := range results {
+		if result.Placeholder {
+			placeholders = append(placeholders, result.Requirement.Path)
+		}
+	}
+	return placeholders
+}
+
+// waivedFiles returns the missing files the policy exempts the repository from
+func waivedFiles(results []checker.ValidationResult) []string {
+	var waived []string
+	for _, result := range results {
+		if result.Waived {
+			waived = append(waived, result.Requirement.Path)
+		}
+	}
+	return waived
+}
+
 // reportResultsConsole reports the validation results to the console
 func (r *Reporter) reportResultsConsole(results []checker.ValidationResult) error {
 	missingMustHave, missingShouldHave, errors := r.processResults(results)
+	outdated := outdatedFiles(results)
 
 	// Print summary
 	log.Info("Repository Validation Results")
@@ -94,6 +203,59 @@ func (r *Reporter) reportResultsConsole(results []checker.ValidationResult) erro
 		}
 	}
 
+	// Print missing files the policy waives
+	if waived := waivedFiles(results); len(waived) > 0 {
+		log.Info("Waived files:")
+		for _, file := range waived {
+			log.Info("  - " + file + ": " + r.Config.Policy.Waiver(file).Reason)
+		}
+	}
+
+	// Print files with outdated managed blocks
+	if len(outdated) > 0 {
+		log.Warn("Outdated managed blocks:")
+		for _, file := range outdated {
+			log.Warn("  - " + file)
+		}
+	}
+
+	// Print files that exist but would be missing from a clean clone
+	for _, result := range results {
+		switch result.Git {
+		case gitrepo.Ignored:
+			log.Warn("Ignored by git, remove it from .gitignore and git add it: " + result.Requirement.Path)
+		case gitrepo.Untracked:
+			log.Warn("Not tracked by git, git add it: " + result.Requirement.Path)
+		}
+	}
+
+	// Print files that were generated as placeholders and have to be completed by hand
+	for _, file := range placeholderFiles(results) {
+		log.Warn("Placeholder, replace it with the full text: " + file)
+	}
+
+	// Print required entries missing from files
+	missing := missingEntries(results)
+	for _, result := range results {
+		if entries, ok := missing[result.Requirement.Path]; ok {
+			log.Warn("Missing required entries in " + result.Requirement.Path + ": " + strings.Join(entries, ", "))
+		}
+	}
+
+	// Print files generated from an older template
+	var behind []string
+	for _, result := range results {
+		if result.Drift == lock.DriftBehind || result.Drift == lock.DriftDiverged {
+			behind = append(behind, fmt.Sprintf("%s (%s)", result.Requirement.Path, result.Drift))
+		}
+	}
+	if len(behind) > 0 {
+		log.Warn("Generated from an older template, run repo-validate upgrade:")
+		for _, file := range behind {
+			log.Warn("  - " + file)
+		}
+	}
+
 	// Print errors
 	if len(errors) > 0 {
 		log.Error("Errors:")
@@ -103,8 +265,8 @@ func (r *Reporter) reportResultsConsole(results []checker.ValidationResult) erro
 	}
 
 	// Print fix message
-	if (len(missingMustHave) > 0 || len(missingShouldHave) > 0) && !r.Config.Fix {
-		log.Info("Run with --fix to generate missing files")
+	if (len(missingMustHave) > 0 || len(missingShouldHave) > 0 || len(outdated) > 0 || len(missing) > 0) && !r.Config.Fix {
+		log.Info("Run with --fix to generate missing files and update managed blocks")
 	}
 
 	return nil
@@ -112,21 +274,231 @@ func (r *Reporter) reportResultsConsole(results []checker.ValidationResult) erro
 
 // reportResultsJSON reports the validation results in JSON format
 func (r *Reporter) reportResultsJSON(results []checker.ValidationResult) error {
+	jsonData, err := json.MarshalIndent(r.NewJSONResult(results), "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling JSON: %w", err)
+	}
+
+	fmt.Println(string(jsonData))
+
+	return nil
+}
+
+// NewJSONResult builds the JSON output of the validation results
+func (r *Reporter) NewJSONResult(results []checker.ValidationResult) JSONResult {
 	missingMustHave, missingShouldHave, errors := r.processResults(results)
 
-	jsonResult := JSONResult{
+	return JSONResult{
 		Success:              len(missingMustHave) == 0 && len(errors) == 0,
 		MissingMustHaveFiles: missingMustHave,
 		MissingShouldHaveFiles: missingShouldHave,
+		OutdatedFiles:        outdatedFiles(results),
+		Git:                  untrackedFiles(results),
+		MissingEntries:       missingEntries(results),
+		Drift:                driftStatuses(results),
+		PlaceholderFiles:     placeholderFiles(results),
+		WaivedFiles:          waivedFiles(results),
 		Errors:               errors,
+		Requirements:         jsonRequirements(results),
 	}
+}
 
-	jsonData, err := json.MarshalIndent(jsonResult, "", "  ")
-	if err != nil {
-		return fmt.Errorf("error marshaling JSON: %w", err)
+// jsonRequirements lists every validated requirement with its status
+func jsonRequirements(results []checker.ValidationResult) []JSONRequirement {
+	requirements := make([]JSONRequirement, 0, len(results))
+	for _, result := range results {
+		requirements = append(requirements, JSONRequirement{
+			Path:     result.Requirement.Path,
+			Priority: result.Requirement.Priority,
+			Category: result.Requirement.Category,
+			Status:   result.Status(),
+		})
 	}
+	return requirements
+}
 
-	fmt.Println(string(jsonData))
+// FixedFilesResult represents the JSON output of the files written by --fix
+type FixedFilesResult struct {
+	// CreatedFiles is the list of files that were created
+	CreatedFiles []string `json:"createdFiles"`
+	// ModifiedFiles is the list of existing files whose managed block was updated
+	ModifiedFiles []string `json:"modifiedFiles,omitempty"`
+}
+
+// ReportFixedFiles reports the files created and modified when fixing the repository
+func (r *Reporter) ReportFixedFiles(created, modified []string) error {
+	if r.Config.JSONOutput {
+		if created == nil {
+			created = []string{}
+		}
+		jsonData, err := json.MarshalIndent(FixedFilesResult{CreatedFiles: created, ModifiedFiles: modified}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling JSON: %w", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	if len(created) == 0 && len(modified) == 0 {
+		log.Info("No files were created")
+		return nil
+	}
+
+	if len(created) > 0 {
+		log.Info("Created files:")
+		for _, file := range created {
+			log.Info("  - " + file)
+		}
+	}
+
+	if len(modified) > 0 {
+		log.Info("Updated managed blocks:")
+		for _, file := range modified {
+			log.Info("  - " + file)
+		}
+	}
+
+	return nil
+}
+
+// UpgradeResult represents a file upgraded to its current template in JSON format
+type UpgradeResult struct {
+	Path      string `json:"path"`
+	Drift     string `json:"drift"`
+	Conflicts int    `json:"conflicts"`
+}
+
+// ReportUpgrades reports the generated files brought up to date with their templates
+func (r *Reporter) ReportUpgrades(upgrades []checker.Upgrade) error {
+	if r.Config.JSONOutput {
+		result := struct {
+			Upgrades []UpgradeResult `json:"upgrades"`
+		}{Upgrades: []UpgradeResult{}}
+		for _, upgrade := range upgrades {
+			result.Upgrades = append(result.Upgrades, UpgradeResult{Path: upgrade.Path, Drift: upgrade.Drift, Conflicts: upgrade.Conflicts})
+		}
+
+		jsonData, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling JSON: %w", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	if len(upgrades) == 0 {
+		log.Info("All generated files are up to date")
+		return nil
+	}
+
+	for _, upgrade := range upgrades {
+		switch {
+		case upgrade.Conflicts > 0:
+			log.Warn(fmt.Sprintf("conflict: %s (%d conflicts)", upgrade.Path, upgrade.Conflicts))
+		case upgrade.Drift == lock.DriftDiverged:
+			log.Info("merged: " + upgrade.Path)
+		default:
+			log.Info("upgraded: " + upgrade.Path)
+		}
+	}
+
+	return nil
+}
+
+// UndoResult represents the result of undoing a journal in JSON format
+type UndoResult struct {
+	Journal  string   `json:"journal"`
+	Reverted []string `json:"reverted"`
+}
+
+// ReportUndo reports the files reverted by undoing a journal
+func (r *Reporter) ReportUndo(j *journal.Journal) error {
+	if r.Config.JSONOutput {
+		jsonData, err := json.MarshalIndent(UndoResult{Journal: j.ID, Reverted: j.Paths()}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling JSON: %w", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	log.Info("Undid journal " + j.ID + ":")
+	for _, entry := range j.Entries {
+		if entry.Action == journal.ActionCreated {
+			log.Info("  removed: " + entry.Path)
+		} else {
+			log.Info("  restored: " + entry.Path)
+		}
+	}
+
+	return nil
+}
+
+// ChangeResult represents a single change in the JSON output of a fix preview
+type ChangeResult struct {
+	// Path is the path of the file
+	Path string `json:"path"`
+	// Requirement is the path of the requirement the change fixes
+	Requirement string `json:"requirement"`
+	// Status is "new" for files that would be created and "modified" for existing files
+	Status string `json:"status"`
+	// Diff is the unified diff of the change
+	Diff string `json:"diff"`
+}
+
+// ReportChanges reports the changes --fix would make as unified diffs
+func (r *Reporter) ReportChanges(changes []checker.Change) error {
+	var created, modified int
+	for _, change := range changes {
+		if change.IsNew {
+			created++
+		} else {
+			modified++
+		}
+	}
+
+	if r.Config.JSONOutput {
+		result := struct {
+			Changes []ChangeResult `json:"changes"`
+		}{Changes: []ChangeResult{}}
+
+		for _, change := range changes {
+			status := "modified"
+			if change.IsNew {
+				status = "new"
+			}
+			result.Changes = append(result.Changes, ChangeResult{
+				Path:        change.Path,
+				Requirement: change.Requirement.Path,
+				Status:      status,
+				Diff:        checker.Patch([]checker.Change{change}),
+			})
+		}
+
+		jsonData, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling JSON: %w", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	if len(changes) == 0 {
+		log.Info("No files would be changed")
+		return nil
+	}
+
+	for _, change := range changes {
+		if change.IsNew {
+			log.Info("new file: " + change.Path)
+		} else {
+			log.Info("modified: " + change.Path)
+		}
+	}
+	fmt.Println()
+	fmt.Print(checker.Patch(changes))
+	fmt.Println()
+	log.Info(fmt.Sprintf("--fix would create %d and modify %d files", created, modified))
 
 	return nil
 }
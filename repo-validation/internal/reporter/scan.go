@@ -0,0 +1,236 @@
+package reporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/scan"
+)
+
+// Scan report formats
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// ScanJSONResult represents the JSON output of a multi-repository scan
+type ScanJSONResult struct {
+	// Passed is the number of repositories that passed
+	Passed int `json:"passed"`
+	// Failed is the number of repositories that failed or could not be validated
+	Failed int `json:"failed"`
+	// Score is the average score of the repositories
+	Score int `json:"score"`
+	// Repositories are the results of each repository
+	Repositories []ScanJSONRepository `json:"repositories"`
+}
+
+// ScanJSONRepository represents the JSON output of a single repository of a scan
+type ScanJSONRepository struct {
+	// Path is the absolute path of the repository
+	Path string `json:"path"`
+	// Name is the name of the repository
+	Name string `json:"name"`
+	// Passed indicates no must-have file is missing
+	Passed bool `json:"passed"`
+	// Score is the percentage of requirements present, weighted by priority
+	Score int `json:"score"`
+	// Missing are the paths of the missing requirements
+	Missing []string `json:"missing,omitempty"`
+	// Error is the error that prevented the repository from being validated, if any
+	Error string `json:"error,omitempty"`
+}
+
+// WriteScan writes the aggregate report of a scan in the given format
+func WriteScan(w io.Writer, format string, results []scan.Result) error {
+	switch format {
+	case FormatTable:
+		return writeScanTable(w, results)
+	case FormatJSON:
+		return writeScanJSON(w, results)
+	case FormatCSV:
+		return writeScanCSV(w, results)
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s, %s, %s", format, FormatTable, FormatJSON, FormatCSV)
+}
+
+// scanStatus returns the status of a repository as shown in reports
+func scanStatus(r scan.Result) string {
+	switch {
+	case r.Error != nil:
+		return "error"
+	case r.Passed:
+		return "pass"
+	default:
+		return "fail"
+	}
+}
+
+// writeScanTable writes a scan as an aligned table followed by a summary line
+func writeScanTable(w io.Writer, results []scan.Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPOSITORY\tSTATUS\tSCORE\tMISSING")
+	for _, r := range results {
+		missing := strings.Join(r.Missing, ", ")
+		if r.Error != nil {
+			missing = r.Error.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", r.Name, scanStatus(r), r.Score, missing)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	passed, failed, score := scan.Summary(results)
+	_, err := fmt.Fprintf(w, "\n%d repositories, %d passed, %d failed, average score %d\n", len(results), passed, failed, score)
+	return err
+}
+
+// writeScanJSON writes a scan as a JSON document
+func writeScanJSON(w io.Writer, results []scan.Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewScanJSONResult(results))
+}
+
+// NewScanJSONResult builds the JSON output of a scan
+func NewScanJSONResult(results []scan.Result) ScanJSONResult {
+	passed, failed, score := scan.Summary(results)
+	out := ScanJSONResult{
+		Passed:       passed,
+		Failed:       failed,
+		Score:        score,
+		Repositories: make([]ScanJSONRepository, 0, len(results)),
+	}
+
+	for _, r := range results {
+		repo := ScanJSONRepository{
+			Path:    r.Path,
+			Name:    r.Name,
+			Passed:  r.Passed,
+			Score:   r.Score,
+			Missing: r.Missing,
+		}
+		if r.Error != nil {
+			repo.Error = r.Error.Error()
+		}
+		out.Repositories = append(out.Repositories, repo)
+	}
+	return out
+}
+
+// writeScanCSV writes a scan as CSV with one row per repository, missing requirements are
+// separated by semicolons
+func writeScanCSV(w io.Writer, results []scan.Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"repository", "path", "status", "score", "missing", "error"}); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		errMsg := ""
+		if r.Error != nil {
+			errMsg = r.Error.Error()
+		}
+		row := []string{r.Name, r.Path, scanStatus(r), strconv.Itoa(r.Score), strings.Join(r.Missing, ";"), errMsg}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ScanFixJSONResult represents the JSON output of fixing many repositories
+type ScanFixJSONResult struct {
+	// Changed is the number of repositories a commit was made in
+	Changed int `json:"changed"`
+	// Skipped is the number of repositories that were left alone
+	Skipped int `json:"skipped"`
+	// Failed is the number of repositories that could not be fixed
+	Failed int `json:"failed"`
+	// Repositories are the results of each repository
+	Repositories []ScanFixJSONRepository `json:"repositories"`
+}
+
+// ScanFixJSONRepository represents the JSON output of fixing a single repository
+type ScanFixJSONRepository struct {
+	// Path is the absolute path of the repository
+	Path string `json:"path"`
+	// Name is the name of the repository
+	Name string `json:"name"`
+	// Status is changed, skipped or error
+	Status string `json:"status"`
+	// Commit is the hash of the commit on the branch
+	Commit string `json:"commit,omitempty"`
+	// Files are the files that were committed
+	Files []string `json:"files,omitempty"`
+	// Reason is why the repository was skipped, or the error that occurred
+	Reason string `json:"reason,omitempty"`
+}
+
+// WriteScanFixes writes the report of fixing many repositories in the given format
+func WriteScanFixes(w io.Writer, format string, results []scan.FixResult) error {
+	repos := make([]ScanFixJSONRepository, 0, len(results))
+	for _, r := range results {
+		repo := ScanFixJSONRepository{Path: r.Path, Name: r.Name, Status: "changed", Commit: r.Commit, Files: r.Files}
+		switch {
+		case r.Error != nil:
+			repo.Status, repo.Reason = "error", r.Error.Error()
+		case r.Skipped != "":
+			repo.Status, repo.Reason = "skipped", r.Skipped
+		}
+		repos = append(repos, repo)
+	}
+	changed, skipped, failed := scan.FixSummary(results)
+
+	switch format {
+	case FormatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "REPOSITORY\tSTATUS\tCOMMIT\tDETAILS")
+		for _, repo := range repos {
+			details := repo.Reason
+			if repo.Status == "changed" {
+				details = strings.Join(repo.Files, ", ")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", repo.Name, repo.Status, shortHash(repo.Commit), details)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "\n%d repositories, %d changed, %d skipped, %d failed\n", len(results), changed, skipped, failed)
+		return err
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(ScanFixJSONResult{Changed: changed, Skipped: skipped, Failed: failed, Repositories: repos})
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"repository", "path", "status", "commit", "files", "reason"}); err != nil {
+			return err
+		}
+		for _, repo := range repos {
+			if err := cw.Write([]string{repo.Name, repo.Path, repo.Status, repo.Commit, strings.Join(repo.Files, ";"), repo.Reason}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s, %s, %s", format, FormatTable, FormatJSON, FormatCSV)
+}
+
+// shortHash abbreviates a commit hash for display
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
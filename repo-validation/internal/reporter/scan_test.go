@@ -0,0 +1,104 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/scan"
+)
+
+func TestWriteScan(t *testing.T) {
+	results := []scan.Result{
+		{Path: "/src/org/api", Name: "api", Passed: true, Score: 100},
+		{Path: "/src/org/web", Name: "web", Score: 60, Missing: []string{"LICENSE.md", "AUTHORS"}},
+		{Path: "/src/org/old", Name: "old", Error: errors.New("permission denied")},
+	}
+
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteScan(&buf, FormatTable, results); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		out := buf.String()
+		for _, want := range []string{"REPOSITORY", "web", "fail", "LICENSE.md, AUTHORS", "permission denied", "3 repositories, 1 passed, 2 failed, average score 53"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Expected table to contain %q, got:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteScan(&buf, FormatJSON, results); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		var out ScanJSONResult
+		if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		if out.Passed != 1 || out.Failed != 2 || len(out.Repositories) != 3 {
+			t.Errorf("Expected 1 passed, 2 failed and 3 repositories, got %+v", out)
+		}
+		if out.Repositories[2].Error != "permission denied" {
+			t.Errorf("Expected the error of old, got %q", out.Repositories[2].Error)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteScan(&buf, FormatCSV, results); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		rows, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("Expected valid CSV, got %v", err)
+		}
+		if len(rows) != 4 {
+			t.Fatalf("Expected a header and 3 rows, got %d rows", len(rows))
+		}
+		if got := strings.Join(rows[2], ","); got != "web,/src/org/web,fail,60,LICENSE.md;AUTHORS," {
+			t.Errorf("Expected the row of web, got %s", got)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if err := WriteScan(&bytes.Buffer{}, "xml", results); err == nil {
+			t.Errorf("Expected error for unknown format, got nil")
+		}
+	})
+}
+
+func TestWriteScanFixes(t *testing.T) {
+	results := []scan.FixResult{
+		{Path: "/src/org/api", Name: "api", Commit: "0123456789abcdef", Files: []string{"LICENSE.md", ".repo-validation/lock.yaml"}},
+		{Path: "/src/org/web", Name: "web", Skipped: "dirty working tree"},
+		{Path: "/src/org/old", Name: "old", Error: errors.New("permission denied")},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteScanFixes(&buf, FormatTable, results); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"0123456", "LICENSE.md, .repo-validation/lock.yaml", "skipped", "dirty working tree", "3 repositories, 1 changed, 1 skipped, 1 failed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected table to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteScanFixes(&buf, FormatJSON, results); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var decoded ScanFixJSONResult
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if decoded.Changed != 1 || decoded.Repositories[1].Status != "skipped" || decoded.Repositories[2].Reason != "permission denied" {
+		t.Errorf("Unexpected JSON result: %+v", decoded)
+	}
+}
@@ -0,0 +1,155 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/gitrepo"
+)
+
+// DefaultMessage is the commit message used when none is configured
+const DefaultMessage = "chore: add repository standard files\n\nGenerated by repo-validate scan --fix."
+
+// Skip reasons of repositories that are not fixed
+const (
+	SkipNothingToFix = "nothing to fix"
+	SkipNotGit       = "not a git working tree"
+)
+
+// Remediation configures how fixes are committed to each repository
+type Remediation struct {
+	// Branch is the local branch the fixes are committed to, it must not exist yet
+	Branch string
+	// Message is the commit message
+	Message string
+	// Author is the commit author, the git configuration of each repository is used if empty
+	Author gitrepo.Author
+}
+
+// FixResult is the outcome of fixing a single repository
+type FixResult struct {
+	// Path is the absolute path of the repository
+	Path string
+	// Name is the name of the repository
+	Name string
+	// Commit is the hash of the commit on the branch, "" if the repository was not changed
+	Commit string
+	// Files are the files that were committed, including the lock file
+	Files []string
+	// Skipped is the reason the repository was left alone, "" if it was fixed or failed
+	Skipped string
+	// Error is any error that occurred while fixing the repository
+	Error error
+}
+
+// Fix generates the missing files of each repository and commits them to a new local branch,
+// with at most Jobs repositories at a time. Repositories with a dirty working tree, an existing
+// branch or nothing to fix are skipped. Nothing is ever pushed.
+func (s *Scanner) Fix(ctx context.Context, repos []string, r Remediation) ([]FixResult, error) {
+	results := make([]FixResult, len(repos))
+	done := s.forEach(ctx, len(repos), func(i int) {
+		results[i] = s.FixRepository(repos[i], r)
+	})
+
+	completed := results[:0]
+	for i, result := range results {
+		if done[i] {
+			completed = append(completed, result)
+		}
+	}
+
+	return completed, ctx.Err()
+}
+
+// FixRepository generates the missing files of a repository and commits them to a new branch
+func (s *Scanner) FixRepository(repoPath string, r Remediation) FixResult {
+	result := FixResult{Path: repoPath, Name: filepath.Base(repoPath)}
+
+	chk, err := s.Checker(repoPath)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer chk.Close()
+
+	results, err := chk.CheckRepository()
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	if chk.Git == nil {
+		result.Skipped = SkipNotGit
+		return result
+	}
+
+	changes, err := chk.PlanFixes(results)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	if len(changes) == 0 {
+		result.Skipped = SkipNothingToFix
+		return result
+	}
+
+	author := r.Author
+	if author.Name == "" || author.Email == "" {
+		if author, err = gitrepo.DefaultAuthor(repoPath); err != nil {
+			result.Error = err
+			return result
+		}
+	}
+
+	message := r.Message
+	if message == "" {
+		message = DefaultMessage
+	}
+
+	result.Commit, err = gitrepo.CommitToBranch(repoPath, r.Branch, message, author, func() ([]string, error) {
+		j, err := chk.ApplyChanges(changes)
+		if err != nil {
+			return nil, err
+		}
+		result.Files = j.Paths()
+		return result.Files, nil
+	})
+	if err != nil {
+		result.Files = nil
+		if errors.Is(err, gitrepo.ErrDirty) || errors.Is(err, gitrepo.ErrBranchExists) || errors.Is(err, gitrepo.ErrDetached) {
+			result.Skipped = skipReason(err, r.Branch)
+			return result
+		}
+		result.Error = err
+	}
+
+	return result
+}
+
+// skipReason describes why CommitToBranch refused to change a repository
+func skipReason(err error, branch string) string {
+	switch {
+	case errors.Is(err, gitrepo.ErrDirty):
+		return "dirty working tree"
+	case errors.Is(err, gitrepo.ErrBranchExists):
+		return fmt.Sprintf("branch %s already exists", branch)
+	default:
+		return "detached HEAD"
+	}
+}
+
+// FixSummary counts changed, skipped and failed repositories
+func FixSummary(results []FixResult) (changed, skipped, failed int) {
+	for _, r := range results {
+		switch {
+		case r.Error != nil:
+			failed++
+		case r.Skipped != "":
+			skipped++
+		default:
+			changed++
+		}
+	}
+	return changed, skipped, failed
+}
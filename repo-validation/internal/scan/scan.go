@@ -0,0 +1,359 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+)
+
+// Sort orders
+const (
+	// SortPath keeps the order repositories were discovered or listed in
+	SortPath = "path"
+	// SortName orders repositories by name
+	SortName = "name"
+	// SortScore orders repositories by ascending score, so those that need the most work come first
+	SortScore = "score"
+)
+
+// weights are the score weights of each requirement priority
+var weights = map[string]int{
+	config.PriorityMustHave:   3,
+	config.PriorityShouldHave: 2,
+	config.PriorityNiceToHave: 1,
+}
+
+// Result is the outcome of validating a single repository
+type Result struct {
+	// Path is the absolute path of the repository
+	Path string
+	// Name is the name of the repository
+	Name string
+	// Results are the validation results of every requirement
+	Results []checker.ValidationResult
+	// Passed indicates no must-have file is missing and no requirement failed to validate
+	Passed bool
+	// Score is the percentage of requirements present, weighted by priority
+	Score int
+	// Missing are the paths of the missing requirements of any priority that are not waived
+	Missing []string
+	// Error is any error that prevented the repository from being validated
+	Error error
+}
+
+// Scanner validates many repositories concurrently
+type Scanner struct {
+	// Jobs is the number of repositories validated at the same time
+	Jobs int
+	// Options configure the validation of every repository, the repository path and policy
+	// are set per repository
+	Options []config.ConfigOption
+	// Roots confine what repositories may read, such as for the API: if set, files are read
+	// without following symlinks out of the repository and template packs outside the
+	// repository must be below one of the roots
+	Roots []string
+}
+
+// NewScanner creates a new Scanner
+func NewScanner(jobs int, opts ...config.ConfigOption) *Scanner {
+	if jobs < 1 {
+		jobs = 1
+	}
+	return &Scanner{
+		Jobs:    jobs,
+		Options: opts,
+	}
+}
+
+// Discover returns the repositories below root in lexical order. A directory is a repository
+// if it contains .git, repositories are not searched for nested repositories.
+func Discover(ctx context.Context, root string) ([]string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []string
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			// Unreadable directories below the root are skipped
+			if p != root {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		if _, err := os.Lstat(filepath.Join(p, ".git")); err == nil {
+			repos = append(repos, p)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repos, nil
+}
+
+// ReadList reads repository paths from a file, one per line. Blank lines and lines starting
+// with # are skipped, relative paths are resolved against the directory of the file.
+func ReadList(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dir, err := filepath.Abs(filepath.Dir(name))
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(dir, line)
+		}
+		repos = append(repos, filepath.Clean(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return repos, nil
+}
+
+// Scan validates the repositories with at most Jobs at a time and returns their results in
+// the order given. When ctx is cancelled no further repositories are started, and the results
+// of those already validated are returned along with the context error.
+func (s *Scanner) Scan(ctx context.Context, repos []string) ([]Result, error) {
+	results := make([]Result, len(repos))
+	done := s.forEach(ctx, len(repos), func(i int) {
+		results[i] = s.Check(repos[i])
+	})
+
+	completed := results[:0]
+	for i, result := range results {
+		if done[i] {
+			completed = append(completed, result)
+		}
+	}
+
+	return completed, ctx.Err()
+}
+
+// forEach calls fn for the indices 0 to n-1 with at most Jobs calls at a time, and reports
+// which indices were processed before ctx was cancelled
+func (s *Scanner) forEach(ctx context.Context, n int, fn func(i int)) []bool {
+	done := make([]bool, n)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < s.Jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+				done[i] = true
+			}
+		}()
+	}
+
+dispatch:
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return done
+}
+
+// Check validates a single repository
+func (s *Scanner) Check(repoPath string) Result {
+	result := Result{Path: repoPath, Name: filepath.Base(repoPath)}
+
+	chk, err := s.Checker(repoPath)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer chk.Close()
+
+	results, err := chk.CheckRepository()
+	if err != nil {
+		result.Error = errors.NewFileAccessError(repoPath, err)
+		return result
+	}
+
+	return NewResult(repoPath, results)
+}
+
+// NewResult summarises the validation results of a repository
+func NewResult(repoPath string, results []checker.ValidationResult) Result {
+	result := Result{
+		Path:    repoPath,
+		Name:    filepath.Base(repoPath),
+		Results: results,
+		Score:   Score(results),
+		Passed:  true,
+	}
+	for _, r := range results {
+		if r.Error != nil {
+			result.Passed = false
+			continue
+		}
+		if !r.Exists && !r.Waived {
+			result.Missing = append(result.Missing, r.Requirement.Path)
+			if r.Requirement.Priority == config.PriorityMustHave {
+				result.Passed = false
+			}
+		}
+	}
+
+	return result
+}
+
+// Checker creates a checker for a repository, configured with the scanner options and the
+// policy of the repository. Errors are of the types of the errors package, so they can be
+// told apart. The checker must be closed when done.
+func (s *Scanner) Checker(repoPath string) (*checker.Checker, error) {
+	stat, err := os.Stat(repoPath)
+	if err != nil {
+		return nil, errors.NewFileAccessError(repoPath, err)
+	}
+	if !stat.IsDir() {
+		return nil, errors.NewPathError(repoPath, fmt.Errorf("not a directory"))
+	}
+
+	cfg := &config.Config{}
+	for _, opt := range s.Options {
+		opt(cfg)
+	}
+	cfg.RepoPath = repoPath
+
+	chk := checker.NewChecker(cfg)
+	if len(s.Roots) > 0 {
+		if chk, err = checker.NewCheckerRoot(cfg); err != nil {
+			return nil, errors.NewFileAccessError(repoPath, err)
+		}
+		chk.Roots = s.Roots
+	}
+
+	pol, err := policy.LoadFS(chk.FS)
+	if err != nil {
+		chk.Close()
+		return nil, errors.NewInvalidConfigError(err.Error())
+	}
+	cfg.Policy = pol
+
+	if err := chk.LoadPacks(); err != nil {
+		chk.Close()
+		return nil, errors.NewInvalidConfigError(err.Error())
+	}
+
+	return chk, nil
+}
+
+// Score returns the percentage of requirements present, weighted by priority. Must-have files
+// weigh three times and should-have files twice as much as nice-to-have files, waived files do
+// not count.
+func Score(results []checker.ValidationResult) int {
+	total, present := 0, 0
+	for _, r := range results {
+		if r.Waived {
+			continue
+		}
+		weight := weights[r.Requirement.Priority]
+		if weight == 0 {
+			weight = 1
+		}
+		total += weight
+		if r.Exists && r.Error == nil {
+			present += weight
+		}
+	}
+
+	if total == 0 {
+		return 100
+	}
+	return int(math.Round(100 * float64(present) / float64(total)))
+}
+
+// Summary counts passed and failed repositories and averages their scores
+func Summary(results []Result) (passed, failed, score int) {
+	sum := 0
+	for _, r := range results {
+		if r.Passed {
+			passed++
+		} else {
+			failed++
+		}
+		sum += r.Score
+	}
+
+	if len(results) > 0 {
+		score = int(math.Round(float64(sum) / float64(len(results))))
+	}
+	return passed, failed, score
+}
+
+// Dedupe removes repeated repository paths, keeping the first occurrence
+func Dedupe(repos []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, repo := range repos {
+		if !seen[repo] {
+			seen[repo] = true
+			result = append(result, repo)
+		}
+	}
+	return result
+}
+
+// Sort orders results by path, name or score. Ties keep their order.
+func Sort(results []Result, by string) error {
+	var less func(a, b Result) bool
+	switch by {
+	case SortPath:
+		less = func(a, b Result) bool { return a.Path < b.Path }
+	case SortName:
+		less = func(a, b Result) bool { return a.Name < b.Name }
+	case SortScore:
+		less = func(a, b Result) bool { return a.Score < b.Score }
+	default:
+		return fmt.Errorf("unknown sort order %q, expected one of %s, %s, %s", by, SortPath, SortName, SortScore)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return less(results[i], results[j]) })
+	return nil
+}
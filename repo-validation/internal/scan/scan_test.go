@@ -0,0 +1,274 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/gitrepo"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// makeRepo creates a directory with a .git directory and the given files
+func makeRepo(t *testing.T, dir string, files ...string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte("test content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", file, err)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	makeRepo(t, filepath.Join(root, "beta"))
+	makeRepo(t, filepath.Join(root, "alpha"))
+	makeRepo(t, filepath.Join(root, "group", "gamma"))
+	// Nested repositories are part of their parent
+	makeRepo(t, filepath.Join(root, "alpha", "vendor", "nested"))
+	if err := os.MkdirAll(filepath.Join(root, "not-a-repo"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	repos, err := Discover(context.Background(), root)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := []string{
+		filepath.Join(root, "alpha"),
+		filepath.Join(root, "beta"),
+		filepath.Join(root, "group", "gamma"),
+	}
+	if !reflect.DeepEqual(repos, want) {
+		t.Errorf("Expected %v, got %v", want, repos)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Discover(ctx, root); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestReadList(t *testing.T) {
+	dir := t.TempDir()
+	list := filepath.Join(dir, "repos.txt")
+	content := "# repositories\nalpha\n\n/srv/git/beta\n"
+	if err := os.WriteFile(list, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write list: %v", err)
+	}
+
+	repos, err := ReadList(list)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := []string{filepath.Join(dir, "alpha"), "/srv/git/beta"}
+	if !reflect.DeepEqual(repos, want) {
+		t.Errorf("Expected %v, got %v", want, repos)
+	}
+}
+
+func TestScan(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	var repos []string
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		dir := filepath.Join(root, name)
+		makeRepo(t, dir, "README.md", "LICENSE.md", "SECURITY.md", ".gitignore")
+		repos = append(repos, dir)
+	}
+	makeRepo(t, filepath.Join(root, "incomplete"), "README.md")
+	repos = append(repos, filepath.Join(root, "incomplete"))
+
+	t.Run("results in order", func(t *testing.T) {
+		results, err := NewScanner(3).Scan(context.Background(), repos)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(results) != len(repos) {
+			t.Fatalf("Expected %d results, got %d", len(repos), len(results))
+		}
+		for i, result := range results {
+			if result.Path != repos[i] {
+				t.Errorf("Expected result %d to be %s, got %s", i, repos[i], result.Path)
+			}
+		}
+
+		incomplete := results[len(results)-1]
+		if incomplete.Passed {
+			t.Errorf("Expected repository without LICENSE.md to fail")
+		}
+		if incomplete.Score >= results[0].Score {
+			t.Errorf("Expected a lower score for the incomplete repository, got %d and %d", incomplete.Score, results[0].Score)
+		}
+
+		passed, failed, _ := Summary(results)
+		if passed != 5 || failed != 1 {
+			t.Errorf("Expected 5 passed and 1 failed, got %d and %d", passed, failed)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		results, err := NewScanner(2).Scan(ctx, repos)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+		if len(results) == len(repos) {
+			t.Errorf("Expected the scan to stop early, got all %d results", len(results))
+		}
+	})
+
+	t.Run("missing repository", func(t *testing.T) {
+		result := NewScanner(1).Check(filepath.Join(root, "missing"))
+		if result.Passed || result.Error == nil {
+			t.Errorf("Expected a missing repository to fail with an error")
+		}
+	})
+}
+
+func TestScore(t *testing.T) {
+	results := []checker.ValidationResult{
+		{Requirement: config.FileRequirement{Path: "README.md", Priority: config.PriorityMustHave}, Exists: true},
+		{Requirement: config.FileRequirement{Path: "AUTHORS", Priority: config.PriorityShouldHave}, Exists: false},
+		{Requirement: config.FileRequirement{Path: "CITATION.cff", Priority: config.PriorityNiceToHave}, Exists: true},
+	}
+
+	if score := Score(results); score != 67 {
+		t.Errorf("Expected score 67, got %d", score)
+	}
+	if score := Score(nil); score != 100 {
+		t.Errorf("Expected score 100 without requirements, got %d", score)
+	}
+}
+
+func TestSort(t *testing.T) {
+	results := []Result{
+		{Path: "/src/b", Name: "b", Score: 80},
+		{Path: "/src/a", Name: "c", Score: 40},
+		{Path: "/src/c", Name: "a", Score: 80},
+	}
+
+	tests := map[string][]string{
+		SortPath:  {"/src/a", "/src/b", "/src/c"},
+		SortName:  {"/src/c", "/src/b", "/src/a"},
+		SortScore: {"/src/a", "/src/b", "/src/c"},
+	}
+	for by, want := range tests {
+		t.Run(by, func(t *testing.T) {
+			sorted := append([]Result(nil), results...)
+			if err := Sort(sorted, by); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			var got []string
+			for _, r := range sorted {
+				got = append(got, r.Path)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Expected %v, got %v", want, got)
+			}
+		})
+	}
+
+	if err := Sort(results, "size"); err == nil {
+		t.Errorf("Expected error for unknown sort order, got nil")
+	}
+}
+
+func TestScoreIgnoresWaivers(t *testing.T) {
+	results := []checker.ValidationResult{
+		{Requirement: config.FileRequirement{Path: "README.md", Priority: config.PriorityMustHave}, Exists: true},
+		{Requirement: config.FileRequirement{Path: "CODEOWNERS", Priority: config.PriorityShouldHave}, Waived: true},
+	}
+
+	if score := Score(results); score != 100 {
+		t.Errorf("Expected score 100, got %d", score)
+	}
+}
+
+// initRepo creates a git repository with the given files committed
+func initRepo(t *testing.T, dir string, files ...string) {
+	t.Helper()
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("Failed to init repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to open worktree: %v", err)
+	}
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte("test content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", file, err)
+		}
+		if _, err := wt.Add(file); err != nil {
+			t.Fatalf("Failed to add %s: %v", file, err)
+		}
+	}
+	sig := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Unix(1700000000, 0)}
+	if _, err := wt.Commit("initial", &git.CommitOptions{Author: sig, Committer: sig}); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+}
+
+func TestFix(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	clean, dirty, plain := filepath.Join(root, "clean"), filepath.Join(root, "dirty"), filepath.Join(root, "plain")
+	initRepo(t, clean, "README.md")
+	initRepo(t, dirty, "README.md")
+	if err := os.WriteFile(filepath.Join(dirty, "README.md"), []byte("edited"), 0644); err != nil {
+		t.Fatalf("Failed to edit README.md: %v", err)
+	}
+	if err := os.MkdirAll(plain, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	remediation := Remediation{Branch: "chore/repo-standards", Author: gitrepo.Author{Name: "Bot", Email: "bot@example.com"}}
+	results, err := NewScanner(2).Fix(context.Background(), []string{clean, dirty, plain}, remediation)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if r := results[0]; r.Error != nil || r.Skipped != "" || r.Commit == "" || len(r.Files) == 0 {
+		t.Errorf("Expected clean repository to be changed, got %+v", r)
+	}
+	if r := results[1]; r.Skipped != "dirty working tree" {
+		t.Errorf("Expected dirty repository to be skipped, got %+v", r)
+	}
+	if r := results[2]; r.Skipped != SkipNotGit {
+		t.Errorf("Expected plain directory to be skipped, got %+v", r)
+	}
+
+	changed, skipped, failed := FixSummary(results)
+	if changed != 1 || skipped != 2 || failed != 0 {
+		t.Errorf("Expected 1 changed and 2 skipped, got %d, %d and %d", changed, skipped, failed)
+	}
+
+	// The checked out branch is untouched, so the repository stays clean for the next run
+	if _, err := os.Stat(filepath.Join(clean, "LICENSE.md")); !os.IsNotExist(err) {
+		t.Errorf("Expected LICENSE.md only on the branch, got %v", err)
+	}
+	again := NewScanner(1).FixRepository(clean, remediation)
+	if again.Skipped != "branch chore/repo-standards already exists" {
+		t.Errorf("Expected the existing branch to be skipped, got %+v", again)
+	}
+}
@@ -0,0 +1,574 @@
+package server
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/errors"
+	"github.com/LarsArtmann/templates/repo-validation/internal/metrics"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+	"github.com/LarsArtmann/templates/repo-validation/internal/reporter"
+	"github.com/LarsArtmann/templates/repo-validation/internal/scan"
+	"github.com/charmbracelet/log"
+)
+
+// DefaultTimeout is how long a request may take before it is answered with 503
+const DefaultTimeout = 60 * time.Second
+
+// maxBodySize limits the size of request bodies
+const maxBodySize = 1 << 20
+
+// Server answers validation requests over HTTP for the repositories below its base directories
+type Server struct {
+	// Roots are the base directories repositories must be in, resolved to absolute paths
+	// without symlinks
+	Roots []string
+	// Timeout is how long a request may take
+	Timeout time.Duration
+	// Jobs is the number of repositories a scan validates concurrently
+	Jobs int
+	// Options configure the validation of every repository, such as template directories
+	Options []config.ConfigOption
+	// Metrics holds the latest results of the validated repositories and the errors counted
+	Metrics *metrics.Registry
+}
+
+// ServerOption configures a Server
+type ServerOption func(*Server)
+
+// WithTimeout sets how long a request may take
+func WithTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.Timeout = timeout
+	}
+}
+
+// WithJobs sets the number of repositories a scan validates concurrently
+func WithJobs(jobs int) ServerOption {
+	return func(s *Server) {
+		s.Jobs = jobs
+	}
+}
+
+// WithConfigOptions sets the options every repository is validated with
+func WithConfigOptions(opts ...config.ConfigOption) ServerOption {
+	return func(s *Server) {
+		s.Options = opts
+	}
+}
+
+// NewServer creates a new Server for the repositories below the given base directories
+func NewServer(roots []string, opts ...ServerOption) (*Server, error) {
+	if len(roots) == 0 {
+		return nil, errors.NewInvalidConfigError("at least one allowed base directory is needed")
+	}
+
+	s := &Server{Timeout: DefaultTimeout, Jobs: runtime.NumCPU(), Metrics: metrics.NewRegistry()}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	for _, root := range roots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return nil, errors.NewPathError(root, err)
+		}
+		real, err := filepath.EvalSymlinks(abs)
+		if err != nil {
+			return nil, errors.NewFileAccessError(abs, err)
+		}
+		s.Roots = append(s.Roots, real)
+	}
+
+	return s, nil
+}
+
+// Handler returns the HTTP handler of the API, every request is answered within the timeout
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/validate", s.handleValidate)
+	mux.HandleFunc("GET /api/policy", s.handlePolicy)
+	mux.HandleFunc("POST /api/preview", s.handlePreview)
+	mux.HandleFunc("POST /api/scan", s.handleScan)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	return http.TimeoutHandler(mux, s.Timeout, `{"error":"request timed out"}`)
+}
+
+// ErrorResponse is the response of a failed request
+type ErrorResponse struct {
+	// Error describes what went wrong
+	Error string `json:"error"`
+}
+
+// ValidateResponse is the response of the validate endpoint, the JSON output of --json with the
+// repository and its score
+type ValidateResponse struct {
+	// Path is the absolute path of the repository
+	Path string `json:"path"`
+	// Name is the name of the repository
+	Name string `json:"name"`
+	// Score is the percentage of requirements present, weighted by priority
+	Score int `json:"score"`
+	reporter.JSONResult
+}
+
+// handleValidate validates the repository given by the path query parameter
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.Validate(r.URL.Query().Get("path"), r.URL.Query()["group"])
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Validate validates a repository with optional file groups in addition to those of its policy
+func (s *Server) Validate(p string, groups []string) (ValidateResponse, error) {
+	repoPath, scanner, err := s.repository(p, groups)
+	if err != nil {
+		return ValidateResponse{}, err
+	}
+
+	result := scanner.Check(repoPath)
+	if result.Error != nil {
+		return ValidateResponse{}, result.Error
+	}
+
+	s.Metrics.ObserveRepository(result)
+
+	rep := reporter.NewReporter(&config.Config{RepoPath: repoPath, JSONOutput: true})
+	return ValidateResponse{
+		Path:       result.Path,
+		Name:       result.Name,
+		Score:      result.Score,
+		JSONResult: rep.NewJSONResult(result.Results),
+	}, nil
+}
+
+// PolicyResponse is the response of the policy endpoint
+type PolicyResponse struct {
+	// Path is the absolute path of the repository
+	Path string `json:"path"`
+	// Policy is the policy file of the repository, empty if it has none
+	Policy PolicyJSON `json:"policy"`
+	// Requirements are the requirements the repository is validated against
+	Requirements []RequirementJSON `json:"requirements"`
+}
+
+// PolicyJSON represents the JSON output of a policy file
+type PolicyJSON struct {
+	// Groups are the optional file groups the policy enables
+	Groups []string `json:"groups,omitempty"`
+	// Variables are the template variables of the policy
+	Variables map[string]string `json:"variables,omitempty"`
+	// Packs are the paths of the template packs of the policy
+	Packs []string `json:"packs,omitempty"`
+	// Untracked is how untracked files count, present or missing
+	Untracked string `json:"untracked,omitempty"`
+	// Waivers map waived requirements to the reason they are waived
+	Waivers map[string]string `json:"waivers,omitempty"`
+}
+
+// RequirementJSON represents the JSON output of a requirement
+type RequirementJSON struct {
+	// Path is the path of the required file
+	Path string `json:"path"`
+	// Priority is the priority of the requirement
+	Priority string `json:"priority"`
+	// Category is the category of the requirement
+	Category string `json:"category,omitempty"`
+	// Group is the file group of the requirement, Policy for requirements the policy adds
+	Group string `json:"group"`
+	// Description is a brief description of what the file is for
+	Description string `json:"description,omitempty"`
+	// Template is the template that generates the file, if any
+	Template string `json:"template,omitempty"`
+	// Managed indicates only the managed block of the file is generated
+	Managed bool `json:"managed,omitempty"`
+}
+
+// handlePolicy lists the policy and the requirements of the repository given by the path query
+// parameter
+func (s *Server) handlePolicy(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.Policy(r.URL.Query().Get("path"), r.URL.Query()["group"])
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Policy lists the policy of a repository and the requirements it is validated against
+func (s *Server) Policy(p string, groups []string) (PolicyResponse, error) {
+	chk, err := s.Checker(p, groups)
+	if err != nil {
+		return PolicyResponse{}, err
+	}
+	defer chk.Close()
+
+	byPath := map[string]string{}
+	for _, group := range config.GetFileGroups(chk.Config) {
+		for _, req := range group.Requirements {
+			byPath[req.Path] = group.Name
+		}
+	}
+
+	resp := PolicyResponse{Path: chk.Config.RepoPath, Policy: newPolicyJSON(chk.Config.Policy), Requirements: []RequirementJSON{}}
+	for _, req := range config.GetAllFileRequirements(chk.Config) {
+		group, ok := byPath[req.Path]
+		if !ok {
+			group = "Policy"
+		}
+		resp.Requirements = append(resp.Requirements, RequirementJSON{
+			Path:        req.Path,
+			Priority:    req.Priority,
+			Category:    req.Category,
+			Group:       group,
+			Description: req.Description,
+			Template:    chk.Templates.TemplateFor(req.Path, req.TemplatePath),
+			Managed:     req.Managed,
+		})
+	}
+
+	return resp, nil
+}
+
+// newPolicyJSON converts a policy to its JSON output
+func newPolicyJSON(p *policy.Policy) PolicyJSON {
+	if p == nil {
+		return PolicyJSON{}
+	}
+
+	out := PolicyJSON{Groups: p.Groups, Variables: p.Variables, Untracked: p.Git.Untracked}
+	for _, pack := range p.Packs {
+		out.Packs = append(out.Packs, pack.Path)
+	}
+	if len(p.Waivers) > 0 {
+		out.Waivers = map[string]string{}
+		for _, waiver := range p.Waivers {
+			out.Waivers[waiver.Path] = waiver.Reason
+		}
+	}
+	return out
+}
+
+// PreviewRequest is the request of the preview endpoint
+type PreviewRequest struct {
+	// Path is the path of the repository
+	Path string `json:"path"`
+	// Requirement is the path of the requirement to render the template of
+	Requirement string `json:"requirement"`
+	// Groups are optional file groups to enable in addition to those of the policy
+	Groups []string `json:"groups,omitempty"`
+	// Variables are template variables in addition to those of the policy
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// PreviewResponse is the response of the preview endpoint
+type PreviewResponse struct {
+	// Path is the absolute path of the repository
+	Path string `json:"path"`
+	// Requirement is the path of the requirement
+	Requirement string `json:"requirement"`
+	// Fixable indicates --fix would write the changes, otherwise they show what the template
+	// renders for a missing file
+	Fixable bool `json:"fixable"`
+	// MissingVariables are template variables that are not set, rendered as <Name> placeholders
+	MissingVariables []string `json:"missingVariables,omitempty"`
+	// Changes are the files the template renders
+	Changes []PreviewChange `json:"changes"`
+}
+
+// PreviewChange is a file rendered by the preview endpoint
+type PreviewChange struct {
+	reporter.ChangeResult
+	// Content is the rendered content of the file
+	Content string `json:"content"`
+}
+
+// handlePreview renders the template of a requirement with the policy of a repository
+func (s *Server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	var req PreviewRequest
+	if err := decode(w, r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	resp, err := s.Preview(req)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Preview renders the template of a requirement with the policy of a repository. Requirements
+// that need no fix are rendered as if the file were missing.
+func (s *Server) Preview(req PreviewRequest) (PreviewResponse, error) {
+	chk, err := s.Checker(req.Path, req.Groups)
+	if err != nil {
+		return PreviewResponse{}, err
+	}
+	defer chk.Close()
+	chk.Variables = req.Variables
+
+	results, err := chk.CheckRepository()
+	if err != nil {
+		return PreviewResponse{}, err
+	}
+	i := slices.IndexFunc(results, func(result checker.ValidationResult) bool {
+		return result.Requirement.Path == req.Requirement
+	})
+	if i < 0 {
+		return PreviewResponse{}, errors.NewInvalidConfigError(fmt.Sprintf("unknown requirement %q", req.Requirement))
+	}
+
+	result := results[i]
+	fixable := len(chk.Fixable([]checker.ValidationResult{result})) > 0
+	if !fixable {
+		result = checker.ValidationResult{Requirement: result.Requirement}
+	}
+
+	changes, missing, err := chk.Preview(result)
+	if err != nil {
+		return PreviewResponse{}, err
+	}
+	if len(changes) == 0 {
+		return PreviewResponse{}, errors.NewInvalidConfigError(fmt.Sprintf("requirement %q has no template", req.Requirement))
+	}
+
+	resp := PreviewResponse{Path: chk.Config.RepoPath, Requirement: req.Requirement, Fixable: fixable, MissingVariables: missing}
+	for _, change := range changes {
+		status := "modified"
+		if change.IsNew {
+			status = "new"
+		}
+		resp.Changes = append(resp.Changes, PreviewChange{
+			ChangeResult: reporter.ChangeResult{
+				Path:        change.Path,
+				Requirement: change.Requirement.Path,
+				Status:      status,
+				Diff:        checker.Patch([]checker.Change{change}),
+			},
+			Content: string(change.New),
+		})
+	}
+
+	return resp, nil
+}
+
+// ScanRequest is the request of the scan endpoint
+type ScanRequest struct {
+	// Roots are the directories to discover repositories below, all base directories if empty
+	Roots []string `json:"roots,omitempty"`
+	// Groups are optional file groups to enable in addition to those of the policies
+	Groups []string `json:"groups,omitempty"`
+}
+
+// handleScan validates every repository below the requested roots, the JSON output of
+// scan --json
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	var req ScanRequest
+	if err := decode(w, r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	roots := s.Roots
+	if len(req.Roots) > 0 {
+		roots = nil
+		for _, root := range req.Roots {
+			resolved, err := s.resolve(root)
+			if err != nil {
+				s.writeError(w, err)
+				return
+			}
+			roots = append(roots, resolved)
+		}
+	}
+	options, err := groupOptions(req.Groups)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	var repos []string
+	for _, root := range roots {
+		found, err := scan.Discover(r.Context(), root)
+		if err != nil {
+			s.writeError(w, errors.NewPathError(root, err))
+			return
+		}
+		repos = append(repos, found...)
+	}
+	repos = scan.Dedupe(repos)
+
+	start := time.Now()
+	results, err := s.scanner(s.Jobs, options).Scan(r.Context(), repos)
+	if err != nil {
+		s.writeError(w, fmt.Errorf("scan interrupted after %d of %d repositories: %w", len(results), len(repos), err))
+		return
+	}
+	duration := time.Since(start)
+	s.Metrics.ObserveScan(results, duration, time.Now())
+	log.Debug("Scanned repositories", "repositories", len(results), "duration", duration)
+
+	writeJSON(w, http.StatusOK, reporter.NewScanJSONResult(results))
+}
+
+// handleMetrics writes the metrics of the validated repositories in the Prometheus text format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metrics.ContentType)
+	if err := s.Metrics.Write(w); err != nil {
+		log.Debug("Error writing metrics", "error", err)
+	}
+}
+
+// repository resolves the repository of a request and creates a scanner with the requested
+// file groups
+func (s *Server) repository(p string, groups []string) (string, *scan.Scanner, error) {
+	repoPath, err := s.resolve(p)
+	if err != nil {
+		return "", nil, err
+	}
+
+	options, err := groupOptions(groups)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return repoPath, s.scanner(1, options), nil
+}
+
+// scanner creates a scanner with the server options and the given file groups, confined to the
+// base directories
+func (s *Server) scanner(jobs int, options []config.ConfigOption) *scan.Scanner {
+	scanner := scan.NewScanner(jobs, append(slices.Clone(s.Options), options...)...)
+	scanner.Roots = s.Roots
+	return scanner
+}
+
+// Checker resolves a repository below the base directories and creates a checker for it with
+// its policy and optional file groups in addition to those of the policy, the checker must be
+// closed when done
+func (s *Server) Checker(p string, groups []string) (*checker.Checker, error) {
+	repoPath, scanner, err := s.repository(p, groups)
+	if err != nil {
+		return nil, err
+	}
+	return scanner.Checker(repoPath)
+}
+
+// resolve returns the absolute path of a directory below one of the base directories, relative
+// paths are resolved against the first base directory. Symlinks are resolved before checking,
+// so they cannot lead out of the base directories.
+func (s *Server) resolve(p string) (string, error) {
+	if p == "" {
+		return "", errors.NewInvalidConfigError("path is required")
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(s.Roots[0], p)
+	}
+
+	// Paths outside the base directories are rejected before touching them, so responses do
+	// not reveal which exist
+	p = filepath.Clean(p)
+	if !s.allowed(p) {
+		return "", errors.NewPathError(p, fmt.Errorf("not below an allowed base directory"))
+	}
+	real, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", errors.NewFileAccessError(p, err)
+	}
+	if !s.allowed(real) {
+		return "", errors.NewPathError(p, fmt.Errorf("not below an allowed base directory"))
+	}
+
+	stat, err := os.Stat(real)
+	if err != nil {
+		return "", errors.NewFileAccessError(real, err)
+	}
+	if !stat.IsDir() {
+		return "", errors.NewInvalidConfigError(fmt.Sprintf("%s is not a directory", p))
+	}
+
+	return real, nil
+}
+
+// allowed reports whether a clean absolute path is one of the base directories or below one
+func (s *Server) allowed(p string) bool {
+	return slices.ContainsFunc(s.Roots, func(root string) bool {
+		return p == root || strings.HasPrefix(p, root+string(filepath.Separator))
+	})
+}
+
+// groupOptions converts the names of optional file groups to config options
+func groupOptions(groups []string) ([]config.ConfigOption, error) {
+	var options []config.ConfigOption
+	for _, group := range groups {
+		if group != "all" && !slices.Contains(policy.GroupNames, group) {
+			return nil, errors.NewInvalidConfigError(fmt.Sprintf("unknown group %q, expected all or one of %s", group, strings.Join(policy.GroupNames, ", ")))
+		}
+		options = append(options, config.WithFileGroup(group, true))
+	}
+	return options, nil
+}
+
+// decode reads the JSON body of a request
+func decode(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return errors.NewInvalidConfigError(fmt.Sprintf("invalid request body: %v", err))
+	}
+	return nil
+}
+
+// StatusCode returns the HTTP status of an error: 400 for invalid requests, 403 for paths
+// outside the base directories, 404 for paths that do not exist and 500 otherwise
+func StatusCode(err error) int {
+	var invalid *errors.InvalidConfigError
+	var pathErr *errors.PathError
+	var accessErr *errors.FileAccessError
+	switch {
+	case stderrors.As(err, &invalid):
+		return http.StatusBadRequest
+	case stderrors.As(err, &pathErr):
+		return http.StatusForbidden
+	case stderrors.As(err, &accessErr):
+		if stderrors.Is(accessErr.Err, os.ErrNotExist) {
+			return http.StatusNotFound
+		}
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeError writes an error response and counts the error
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	s.Metrics.ObserveError(err)
+	writeJSON(w, StatusCode(err), ErrorResponse{Error: err.Error()})
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Debug("Error writing response", "error", err)
+	}
+}
@@ -0,0 +1,393 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+	"github.com/LarsArtmann/templates/repo-validation/internal/reporter"
+	"github.com/LarsArtmann/templates/repo-validation/internal/templates"
+)
+
+// setupRoot creates a base directory with two repositories, one of them with a policy file
+func setupRoot(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	files := map[string]string{
+		"app/.git/HEAD":          "ref: refs/heads/main\n",
+		"app/README.md":          "# App\n",
+		"app/" + policy.FileName: "groups: [docker]\nvariables:\n  Org: acme\nwaivers:\n  - path: CODEOWNERS\n    reason: single maintainer\n",
+		"lib/.git/HEAD":          "ref: refs/heads/main\n",
+		"lib/README.md":          "# Lib\n",
+		"lib/LICENSE.md":         "License\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+	return root
+}
+
+// newTestServer starts the API for the repositories below root
+func newTestServer(t *testing.T, root string, opts ...ServerOption) *httptest.Server {
+	t.Helper()
+	s, err := NewServer([]string{root}, opts...)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// request sends a request and decodes the JSON response into v
+func request(t *testing.T, method, u string, body any, v any) int {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			t.Fatalf("Failed to encode request: %v", err)
+		}
+	}
+
+	req, err := http.NewRequest(method, u, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestValidate(t *testing.T) {
+	root := setupRoot(t)
+	ts := newTestServer(t, root)
+
+	var resp ValidateResponse
+	if status := request(t, http.MethodGet, ts.URL+"/api/validate?path=lib", nil, &resp); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	if resp.Name != "lib" || resp.Success || len(resp.Requirements) == 0 {
+		t.Errorf("Expected the failed results of lib, got %+v", resp)
+	}
+	if strings.Join(resp.MissingMustHaveFiles, ",") != ".gitignore,SECURITY.md" {
+		t.Errorf("Expected .gitignore and SECURITY.md to be missing, got %v", resp.MissingMustHaveFiles)
+	}
+
+	t.Run("groups", func(t *testing.T) {
+		var resp ValidateResponse
+		request(t, http.MethodGet, ts.URL+"/api/validate?path=lib&group=docker", nil, &resp)
+		if !strings.Contains(strings.Join(resp.MissingMustHaveFiles, ","), "Dockerfile") {
+			t.Errorf("Expected Dockerfile to be checked, got %v", resp.MissingMustHaveFiles)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		outside := t.TempDir()
+		if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+
+		tests := []struct {
+			name   string
+			query  string
+			status int
+		}{
+			{"no path", "", http.StatusBadRequest},
+			{"unknown group", "?path=lib&group=java", http.StatusBadRequest},
+			{"missing", "?path=nope", http.StatusNotFound},
+			{"outside", "?path=" + url.QueryEscape(outside), http.StatusForbidden},
+			{"parent", "?path=" + url.QueryEscape("lib/../.."), http.StatusForbidden},
+			{"symlink", "?path=escape", http.StatusForbidden},
+			{"file", "?path=" + url.QueryEscape("lib/README.md"), http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var resp ErrorResponse
+				if status := request(t, http.MethodGet, ts.URL+"/api/validate"+tt.query, nil, &resp); status != tt.status {
+					t.Errorf("Expected status %d, got %d (%s)", tt.status, status, resp.Error)
+				}
+				if resp.Error == "" {
+					t.Error("Expected an error message")
+				}
+			})
+		}
+	})
+}
+
+func TestPolicy(t *testing.T) {
+	ts := newTestServer(t, setupRoot(t))
+
+	var resp PolicyResponse
+	if status := request(t, http.MethodGet, ts.URL+"/api/policy?path=app", nil, &resp); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	if resp.Policy.Variables["Org"] != "acme" || resp.Policy.Waivers["CODEOWNERS"] != "single maintainer" {
+		t.Errorf("Expected the policy of app, got %+v", resp.Policy)
+	}
+
+	groups := map[string]string{}
+	for _, req := range resp.Requirements {
+		groups[req.Path] = req.Group
+	}
+	if groups["README.md"] != "Core" || groups["Dockerfile"] != "Docker" {
+		t.Errorf("Expected the core and Docker requirements, got %v", groups)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	ts := newTestServer(t, setupRoot(t))
+
+	var resp PreviewResponse
+	status := request(t, http.MethodPost, ts.URL+"/api/preview", PreviewRequest{Path: "app", Requirement: "LICENSE.md"}, &resp)
+	if status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	if !resp.Fixable || len(resp.Changes) != 1 || resp.Changes[0].Status != "new" {
+		t.Fatalf("Expected LICENSE.md to be created, got %+v", resp)
+	}
+	if !strings.Contains(resp.Changes[0].Content, "EUPL") || !strings.Contains(resp.Changes[0].Diff, "+++ b/LICENSE.md") {
+		t.Errorf("Expected the rendered license and its diff, got %+v", resp.Changes[0])
+	}
+
+	t.Run("present", func(t *testing.T) {
+		var resp PreviewResponse
+		request(t, http.MethodPost, ts.URL+"/api/preview", PreviewRequest{Path: "lib", Requirement: "LICENSE.md", Variables: map[string]string{"License": "MIT"}}, &resp)
+		if resp.Fixable || len(resp.Changes) != 1 || !strings.Contains(resp.Changes[0].Content, "MIT License") {
+			t.Errorf("Expected the template rendered with the variables, got %+v", resp)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var resp ErrorResponse
+		if status := request(t, http.MethodPost, ts.URL+"/api/preview", PreviewRequest{Path: "app", Requirement: "nope"}, &resp); status != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an unknown requirement, got %d", status)
+		}
+		if status := request(t, http.MethodPost, ts.URL+"/api/preview", map[string]string{"repo": "app"}, &resp); status != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an unknown field, got %d", status)
+		}
+		if status := request(t, http.MethodGet, ts.URL+"/api/preview", nil, nil); status != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405, got %d", status)
+		}
+	})
+}
+
+func TestPreviewOutsideRoot(t *testing.T) {
+	root := setupRoot(t)
+	outside := t.TempDir()
+
+	template := "Secret {{ .Org }}\n"
+	manifest := fmt.Sprintf("name: acme\nversion: 1.0.0\ntemplates:\n  - name: LICENSE.md.tmpl\n    requirements: [LICENSE.md]\n    sha256: %s\n", templates.Checksum([]byte(template)))
+	for _, dir := range []string{filepath.Join(outside, "pack"), filepath.Join(root, "shared")} {
+		writeFiles(t, dir, map[string]string{templates.ManifestName: manifest, "LICENSE.md.tmpl": template})
+	}
+	writeFiles(t, outside, map[string]string{"secret.tmpl": template})
+	if err := os.Symlink(filepath.Join(outside, "pack"), filepath.Join(root, "linked")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	relative, err := filepath.Rel(filepath.Join(root, "repo"), filepath.Join(outside, "pack"))
+	if err != nil {
+		t.Fatalf("Failed to make path relative: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		pack string
+		link bool
+		want int
+	}{
+		{name: "absolute pack path", pack: filepath.Join(outside, "pack"), want: http.StatusBadRequest},
+		{name: "relative pack path", pack: relative, want: http.StatusBadRequest},
+		{name: "pack symlink", pack: "../linked", want: http.StatusBadRequest},
+		{name: "template symlink", link: true, want: http.StatusInternalServerError},
+		{name: "pack below root", pack: "../shared", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := filepath.Join(root, "repo")
+			if err := os.RemoveAll(repo); err != nil {
+				t.Fatalf("Failed to remove repository: %v", err)
+			}
+			files := map[string]string{".git/HEAD": "ref: refs/heads/main\n", policy.FileName: "variables:\n  Org: acme\n"}
+			if tt.pack != "" {
+				files[policy.FileName] += fmt.Sprintf("packs:\n  - path: %q\n", tt.pack)
+			}
+			writeFiles(t, repo, files)
+			if tt.link {
+				link := filepath.Join(repo, templates.RepoTemplateDir, "LICENSE.md.tmpl")
+				if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+					t.Fatalf("Failed to create directory: %v", err)
+				}
+				if err := os.Symlink(filepath.Join(outside, "secret.tmpl"), link); err != nil {
+					t.Fatalf("Failed to create symlink: %v", err)
+				}
+			}
+
+			ts := newTestServer(t, root)
+			var resp map[string]any
+			status := request(t, http.MethodPost, ts.URL+"/api/preview", PreviewRequest{Path: "repo", Requirement: "LICENSE.md"}, &resp)
+			if status != tt.want {
+				t.Errorf("Expected status %d, got %d: %v", tt.want, status, resp)
+			}
+			body := fmt.Sprint(resp)
+			if tt.want != http.StatusOK && strings.Contains(body, "Secret") {
+				t.Errorf("Expected the template outside the root not to be read, got %s", body)
+			}
+			if tt.want == http.StatusOK && !strings.Contains(body, "Secret acme") {
+				t.Errorf("Expected the license of the pack, got %s", body)
+			}
+		})
+	}
+}
+
+func TestRequestsCloseRepositories(t *testing.T) {
+	if _, err := os.Stat("/proc/self/fd"); err != nil {
+		t.Skip("Open files cannot be counted on this platform")
+	}
+	root := setupRoot(t)
+	template := "License of {{ .Org }}\n"
+	manifest := fmt.Sprintf("name: acme\nversion: 1.0.0\ntemplates:\n  - name: LICENSE.md.tmpl\n    requirements: [LICENSE.md]\n    sha256: %s\n", templates.Checksum([]byte(template)))
+	writeFiles(t, filepath.Join(root, "shared"), map[string]string{templates.ManifestName: manifest, "LICENSE.md.tmpl": template})
+	writeFiles(t, filepath.Join(root, "app"), map[string]string{policy.FileName: "variables:\n  Org: acme\npacks:\n  - path: ../shared\n"})
+
+	s, err := NewServer([]string{root})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	openFiles := func() int {
+		entries, err := os.ReadDir("/proc/self/fd")
+		if err != nil {
+			t.Fatalf("Failed to list open files: %v", err)
+		}
+		return len(entries)
+	}
+
+	before := openFiles()
+	for range 20 {
+		if _, err := s.Validate("app", nil); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := s.Policy("app", nil); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := s.Preview(PreviewRequest{Path: "app", Requirement: "LICENSE.md"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if after := openFiles(); after > before+2 {
+		t.Errorf("Expected the repositories and packs to be closed, %d files were open before and %d after", before, after)
+	}
+}
+
+// writeFiles writes files below dir, creating their directories
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	root := setupRoot(t)
+	ts := newTestServer(t, root)
+
+	var resp reporter.ScanJSONResult
+	if status := request(t, http.MethodPost, ts.URL+"/api/scan", ScanRequest{}, &resp); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	if len(resp.Repositories) != 2 || resp.Repositories[0].Name != "app" || resp.Repositories[1].Name != "lib" {
+		t.Errorf("Expected app and lib, got %+v", resp.Repositories)
+	}
+
+	t.Run("roots", func(t *testing.T) {
+		var resp reporter.ScanJSONResult
+		request(t, http.MethodPost, ts.URL+"/api/scan", ScanRequest{Roots: []string{"lib"}}, &resp)
+		if len(resp.Repositories) != 1 || resp.Repositories[0].Name != "lib" {
+			t.Errorf("Expected only lib, got %+v", resp.Repositories)
+		}
+
+		if status := request(t, http.MethodPost, ts.URL+"/api/scan", ScanRequest{Roots: []string{"/"}}, nil); status != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", status)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ts := newTestServer(t, root, WithTimeout(time.Nanosecond))
+		var resp ErrorResponse
+		if status := request(t, http.MethodPost, ts.URL+"/api/scan", ScanRequest{}, &resp); status != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %d", status)
+		}
+		if resp.Error != "request timed out" {
+			t.Errorf("Expected a timeout error, got %q", resp.Error)
+		}
+	})
+}
+
+func TestNewServer(t *testing.T) {
+	if _, err := NewServer(nil); err == nil {
+		t.Error("Expected an error without base directories, got nil")
+	}
+	if _, err := NewServer([]string{filepath.Join(t.TempDir(), "nope")}); err == nil {
+		t.Error("Expected an error for a missing base directory, got nil")
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	ts := newTestServer(t, setupRoot(t))
+
+	request(t, http.MethodGet, ts.URL+"/api/validate?path=lib", nil, nil)
+	request(t, http.MethodGet, ts.URL+"/api/validate?path=/", nil, nil)
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		t.Errorf("Expected the text format, got %s", resp.Header.Get("Content-Type"))
+	}
+	for _, want := range []string{`repo_validation_repository_score{repository="lib"`, `repo_validation_errors_total{type="PathError"} 1`} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected the metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package setup
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/gitignore"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+	"gopkg.in/yaml.v3"
+)
+
+// LicenseVariable is the policy variable the README and LICENSE templates read the SPDX
+// license identifier from
+const LicenseVariable = "License"
+
+// DefaultLicense is the license of the built-in templates
+const DefaultLicense = "EUPL-1.2"
+
+// groupMarkers are the files that suggest a repository needs an optional file group
+var groupMarkers = map[string][]string{
+	"augment":      {".augment-guidelines", ".augmentignore"},
+	"docker":       {"Dockerfile", "docker-compose.yaml", "docker-compose.yml", "compose.yaml", ".dockerignore"},
+	"typescript":   {"tsconfig.json"},
+	"devcontainer": {".devcontainer.json", ".devcontainer"},
+	"devenv":       {"devenv.nix", "devenv.yaml"},
+	"github":       {".github"},
+}
+
+// stackGroups maps detected stacks to the file group they need
+var stackGroups = map[string]string{
+	gitignore.StackNode: "typescript",
+}
+
+// Answers are the choices made when bootstrapping a policy
+type Answers struct {
+	// Groups are the optional file groups to check
+	Groups []string
+	// License is the SPDX identifier of the license of the repository
+	License string
+	// MustHave are the paths of the requirements that must be present
+	MustHave []string
+}
+
+// DetectGroups returns the optional file groups whose files or stacks are found in the root
+// of fsys, in the order of policy.GroupNames
+func DetectGroups(fsys fs.FS) []string {
+	found := map[string]bool{}
+	for group, markers := range groupMarkers {
+		for _, marker := range markers {
+			if _, err := fs.Stat(fsys, marker); err == nil {
+				found[group] = true
+				break
+			}
+		}
+	}
+	for _, stack := range gitignore.Detect(fsys) {
+		if group, ok := stackGroups[stack]; ok {
+			found[group] = true
+		}
+	}
+
+	var groups []string
+	for _, group := range policy.GroupNames {
+		if found[group] {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// Defaults returns the answers to start from: the groups of the existing policy and those
+// detected in the repository, the license variable and the current must-have files
+func Defaults(fsys fs.FS, p *policy.Policy) Answers {
+	detected := DetectGroups(fsys)
+	var groups []string
+	for _, group := range policy.GroupNames {
+		if p.HasGroup(group) || slices.Contains(detected, group) {
+			groups = append(groups, group)
+		}
+	}
+
+	a := Answers{Groups: groups, License: p.Variables[LicenseVariable]}
+	if a.License == "" {
+		a.License = DefaultLicense
+	}
+	a.MustHave = MustHave(Apply(p, Answers{Groups: groups, License: a.License}))
+	return a
+}
+
+// Requirements returns the requirements checked in a repository with the policy
+func Requirements(p *policy.Policy) config.FileRequirementList {
+	return config.GetAllFileRequirements(&config.Config{Policy: p})
+}
+
+// MustHave returns the paths of the must-have requirements of the policy
+func MustHave(p *policy.Policy) []string {
+	var paths []string
+	for _, req := range Requirements(p) {
+		if req.Priority == config.PriorityMustHave {
+			paths = append(paths, req.Path)
+		}
+	}
+	return paths
+}
+
+// Apply returns a copy of the policy with the answers applied. Requirements that become
+// must-have or stop being must-have get a priority override, demoted requirements become
+// should-have. A nil MustHave leaves the priorities alone.
+func Apply(p *policy.Policy, a Answers) *policy.Policy {
+	result := &policy.Policy{}
+	if p != nil {
+		*result = *p
+	}
+	result.Variables = maps.Clone(result.Variables)
+	result.Requirements = slices.Clone(result.Requirements)
+
+	result.Groups = nil
+	for _, group := range policy.GroupNames {
+		if slices.Contains(a.Groups, group) {
+			result.Groups = append(result.Groups, group)
+		}
+	}
+
+	// The default license needs no variable
+	if a.License != "" && a.License != DefaultLicense {
+		if result.Variables == nil {
+			result.Variables = map[string]string{}
+		}
+		result.Variables[LicenseVariable] = a.License
+	} else {
+		delete(result.Variables, LicenseVariable)
+	}
+
+	if a.MustHave == nil {
+		return result
+	}
+	for _, req := range Requirements(result) {
+		mustHave := slices.Contains(a.MustHave, req.Path)
+		switch {
+		case mustHave && req.Priority != config.PriorityMustHave:
+			setPriority(result, req.Path, config.PriorityMustHave)
+		case !mustHave && req.Priority == config.PriorityMustHave:
+			setPriority(result, req.Path, config.PriorityShouldHave)
+		}
+	}
+
+	return result
+}
+
+// setPriority overrides the priority of a requirement in the policy
+func setPriority(p *policy.Policy, path, priority string) {
+	for i := range p.Requirements {
+		if p.Requirements[i].Path == path {
+			p.Requirements[i].Priority = priority
+			return
+		}
+	}
+	p.Requirements = append(p.Requirements, policy.Requirement{Path: path, Priority: priority})
+}
+
+// Write writes the policy file into the repository root
+func Write(repoPath string, p *policy.Policy) error {
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("error marshaling policy: %w", err)
+	}
+	return os.WriteFile(filepath.Join(repoPath, policy.FileName), data, 0644)
+}
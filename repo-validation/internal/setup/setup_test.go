@@ -0,0 +1,106 @@
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+)
+
+func TestDetectGroups(t *testing.T) {
+	fsys := fstest.MapFS{
+		"package.json":             {Data: []byte("{}")},
+		"compose.yaml":             {Data: []byte("services: {}")},
+		".github/workflows/ci.yml": {Data: []byte("on: push")},
+	}
+
+	want := []string{"docker", "typescript", "github"}
+	if got := DetectGroups(fsys); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	existing := &policy.Policy{
+		Groups:    []string{"devenv"},
+		Variables: map[string]string{LicenseVariable: "MIT"},
+	}
+
+	a := Defaults(fstest.MapFS{"Dockerfile": {Data: []byte("FROM scratch")}}, existing)
+	if want := []string{"docker", "devenv"}; !reflect.DeepEqual(a.Groups, want) {
+		t.Errorf("Expected groups %v, got %v", want, a.Groups)
+	}
+	if a.License != "MIT" {
+		t.Errorf("Expected license MIT, got %s", a.License)
+	}
+	if !slices.Contains(a.MustHave, "README.md") || !slices.Contains(a.MustHave, "Dockerfile") || slices.Contains(a.MustHave, "AUTHORS") {
+		t.Errorf("Expected the must-have files of the core and Docker groups, got %v", a.MustHave)
+	}
+
+	if a := Defaults(fstest.MapFS{}, &policy.Policy{}); a.License != DefaultLicense || len(a.Groups) != 0 {
+		t.Errorf("Expected the default license and no groups, got %+v", a)
+	}
+}
+
+func TestApply(t *testing.T) {
+	existing := &policy.Policy{
+		Variables: map[string]string{"Org": "Acme", LicenseVariable: "MIT"},
+		Waivers:   []policy.Waiver{{Path: "CODEOWNERS", Reason: "single maintainer"}},
+	}
+
+	mustHave := MustHave(existing)
+	mustHave = slices.DeleteFunc(mustHave, func(path string) bool { return path == "LICENSE.md" })
+	mustHave = append(mustHave, "AUTHORS")
+
+	p := Apply(existing, Answers{Groups: []string{"github", "docker"}, License: DefaultLicense, MustHave: mustHave})
+
+	if want := []string{"docker", "github"}; !reflect.DeepEqual(p.Groups, want) {
+		t.Errorf("Expected groups %v, got %v", want, p.Groups)
+	}
+	if _, ok := p.Variables[LicenseVariable]; ok || p.Variables["Org"] != "Acme" {
+		t.Errorf("Expected the license variable to be removed for the default license, got %v", p.Variables)
+	}
+	if existing.Variables[LicenseVariable] != "MIT" {
+		t.Error("Expected the existing policy to stay unchanged")
+	}
+	if len(p.Waivers) != 1 {
+		t.Errorf("Expected the waivers to be kept, got %v", p.Waivers)
+	}
+
+	priorities := map[string]string{}
+	for _, req := range Requirements(p) {
+		priorities[req.Path] = req.Priority
+	}
+	if priorities["AUTHORS"] != config.PriorityMustHave || priorities["LICENSE.md"] != config.PriorityShouldHave {
+		t.Errorf("Expected AUTHORS to be promoted and LICENSE.md demoted, got %v", priorities)
+	}
+	// Must-have files of new groups that were not selected are demoted too
+	if priorities["Dockerfile"] != config.PriorityShouldHave {
+		t.Errorf("Expected Dockerfile to be demoted, got %s", priorities["Dockerfile"])
+	}
+	if len(p.Requirements) != 3 {
+		t.Errorf("Expected overrides for AUTHORS, LICENSE.md and Dockerfile, got %+v", p.Requirements)
+	}
+
+	t.Run("write", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := Write(dir, p); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		loaded, err := policy.Load(dir)
+		if err != nil {
+			t.Fatalf("Expected the written policy to load, got %v", err)
+		}
+		if !reflect.DeepEqual(loaded, p) {
+			t.Errorf("Expected %+v, got %+v", p, loaded)
+		}
+		if _, err := os.Stat(filepath.Join(dir, policy.FileName)); err != nil {
+			t.Errorf("Expected the policy file, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/gitignore"
+)
+
+// now returns the current time, it is a variable so tests can pin it
+var now = time.Now
+
+// nonSlugChars matches runs of characters that are not allowed in a slug
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// FuncMap returns the functions available to templates
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		// Strings
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"title":      title,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":       func(sep string, elems []string) string { return strings.Join(elems, sep) },
+		"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+		"quote":      func(s string) string { return fmt.Sprintf("%q", s) },
+		"slugify":    slugify,
+		"indent":     indent,
+		"nindent":    func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+
+		// Dates
+		"now":  func() time.Time { return now() },
+		"date": func(layout string, t time.Time) string { return t.Format(layout) },
+		"year": func() int { return now().Year() },
+
+		// Defaults and required values
+		"default":  defaultValue,
+		"coalesce": coalesce,
+		"empty":    isEmpty,
+		"required": required,
+
+		// Ignore files
+		"gitignore": gitignore.Compose,
+
+		// SPDX licenses
+		"spdxName": func(id string) (string, error) {
+			license, err := LookupLicense(id)
+			return license.Name, err
+		},
+		"spdxURL": func(id string) (string, error) {
+			license, err := LookupLicense(id)
+			return license.URL, err
+		},
+	}
+}
+
+// title upper-cases the first letter of every word
+func title(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		if i == 0 || unicode.IsSpace(runes[i-1]) || runes[i-1] == '-' || runes[i-1] == '_' {
+			runes[i] = unicode.ToTitle(r)
+		}
+	}
+	return string(runes)
+}
+
+// slugify converts a string into a lowercase, dash-separated identifier
+func slugify(s string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+// indent prefixes every non-empty line of s with the given number of spaces
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isEmpty returns true for nil and zero values, including empty strings, slices and maps
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// defaultValue returns value unless it is empty, in which case def is returned
+func defaultValue(def, value interface{}) interface{} {
+	if isEmpty(value) {
+		return def
+	}
+	return value
+}
+
+// coalesce returns the first non-empty value
+func coalesce(values ...interface{}) interface{} {
+	for _, value := range values {
+		if !isEmpty(value) {
+			return value
+		}
+	}
+	return nil
+}
+
+// required fails template execution with message if value is empty
+func required(message string, value interface{}) (interface{}, error) {
+	if isEmpty(value) {
+		return nil, errors.New(message)
+	}
+	return value, nil
+}
@@ -0,0 +1,101 @@
+package templates
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// execute renders an inline template with the function library
+func execute(t *testing.T, text string, data map[string]interface{}) (string, error) {
+	t.Helper()
+
+	tmpl, err := Parse("inline.tmpl", []byte(text))
+	if err != nil {
+		t.Fatalf("Failed to parse %q: %v", text, err)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	return buf.String(), err
+}
+
+func TestFuncMap(t *testing.T) {
+	now = func() time.Time { return time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	data := map[string]interface{}{
+		"Name":  "My Cool_Project",
+		"Empty": "",
+		"Lines": "a\nb",
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "lower", text: `{{ lower .Name }}`, want: "my cool_project"},
+		{name: "upper", text: `{{ upper .Name }}`, want: "MY COOL_PROJECT"},
+		{name: "title", text: `{{ title "hello wide-world" }}`, want: "Hello Wide-World"},
+		{name: "replace", text: `{{ .Name | replace " " "-" }}`, want: "My-Cool_Project"},
+		{name: "trim", text: `{{ trim "  x  " }}`, want: "x"},
+		{name: "slugify", text: `{{ slugify .Name }}`, want: "my-cool-project"},
+		{name: "indent", text: `{{ indent 2 .Lines }}`, want: "  a\n  b"},
+		{name: "nindent", text: `x:{{ nindent 2 .Lines }}`, want: "x:\n  a\n  b"},
+		{name: "date", text: `{{ now | date "2006-01-02" }}`, want: "2026-03-14"},
+		{name: "year", text: `© {{ year }}`, want: "© 2026"},
+		{name: "default for empty value", text: `{{ default "Acme" .Empty }}`, want: "Acme"},
+		{name: "default for missing key via index", text: `{{ default "Acme" (index . "Org") }}`, want: "Acme"},
+		{name: "default keeps value", text: `{{ default "Acme" .Name }}`, want: "My Cool_Project"},
+		{name: "coalesce", text: `{{ coalesce .Empty "" "first" }}`, want: "first"},
+		{name: "spdxName", text: `{{ spdxName "mit" }}`, want: "MIT License"},
+		{name: "spdxURL", text: `{{ spdxURL "EUPL-1.2" }}`, want: "https://spdx.org/licenses/EUPL-1.2.html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := execute(t, tt.text, data)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFuncMapErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr string
+	}{
+		{name: "required", text: `{{ required "Org must be set" .Empty }}`, wantErr: "Org must be set"},
+		{name: "unknown license", text: `{{ spdxName "NOPE" }}`, wantErr: "unknown SPDX license identifier"},
+		{name: "missing key", text: "line one\n{{ .Org }}", wantErr: `inline.tmpl:2:3: executing "inline.tmpl" at <.Org>: map has no entry for key "Org"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := execute(t, tt.text, map[string]interface{}{"Empty": ""})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLicenses(t *testing.T) {
+	licenses := Licenses()
+	if len(licenses) == 0 {
+		t.Fatalf("Expected known licenses, got none")
+	}
+	for i := 1; i < len(licenses); i++ {
+		if licenses[i-1].ID > licenses[i].ID {
+			t.Errorf("Expected licenses sorted by ID, got %s before %s", licenses[i-1].ID, licenses[i].ID)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package templates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/archivefs"
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+	"gopkg.in/yaml.v3"
+)
+
+// SourcePack is the source kind for templates loaded from a template pack
+const SourcePack = "pack"
+
+// ManifestName is the name of the manifest at the root of a template pack
+const ManifestName = "pack.yaml"
+
+// Manifest describes the contents of a template pack
+type Manifest struct {
+	// Name is the name of the pack
+	Name string `yaml:"name"`
+	// Version is the version of the pack
+	Version string `yaml:"version"`
+	// Templates are the templates provided by the pack
+	Templates []ManifestTemplate `yaml:"templates"`
+}
+
+// ManifestTemplate describes a single template in a pack
+type ManifestTemplate struct {
+	// Name is the file name of the template, relative to the pack root
+	Name string `yaml:"name"`
+	// Requirements are the paths of the file requirements this template generates
+	Requirements []string `yaml:"requirements,omitempty"`
+	// Variables are the template variables that must be provided to render the template
+	Variables []string `yaml:"variables,omitempty"`
+	// SHA256 is the checksum of the template file
+	SHA256 string `yaml:"sha256"`
+}
+
+// Pack is a verified template pack
+type Pack struct {
+	// Manifest is the pack manifest
+	Manifest Manifest
+	// Path is the location the pack was loaded from
+	Path string
+	// FS contains only the templates listed in the manifest
+	FS fs.FS
+}
+
+// Checksum returns the hex-encoded SHA-256 checksum of data
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadPack loads a template pack from a directory or archive and verifies the checksum of
+// every template against the manifest
+func LoadPack(path string) (*Pack, error) {
+	src, err := archivefs.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening template pack %s: %w", path, err)
+	}
+
+	return loadPack(path, src)
+}
+
+// loadPack reads and verifies the manifest of an opened template pack
+func loadPack(path string, src fs.FS) (*Pack, error) {
+	data, err := fs.ReadFile(src, ManifestName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest of template pack %s: %w", path, err)
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("error parsing manifest of template pack %s: %w", path, err)
+	}
+	if manifest.Name == "" || manifest.Version == "" {
+		return nil, fmt.Errorf("manifest of template pack %s must have a name and a version", path)
+	}
+
+	verified := archivefs.New()
+	for _, tmpl := range manifest.Templates {
+		if tmpl.Name == "" || tmpl.SHA256 == "" {
+			return nil, fmt.Errorf("template pack %s: every template needs a name and a sha256", path)
+		}
+
+		content, err := fs.ReadFile(src, tmpl.Name)
+		if err != nil {
+			return nil, fmt.Errorf("template pack %s: error reading %s: %w", path, tmpl.Name, err)
+		}
+		if sum := Checksum(content); !strings.EqualFold(sum, tmpl.SHA256) {
+			return nil, fmt.Errorf("template pack %s: checksum mismatch for %s: expected %s, got %s", path, tmpl.Name, tmpl.SHA256, sum)
+		}
+
+		verified.AddFile(tmpl.Name, content, 0644, time.Time{})
+	}
+
+	return &Pack{
+		Manifest: manifest,
+		Path:     path,
+		FS:       verified,
+	}, nil
+}
+
+// LoadPacks loads the packs referenced by a policy, checking pinned checksums and versions.
+// For an archive the pinned checksum covers the whole file, for a directory it covers the manifest.
+func LoadPacks(repoPath string, refs []policy.PackRef) ([]*Pack, error) {
+	return LoadPacksFS(os.DirFS(repoPath), repoPath, refs)
+}
+
+// LoadPacksFS is like LoadPacks, but reads packs inside the repository from the repository
+// tree fsys. Packs outside the repository are read from disk. If roots are given, packs outside
+// the repository must be below one of them once symlinks are resolved, and are read without
+// following symlinks out of it.
+func LoadPacksFS(fsys fs.FS, repoPath string, refs []policy.PackRef, roots ...string) ([]*Pack, error) {
+	var packs []*Pack
+
+	for _, ref := range refs {
+		pack, err := loadPackRef(fsys, repoPath, ref, roots)
+		if err != nil {
+			return nil, err
+		}
+		packs = append(packs, pack)
+	}
+
+	return packs, nil
+}
+
+// loadPackRef loads the pack a policy references. Packs are read into memory, so a root a pack
+// is confined to is closed once it is loaded.
+func loadPackRef(fsys fs.FS, repoPath string, ref policy.PackRef, roots []string) (*Pack, error) {
+	packFS, name := fsys, path.Clean(filepath.ToSlash(ref.Path))
+	if filepath.IsAbs(ref.Path) || !fs.ValidPath(name) {
+		resolved := policy.ResolvePath(repoPath, ref.Path)
+		packFS, name = os.DirFS(filepath.Dir(resolved)), filepath.Base(resolved)
+		if len(roots) > 0 {
+			root, rel, err := confine(resolved, roots)
+			if err != nil {
+				return nil, fmt.Errorf("error opening template pack %s: %w", ref.Path, err)
+			}
+			defer root.Close()
+			packFS, name = root.FS(), rel
+		}
+	}
+
+	if ref.SHA256 != "" {
+		pinned := name
+		if stat, err := fs.Stat(packFS, name); err == nil && stat.IsDir() {
+			pinned = path.Join(name, ManifestName)
+		}
+		data, err := fs.ReadFile(packFS, pinned)
+		if err != nil {
+			return nil, fmt.Errorf("error reading template pack %s: %w", ref.Path, err)
+		}
+		if sum := Checksum(data); !strings.EqualFold(sum, ref.SHA256) {
+			return nil, fmt.Errorf("template pack %s: checksum mismatch: expected %s, got %s", ref.Path, ref.SHA256, sum)
+		}
+	}
+
+	src, err := archivefs.OpenFS(packFS, name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening template pack %s: %w", ref.Path, err)
+	}
+
+	pack, err := loadPack(policy.ResolvePath(repoPath, ref.Path), src)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref.Version != "" && ref.Version != pack.Manifest.Version {
+		return nil, fmt.Errorf("template pack %s: expected version %s, got %s", ref.Path, ref.Version, pack.Manifest.Version)
+	}
+
+	return pack, nil
+}
+
+// confine opens the root a pack is below and returns the slash-separated path of the pack
+// within it, so the pack is read without following symlinks out of the root. Symlinks are
+// resolved in both the pack path and the roots before comparing them.
+func confine(resolved string, roots []string) (*os.Root, string, error) {
+	real, err := filepath.EvalSymlinks(resolved)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, dir := range roots {
+		if realDir, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = realDir
+		}
+		rel, err := filepath.Rel(dir, real)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		root, err := os.OpenRoot(dir)
+		if err != nil {
+			return nil, "", err
+		}
+		return root, filepath.ToSlash(rel), nil
+	}
+
+	return nil, "", fmt.Errorf("%s is not below an allowed base directory", real)
+}
+
+// Source returns the template source for the pack
+func (p *Pack) Source() Source {
+	return Source{
+		Kind:     SourcePack,
+		Location: fmt.Sprintf("%s@%s (%s)", p.Manifest.Name, p.Manifest.Version, p.Path),
+		FS:       p.FS,
+		Pack:     p,
+	}
+}
+
+// Template returns the manifest entry for a template
+func (p *Pack) Template(name string) (ManifestTemplate, bool) {
+	name = Normalize(name)
+	for _, tmpl := range p.Manifest.Templates {
+		if Normalize(tmpl.Name) == name {
+			return tmpl, true
+		}
+	}
+	return ManifestTemplate{}, false
+}
+
+// MissingVariables returns the variables a pack template needs that are absent from data
+func (p *Pack) MissingVariables(name string, data map[string]interface{}) []string {
+	tmpl, ok := p.Template(name)
+	if !ok {
+		return nil
+	}
+
+	var missing []string
+	for _, variable := range tmpl.Variables {
+		if value, ok := data[variable]; !ok || value == "" {
+			missing = append(missing, variable)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,145 @@
+package templates
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/policy"
+)
+
+// writePack writes a directory pack with a single CONTRIBUTING.md template
+func writePack(t *testing.T, dir, content, checksum string) {
+	t.Helper()
+
+	if checksum == "" {
+		checksum = Checksum([]byte(content))
+	}
+	manifest := fmt.Sprintf(`name: acme
+version: 1.2.0
+templates:
+  - name: CONTRIBUTING.md.tmpl
+    requirements: [CONTRIBUTING.md]
+    variables: [Org]
+    sha256: %s
+`, checksum)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create pack directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ManifestName), []byte(manifest), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "CONTRIBUTING.md.tmpl"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+}
+
+func TestLoadPack(t *testing.T) {
+	t.Run("directory pack", func(t *testing.T) {
+		dir := t.TempDir()
+		writePack(t, dir, "Contribute to {{.Org}}", "")
+
+		pack, err := LoadPack(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if pack.Manifest.Name != "acme" || pack.Manifest.Version != "1.2.0" {
+			t.Errorf("Expected acme@1.2.0, got %s@%s", pack.Manifest.Name, pack.Manifest.Version)
+		}
+		if _, ok := pack.Template("CONTRIBUTING.md"); !ok {
+			t.Errorf("Expected pack to provide CONTRIBUTING.md.tmpl")
+		}
+		if missing := pack.MissingVariables("CONTRIBUTING.md.tmpl", map[string]interface{}{}); len(missing) != 1 || missing[0] != "Org" {
+			t.Errorf("Expected Org to be missing, got %v", missing)
+		}
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		dir := t.TempDir()
+		writePack(t, dir, "tampered", Checksum([]byte("original")))
+
+		if _, err := LoadPack(dir); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+			t.Errorf("Expected checksum mismatch error, got %v", err)
+		}
+	})
+
+	t.Run("zip pack with top-level directory", func(t *testing.T) {
+		src := t.TempDir()
+		writePack(t, src, "zipped", "")
+
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, name := range []string{ManifestName, "CONTRIBUTING.md.tmpl"} {
+			content, err := os.ReadFile(filepath.Join(src, name))
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", name, err)
+			}
+			w, err := zw.Create("acme-1.2.0/" + name)
+			if err != nil {
+				t.Fatalf("Failed to create zip entry: %v", err)
+			}
+			w.Write(content)
+		}
+		zw.Close()
+
+		archive := filepath.Join(t.TempDir(), "acme-1.2.0.zip")
+		if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+			t.Fatalf("Failed to write archive: %v", err)
+		}
+
+		packs, err := LoadPacks("/", []policy.PackRef{{Path: archive, SHA256: Checksum(buf.Bytes()), Version: "1.2.0"}})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(packs) != 1 {
+			t.Fatalf("Expected 1 pack, got %d", len(packs))
+		}
+
+		if _, err := LoadPacks("/", []policy.PackRef{{Path: archive, SHA256: Checksum([]byte("other"))}}); err == nil {
+			t.Errorf("Expected pinned checksum mismatch to fail, got nil")
+		}
+		if _, err := LoadPacks("/", []policy.PackRef{{Path: archive, Version: "2.0.0"}}); err == nil {
+			t.Errorf("Expected version mismatch to fail, got nil")
+		}
+	})
+}
+
+func TestResolverAddPack(t *testing.T) {
+	dir := t.TempDir()
+	writePack(t, dir, "pack contributing", "")
+
+	pack, err := LoadPack(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	resolver := NewResolver(EmbeddedSource())
+	resolver.AddPack(pack)
+
+	if kinds := []string{resolver.Sources[0].Kind, resolver.Sources[1].Kind}; kinds[0] != SourcePack || kinds[1] != SourceEmbedded {
+		t.Errorf("Expected pack before embedded, got %v", kinds)
+	}
+
+	if name := resolver.TemplateFor("CONTRIBUTING.md", ""); name != "CONTRIBUTING.md.tmpl" {
+		t.Errorf("Expected pack template for CONTRIBUTING.md, got %q", name)
+	}
+	if name := resolver.TemplateFor("README.md", "templates/README.md.tmpl"); name != "README.md.tmpl" {
+		t.Errorf("Expected requirement template for README.md, got %q", name)
+	}
+	if name := resolver.TemplateFor("AUTHORS", ""); name != "" {
+		t.Errorf("Expected no template for AUTHORS, got %q", name)
+	}
+
+	content, src, err := resolver.Resolve("CONTRIBUTING.md.tmpl")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if src.Pack != pack || string(content) != "pack contributing" {
+		t.Errorf("Expected template from pack, got %q from %s", content, src.Location)
+	}
+}
@@ -0,0 +1,139 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// PartialPrefix marks template files that are partials, e.g. _footer.tmpl defines the
+// partial "footer" which other templates include with {{ template "footer" . }}
+const PartialPrefix = "_"
+
+// missingKey matches the error of a template that references a variable that is not set
+var missingKey = regexp.MustCompile(`map has no entry for key "([^"]+)"`)
+
+// MissingVariablesError is returned when a pack template is rendered without the variables
+// its manifest declares
+type MissingVariablesError struct {
+	// Template is the name of the template
+	Template string
+	// Location is the location of the pack
+	Location string
+	// Names are the variables that are not set
+	Names []string
+}
+
+// Error implements the error interface
+func (e *MissingVariablesError) Error() string {
+	return fmt.Sprintf("template %s from %s requires variables: %s", e.Template, e.Location, strings.Join(e.Names, ", "))
+}
+
+// MissingVariables returns the variables a render error reports as not set, declared by a
+// pack manifest or referenced by the template, or nil for other errors
+func MissingVariables(err error) []string {
+	var missing *MissingVariablesError
+	if errors.As(err, &missing) {
+		return missing.Names
+	}
+	if err == nil {
+		return nil
+	}
+	if m := missingKey.FindStringSubmatch(err.Error()); m != nil {
+		return []string{m[1]}
+	}
+	return nil
+}
+
+// IsPartial returns true if the template name is a partial
+func IsPartial(name string) bool {
+	return strings.HasPrefix(path.Base(name), PartialPrefix)
+}
+
+// PartialName returns the name a partial is included by
+func PartialName(name string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(path.Base(name), PartialPrefix), TemplateExt)
+}
+
+// Parse parses a template with the function library, failing on missing keys
+func Parse(name string, content []byte) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(FuncMap()).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %w", err)
+	}
+	return tmpl, nil
+}
+
+// Render resolves and renders a template. Partials from every source are available to it,
+// each resolved with the same precedence as regular templates. Errors name the template and
+// the line that failed.
+func (r *Resolver) Render(name string, data map[string]interface{}) ([]byte, Source, error) {
+	name = Normalize(name)
+
+	content, src, err := r.Resolve(name)
+	if err != nil {
+		return nil, src, err
+	}
+
+	// Pack templates declare the variables they need
+	if src.Pack != nil {
+		if missing := src.Pack.MissingVariables(name, data); len(missing) > 0 {
+			return nil, src, &MissingVariablesError{Template: name, Location: src.Location, Names: missing}
+		}
+	}
+
+	rendered, err := r.renderContent(name, content, data)
+	if err != nil {
+		return nil, src, err
+	}
+
+	return rendered, src, nil
+}
+
+// renderContent renders template content with the partials from the search path
+func (r *Resolver) renderContent(name string, content []byte, data map[string]interface{}) ([]byte, error) {
+	tmpl, err := Parse(name, content)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.addPartials(tmpl); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return nil, fmt.Errorf("error executing %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// addPartials associates every partial in the search path with tmpl
+func (r *Resolver) addPartials(tmpl *template.Template) error {
+	resolutions, err := r.List()
+	if err != nil {
+		return err
+	}
+
+	for _, res := range resolutions {
+		if !IsPartial(res.Name) || res.Name == tmpl.Name() {
+			continue
+		}
+
+		content, _, err := r.Resolve(res.Name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tmpl.New(PartialName(res.Name)).Parse(string(content)); err != nil {
+			return fmt.Errorf("error parsing %w", err)
+		}
+	}
+
+	return nil
+}
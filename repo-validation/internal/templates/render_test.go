@@ -0,0 +1,147 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderWithPartials(t *testing.T) {
+	pack := Source{Kind: SourcePack, Location: "pack", FS: fstest.MapFS{
+		"README.md.tmpl": {Data: []byte("# {{ .RepoName }}\n{{ template \"footer\" . }}")},
+		"_footer.tmpl":   {Data: []byte("Maintained by {{ .Org }}")},
+	}}
+	repo := Source{Kind: SourceRepo, Location: "repo", FS: fstest.MapFS{
+		"_footer.tmpl": {Data: []byte("Owned by {{ upper .Org }}")},
+	}}
+
+	t.Run("partial from the same source", func(t *testing.T) {
+		resolver := NewResolver(pack, EmbeddedSource())
+
+		content, _, err := resolver.Render("README.md", map[string]interface{}{"RepoName": "demo", "Org": "Acme"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if want := "# demo\nMaintained by Acme"; string(content) != want {
+			t.Errorf("Expected %q, got %q", want, content)
+		}
+	})
+
+	t.Run("partial overridden by a higher-precedence source", func(t *testing.T) {
+		resolver := NewResolver(repo, pack, EmbeddedSource())
+
+		content, _, err := resolver.Render("README.md", map[string]interface{}{"RepoName": "demo", "Org": "Acme"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if want := "# demo\nOwned by ACME"; string(content) != want {
+			t.Errorf("Expected %q, got %q", want, content)
+		}
+	})
+
+	t.Run("missing key names the template and line", func(t *testing.T) {
+		resolver := NewResolver(pack, EmbeddedSource())
+
+		_, _, err := resolver.Render("README.md", map[string]interface{}{"RepoName": "demo", "Stacks": []string{"go"}})
+		if err == nil || !strings.Contains(err.Error(), `footer:1:17: executing "footer" at <.Org>`) {
+			t.Errorf("Expected error naming the footer partial and line, got %v", err)
+		}
+		if missing := MissingVariables(err); len(missing) != 1 || missing[0] != "Org" {
+			t.Errorf("Expected Org to be reported missing, got %v", missing)
+		}
+	})
+}
+
+func TestMissingVariables(t *testing.T) {
+	err := fmt.Errorf("error generating file: %w", &MissingVariablesError{Template: "CONTRIBUTING.md.tmpl", Location: "pack", Names: []string{"Org", "Team"}})
+	if missing := MissingVariables(err); len(missing) != 2 || missing[1] != "Team" {
+		t.Errorf("Expected the declared variables, got %v", missing)
+	}
+	if !strings.Contains(err.Error(), "requires variables: Org, Team") {
+		t.Errorf("Expected the variables in the message, got %v", err)
+	}
+	if missing := MissingVariables(errors.New("permission denied")); missing != nil {
+		t.Errorf("Expected no variables for other errors, got %v", missing)
+	}
+}
+
+func TestRenderEmbedded(t *testing.T) {
+	resolver := NewResolver(EmbeddedSource())
+
+	resolutions, err := resolver.List()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, res := range resolutions {
+		var err error
+		if res.Dir {
+			_, err = resolver.RenderTree(res.Name, map[string]interface{}{"RepoName": "demo", "Stacks": []string{"go"}})
+		} else {
+			_, _, err = resolver.Render(res.Name, map[string]interface{}{"RepoName": "demo", "Stacks": []string{"go"}})
+		}
+		if err != nil {
+			t.Errorf("Expected built-in template %s to render, got %v", res.Name, err)
+		}
+	}
+}
+
+func TestPartialName(t *testing.T) {
+	if !IsPartial("_footer.tmpl") || IsPartial("README.md.tmpl") {
+		t.Errorf("Expected only underscore-prefixed templates to be partials")
+	}
+	if got := PartialName("_footer.tmpl"); got != "footer" {
+		t.Errorf("Expected partial name footer, got %s", got)
+	}
+}
+
+func TestRenderLicense(t *testing.T) {
+	resolver := NewResolver(EmbeddedSource())
+
+	t.Run("default", func(t *testing.T) {
+		license, _, err := resolver.Render("LICENSE.md", map[string]interface{}{"RepoName": "demo"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !strings.HasPrefix(string(license), "                      EUROPEAN UNION PUBLIC LICENCE v. 1.2\n") || !strings.HasSuffix(string(license), "EUPL version.\n") {
+			t.Errorf("Expected the EUPL text, got %q...", license[:80])
+		}
+		if strings.Contains(string(license), PlaceholderMarker) {
+			t.Error("Expected the EUPL text not to be marked as a placeholder")
+		}
+
+		readme, _, err := resolver.Render("README.md", map[string]interface{}{"RepoName": "demo"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !strings.HasPrefix(string(readme), "# Project Name\n\n[![License: EUPL-1.2](https://img.shields.io/badge/License-EUPL--1.2-blue.svg)](https://joinup.ec.europa.eu/software/page/eupl)\n") {
+			t.Errorf("Expected the EUPL badge, got %q", readme)
+		}
+	})
+
+	t.Run("license variable", func(t *testing.T) {
+		data := map[string]interface{}{"RepoName": "demo", "License": "Apache-2.0"}
+		license, _, err := resolver.Render("LICENSE.md", data)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !strings.HasPrefix(string(license), "Apache License 2.0 (Apache-2.0)\n") || !strings.Contains(string(license), "https://spdx.org/licenses/Apache-2.0.html") {
+			t.Errorf("Expected an Apache-2.0 notice, got %q", license)
+		}
+		if !strings.Contains(string(license), PlaceholderMarker) {
+			t.Errorf("Expected the notice to be marked as a placeholder, got %q", license)
+		}
+
+		readme, _, err := resolver.Render("README.md", data)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for _, want := range []string{"License-Apache--2.0-blue.svg", "licensed under the Apache License 2.0 - see"} {
+			if !strings.Contains(string(readme), want) {
+				t.Errorf("Expected README to contain %q, got %q", want, readme)
+			}
+		}
+	})
+}
@@ -0,0 +1,309 @@
+package templates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Source kinds, in order of precedence
+const (
+	SourceRepo     = "repo"
+	SourceUser     = "user"
+	SourceOrg      = "org"
+	SourceEmbedded = "embedded"
+)
+
+// RepoTemplateDir is the template override directory, relative to the repository root
+const RepoTemplateDir = ".repo-validation/templates"
+
+// TemplateExt is the file extension used by template files
+const TemplateExt = ".tmpl"
+
+// Source is a location templates can be loaded from
+type Source struct {
+	// Kind is the kind of the source (repo, user, org, pack, embedded)
+	Kind string
+	// Location is a human-readable description of where the source lives
+	Location string
+	// FS is the filesystem the templates are read from
+	FS fs.FS
+	// Pack is the template pack backing the source, if any
+	Pack *Pack
+}
+
+// EmbeddedSource returns the source for the built-in templates
+func EmbeddedSource() Source {
+	return Source{
+		Kind:     SourceEmbedded,
+		Location: "built-in",
+		FS:       TemplateFS,
+	}
+}
+
+// DirSource returns a source that reads templates from a directory on disk
+func DirSource(kind, dir string) Source {
+	return Source{
+		Kind:     kind,
+		Location: dir,
+		FS:       os.DirFS(dir),
+	}
+}
+
+// repoSource returns the source for the override directory of a repository tree
+func repoSource(repoFS fs.FS, location string) Source {
+	// Sub only fails for invalid paths, RepoTemplateDir is valid
+	dir, _ := fs.Sub(repoFS, RepoTemplateDir)
+	return Source{
+		Kind:     SourceRepo,
+		Location: filepath.Join(location, RepoTemplateDir),
+		FS:       dir,
+	}
+}
+
+// UserTemplateDir returns the per-user template override directory
+func UserTemplateDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "repo-validation", "templates"), nil
+}
+
+// DefaultSources returns the template search path for a repository: the repository-local
+// override directory, the user config directory, the organisation directories and finally
+// the built-in templates. Template packs are added with AddPack.
+func DefaultSources(repoPath string, orgDirs []string) []Source {
+	return DefaultSourcesFS(os.DirFS(repoPath), repoPath, orgDirs)
+}
+
+// DefaultSourcesFS is like DefaultSources, but reads the repository-local override directory
+// from the repository tree fsys. Location describes the repository in source listings.
+func DefaultSourcesFS(repoFS fs.FS, location string, orgDirs []string) []Source {
+	sources := []Source{
+		repoSource(repoFS, location),
+	}
+
+	if dir, err := UserTemplateDir(); err == nil {
+		sources = append(sources, DirSource(SourceUser, dir))
+	}
+
+	for _, dir := range orgDirs {
+		if dir == "" {
+			continue
+		}
+		sources = append(sources, DirSource(SourceOrg, dir))
+	}
+
+	return append(sources, EmbeddedSource())
+}
+
+// Resolver looks up templates in an ordered list of sources, the first match wins
+type Resolver struct {
+	// Sources is the search path, highest precedence first
+	Sources []Source
+	// requirements maps requirement paths to template names declared by packs
+	requirements map[string]string
+}
+
+// NewResolver creates a new Resolver
+func NewResolver(sources ...Source) *Resolver {
+	return &Resolver{
+		Sources:      sources,
+		requirements: make(map[string]string),
+	}
+}
+
+// AddPack registers a template pack, placing it after the directory sources and before the
+// built-in templates. Requirements targeted by the pack are mapped to its templates unless an
+// earlier pack already claimed them.
+func (r *Resolver) AddPack(p *Pack) {
+	pos := len(r.Sources)
+	for pos > 0 && r.Sources[pos-1].Kind == SourceEmbedded {
+		pos--
+	}
+	r.Sources = append(r.Sources[:pos], append([]Source{p.Source()}, r.Sources[pos:]...)...)
+
+	for _, tmpl := range p.Manifest.Templates {
+		// Files of a directory template map the requirement to the directory
+		name := strings.SplitN(tmpl.Name, "/", 2)[0]
+		for _, req := range tmpl.Requirements {
+			if _, claimed := r.requirements[req]; !claimed {
+				r.requirements[req] = Normalize(name)
+			}
+		}
+	}
+}
+
+// TemplateFor returns the template name for a requirement, preferring templates declared by
+// packs over the requirement's own template path. It returns "" if there is no template.
+func (r *Resolver) TemplateFor(reqPath, templatePath string) string {
+	if name, ok := r.requirements[reqPath]; ok {
+		return name
+	}
+	if templatePath == "" {
+		return ""
+	}
+	return Normalize(templatePath)
+}
+
+// Resolution describes which source provides a template
+type Resolution struct {
+	// Name is the template name
+	Name string
+	// Dir indicates a directory template
+	Dir bool
+	// Source is the source that wins for this template
+	Source Source
+	// Shadowed are the lower-precedence sources that also provide the template
+	Shadowed []Source
+}
+
+// Normalize returns the canonical name for a template, accepting a template path or a
+// name without the template extension
+func Normalize(name string) string {
+	name = path.Base(filepath.ToSlash(name))
+	if !strings.HasSuffix(name, TemplateExt) {
+		name += TemplateExt
+	}
+	return name
+}
+
+// Resolve reads the named template from the first source that provides it
+func (r *Resolver) Resolve(name string) ([]byte, Source, error) {
+	name = Normalize(name)
+
+	for _, src := range r.Sources {
+		content, err := fs.ReadFile(src.FS, name)
+		if err == nil {
+			return content, src, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, src, fmt.Errorf("error reading template %s from %s: %w", name, src.Location, err)
+		}
+	}
+
+	return nil, Source{}, fmt.Errorf("template %s not found: %w", name, fs.ErrNotExist)
+}
+
+// Version returns the version of a template: the version of its pack, or a content hash of
+// the template for other sources, covering every file of a directory template
+func (r *Resolver) Version(name string) (string, error) {
+	name = Normalize(name)
+
+	src, isDir, err := r.Lookup(name)
+	if err != nil {
+		return "", err
+	}
+	if src.Pack != nil {
+		return src.Pack.Manifest.Version, nil
+	}
+
+	h := sha256.New()
+	err = fs.WalkDir(src.FS, name, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(src.FS, p)
+		if err != nil {
+			return err
+		}
+		// Paths are part of the hash, so renaming a file of a directory template changes it
+		if isDir {
+			fmt.Fprintf(h, "%s\x00%d\x00", strings.TrimPrefix(p, name+"/"), len(content))
+		}
+		h.Write(content)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error reading template %s from %s: %w", name, src.Location, err)
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))[:12], nil
+}
+
+// List returns every template known to the resolver along with the source that wins for it
+func (r *Resolver) List() ([]Resolution, error) {
+	byName := make(map[string]*Resolution)
+	var names []string
+
+	for _, src := range r.Sources {
+		entries, err := fs.ReadDir(src.FS, ".")
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("error listing templates in %s: %w", src.Location, err)
+		}
+
+		for _, entry := range entries {
+			if !strings.HasSuffix(entry.Name(), TemplateExt) {
+				continue
+			}
+
+			if res, ok := byName[entry.Name()]; ok {
+				res.Shadowed = append(res.Shadowed, src)
+				continue
+			}
+
+			byName[entry.Name()] = &Resolution{Name: entry.Name(), Dir: entry.IsDir(), Source: src}
+			names = append(names, entry.Name())
+		}
+	}
+
+	sort.Strings(names)
+
+	resolutions := make([]Resolution, 0, len(names))
+	for _, name := range names {
+		resolutions = append(resolutions, *byName[name])
+	}
+
+	return resolutions, nil
+}
+
+// Eject copies a built-in template, or a whole directory template, into destDir so it can
+// be customised. It refuses to overwrite an existing template unless force is set.
+func Eject(name, destDir string, force bool) (string, error) {
+	name = Normalize(name)
+
+	if _, err := fs.Stat(TemplateFS, name); err != nil {
+		return "", fmt.Errorf("no built-in template named %s: %w", name, err)
+	}
+
+	dest := filepath.Join(destDir, name)
+	if _, err := os.Stat(dest); err == nil && !force {
+		return "", fmt.Errorf("%s already exists, use --force to overwrite it", dest)
+	}
+
+	err := fs.WalkDir(TemplateFS, name, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(destDir, filepath.FromSlash(p))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		content, err := fs.ReadFile(TemplateFS, p)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, content, 0644)
+	})
+	if err != nil {
+		return "", fmt.Errorf("error writing template %s: %w", dest, err)
+	}
+
+	return dest, nil
+}
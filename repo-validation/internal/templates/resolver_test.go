@@ -0,0 +1,124 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolverPrecedence(t *testing.T) {
+	repo := Source{Kind: SourceRepo, Location: "repo", FS: fstest.MapFS{
+		"README.md.tmpl": {Data: []byte("repo readme")},
+	}}
+	user := Source{Kind: SourceUser, Location: "user", FS: fstest.MapFS{
+		"README.md.tmpl":   {Data: []byte("user readme")},
+		"SECURITY.md.tmpl": {Data: []byte("user security")},
+	}}
+	resolver := NewResolver(repo, user, EmbeddedSource())
+
+	tests := []struct {
+		name       string
+		template   string
+		wantKind   string
+		wantPrefix string
+	}{
+		{name: "repo wins over user", template: "README.md.tmpl", wantKind: SourceRepo, wantPrefix: "repo readme"},
+		{name: "user wins over embedded", template: "templates/SECURITY.md.tmpl", wantKind: SourceUser, wantPrefix: "user security"},
+		{name: "embedded fallback", template: "LICENSE.md", wantKind: SourceEmbedded, wantPrefix: `{{ $license := default "EUPL-1.2"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, src, err := resolver.Resolve(tt.template)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if src.Kind != tt.wantKind {
+				t.Errorf("Expected source %s, got %s", tt.wantKind, src.Kind)
+			}
+			if len(content) < len(tt.wantPrefix) || string(content[:len(tt.wantPrefix)]) != tt.wantPrefix {
+				t.Errorf("Expected content to start with %q, got %q", tt.wantPrefix, content)
+			}
+		})
+	}
+
+	t.Run("missing template", func(t *testing.T) {
+		if _, _, err := resolver.Resolve("missing.tmpl"); err == nil {
+			t.Errorf("Expected error for missing template, got nil")
+		}
+	})
+}
+
+func TestResolverSkipsMissingDirectories(t *testing.T) {
+	resolver := NewResolver(DirSource(SourceOrg, filepath.Join(t.TempDir(), "missing")), EmbeddedSource())
+
+	if _, src, err := resolver.Resolve("README.md.tmpl"); err != nil || src.Kind != SourceEmbedded {
+		t.Errorf("Expected embedded README.md.tmpl, got source %q and error %v", src.Kind, err)
+	}
+
+	resolutions, err := resolver.List()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(resolutions) == 0 {
+		t.Errorf("Expected built-in templates to be listed")
+	}
+}
+
+func TestResolverList(t *testing.T) {
+	org := Source{Kind: SourceOrg, Location: "org", FS: fstest.MapFS{
+		".gitignore.tmpl": {Data: []byte("org")},
+		"notes.txt":       {Data: []byte("not a template")},
+	}}
+	resolver := NewResolver(org, EmbeddedSource())
+
+	resolutions, err := resolver.List()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	found := false
+	for _, res := range resolutions {
+		if res.Name == "notes.txt" {
+			t.Errorf("Expected non-template files to be ignored")
+		}
+		if res.Name == ".gitignore.tmpl" {
+			found = true
+			if res.Source.Kind != SourceOrg {
+				t.Errorf("Expected .gitignore.tmpl to resolve to org, got %s", res.Source.Kind)
+			}
+			if len(res.Shadowed) != 1 || res.Shadowed[0].Kind != SourceEmbedded {
+				t.Errorf("Expected .gitignore.tmpl to shadow the embedded template, got %v", res.Shadowed)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected .gitignore.tmpl to be listed")
+	}
+}
+
+func TestEject(t *testing.T) {
+	dir := t.TempDir()
+
+	dest, err := Eject("SECURITY.md", dir, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if dest != filepath.Join(dir, "SECURITY.md.tmpl") {
+		t.Errorf("Expected destination %s, got %s", filepath.Join(dir, "SECURITY.md.tmpl"), dest)
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Errorf("Expected ejected template to exist, got %v", err)
+	}
+
+	if _, err := Eject("SECURITY.md", dir, false); err == nil {
+		t.Errorf("Expected error when ejecting over an existing template, got nil")
+	}
+	if _, err := Eject("SECURITY.md", dir, true); err != nil {
+		t.Errorf("Expected --force to overwrite, got %v", err)
+	}
+	if _, err := Eject("unknown", dir, false); err == nil {
+		t.Errorf("Expected error for unknown template, got nil")
+	}
+}
@@ -0,0 +1,70 @@
+package templates
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// License describes an SPDX license
+type License struct {
+	// ID is the SPDX license identifier
+	ID string
+	// Name is the full name of the license
+	Name string
+	// URL is the SPDX reference URL of the license
+	URL string
+}
+
+// licenses lists the SPDX licenses known to the template function library
+var licenses = map[string]string{
+	"0BSD":              "BSD Zero Clause License",
+	"AGPL-3.0-only":     "GNU Affero General Public License v3.0 only",
+	"AGPL-3.0-or-later": "GNU Affero General Public License v3.0 or later",
+	"Apache-2.0":        "Apache License 2.0",
+	"BSD-2-Clause":      "BSD 2-Clause \"Simplified\" License",
+	"BSD-3-Clause":      "BSD 3-Clause \"New\" or \"Revised\" License",
+	"BSL-1.0":           "Boost Software License 1.0",
+	"CC0-1.0":           "Creative Commons Zero v1.0 Universal",
+	"EUPL-1.2":          "European Union Public License 1.2",
+	"GPL-2.0-only":      "GNU General Public License v2.0 only",
+	"GPL-2.0-or-later":  "GNU General Public License v2.0 or later",
+	"GPL-3.0-only":      "GNU General Public License v3.0 only",
+	"GPL-3.0-or-later":  "GNU General Public License v3.0 or later",
+	"ISC":               "ISC License",
+	"LGPL-2.1-only":     "GNU Lesser General Public License v2.1 only",
+	"LGPL-3.0-only":     "GNU Lesser General Public License v3.0 only",
+	"MIT":               "MIT License",
+	"MPL-2.0":           "Mozilla Public License 2.0",
+	"Unlicense":         "The Unlicense",
+}
+
+// LookupLicense returns the SPDX license with the given identifier, matched case-insensitively
+func LookupLicense(id string) (License, error) {
+	for known, name := range licenses {
+		if strings.EqualFold(known, id) {
+			return License{
+				ID:   known,
+				Name: name,
+				URL:  "https://spdx.org/licenses/" + known + ".html",
+			}, nil
+		}
+	}
+	return License{}, fmt.Errorf("unknown SPDX license identifier %q", id)
+}
+
+// Licenses returns all known SPDX licenses, sorted by identifier
+func Licenses() []License {
+	ids := make([]string, 0, len(licenses))
+	for id := range licenses {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	result := make([]License, 0, len(ids))
+	for _, id := range ids {
+		license, _ := LookupLicense(id)
+		result = append(result, license)
+	}
+	return result
+}
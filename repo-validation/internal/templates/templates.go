@@ -4,5 +4,11 @@ import (
 	"embed"
 )
 
+// PlaceholderMarker marks generated files that only stand in for content that has to be added
+// by hand, such as the text of a license. Files containing it are reported as missing.
+const PlaceholderMarker = "<!-- repo-validation:placeholder -->"
+
+// TemplateFS contains the built-in templates shipped with the binary
+//
 //go:embed *.tmpl
 var TemplateFS embed.FS
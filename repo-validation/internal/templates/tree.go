@@ -0,0 +1,132 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// RenderedFile is a file rendered from a directory template
+type RenderedFile struct {
+	// Path is the path of the file, relative to the target directory
+	Path string
+	// Content is the rendered content of the file
+	Content []byte
+}
+
+// Lookup returns the source that provides a template and whether it is a directory template
+func (r *Resolver) Lookup(name string) (Source, bool, error) {
+	name = Normalize(name)
+
+	for _, src := range r.Sources {
+		stat, err := fs.Stat(src.FS, name)
+		if err == nil {
+			return src, stat.IsDir(), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return src, false, fmt.Errorf("error reading template %s from %s: %w", name, src.Location, err)
+		}
+	}
+
+	return Source{}, false, fmt.Errorf("template %s not found: %w", name, fs.ErrNotExist)
+}
+
+// IsDir returns true if the named template resolves to a directory template
+func (r *Resolver) IsDir(name string) bool {
+	_, isDir, err := r.Lookup(name)
+	return err == nil && isDir
+}
+
+// TreePaths returns the rendered paths of the files in a directory template, without
+// rendering their contents
+func (r *Resolver) TreePaths(name string, data map[string]interface{}) ([]string, error) {
+	files, err := r.renderTree(name, data, false)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	return paths, nil
+}
+
+// RenderTree renders every file of a directory template. File names may contain template
+// actions, and the template extension is removed from them. The whole tree comes from the
+// highest-precedence source that provides the directory.
+func (r *Resolver) RenderTree(name string, data map[string]interface{}) ([]RenderedFile, error) {
+	return r.renderTree(name, data, true)
+}
+
+func (r *Resolver) renderTree(name string, data map[string]interface{}, withContent bool) ([]RenderedFile, error) {
+	name = Normalize(name)
+
+	src, isDir, err := r.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if !isDir {
+		return nil, fmt.Errorf("template %s is not a directory template", name)
+	}
+
+	tree, err := fs.Sub(src.FS, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []RenderedFile
+	err = fs.WalkDir(tree, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		target, err := renderPath(name+"/"+p, p, data)
+		if err != nil {
+			return err
+		}
+
+		file := RenderedFile{Path: target}
+		if withContent {
+			content, err := fs.ReadFile(tree, p)
+			if err != nil {
+				return err
+			}
+			if file.Content, err = r.renderContent(name+"/"+p, content, data); err != nil {
+				return err
+			}
+		}
+
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// renderPath renders the template actions in a file path and strips the template extension
+func renderPath(name, p string, data map[string]interface{}) (string, error) {
+	tmpl, err := Parse(name+" (path)", []byte(p))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("error executing %w", err)
+	}
+
+	target := strings.TrimSuffix(path.Clean(buf.String()), TemplateExt)
+	if !fs.ValidPath(target) || target == "." {
+		return "", fmt.Errorf("template %s renders to invalid path %q", name, buf.String())
+	}
+	return target, nil
+}
@@ -0,0 +1,111 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderTree(t *testing.T) {
+	org := Source{Kind: SourceOrg, Location: "org", FS: fstest.MapFS{
+		"docs.tmpl/index.md.tmpl":                   {Data: []byte("# {{ .RepoName }}")},
+		"docs.tmpl/{{ slugify .RepoName }}.md.tmpl": {Data: []byte("{{ template \"footer\" . }}")},
+		"docs.tmpl/static/logo.svg":                 {Data: []byte("<svg/>")},
+		"_footer.tmpl":                              {Data: []byte("footer")},
+	}}
+	resolver := NewResolver(org, EmbeddedSource())
+	data := map[string]interface{}{"RepoName": "My Repo"}
+
+	if !resolver.IsDir("docs") {
+		t.Fatalf("Expected docs to be a directory template")
+	}
+	if resolver.IsDir("README.md") {
+		t.Errorf("Expected README.md not to be a directory template")
+	}
+
+	files, err := resolver.RenderTree("docs", data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := map[string]string{
+		"index.md":        "# My Repo",
+		"my-repo.md":      "footer",
+		"static/logo.svg": "<svg/>",
+	}
+	if len(files) != len(want) {
+		t.Fatalf("Expected %d files, got %v", len(want), files)
+	}
+	for _, file := range files {
+		if content, ok := want[file.Path]; !ok || content != string(file.Content) {
+			t.Errorf("Unexpected file %s with content %q", file.Path, file.Content)
+		}
+	}
+
+	paths, err := resolver.TreePaths("docs", data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(paths) != 3 || paths[0] != "index.md" {
+		t.Errorf("Expected sorted tree paths, got %v", paths)
+	}
+
+	if _, err := resolver.RenderTree("README.md", data); err == nil {
+		t.Errorf("Expected error rendering a file template as a tree, got nil")
+	}
+}
+
+func TestRenderTreeRejectsEscapingPaths(t *testing.T) {
+	resolver := NewResolver(Source{Kind: SourceOrg, Location: "org", FS: fstest.MapFS{
+		"evil.tmpl/{{ .Target }}.tmpl": {Data: []byte("x")},
+	}})
+
+	if _, err := resolver.RenderTree("evil", map[string]interface{}{"Target": "../../etc/passwd"}); err == nil {
+		t.Errorf("Expected error for a path escaping the target, got nil")
+	}
+}
+
+func TestEjectDirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	dest, err := Eject(".github", dir, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "ISSUE_TEMPLATE", "bug_report.yml.tmpl")); err != nil {
+		t.Errorf("Expected directory template to be ejected, got %v", err)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	tree := fstest.MapFS{
+		"docs.tmpl/index.md.tmpl": {Data: []byte("# {{ .RepoName }}")},
+		"docs.tmpl/guide.md.tmpl": {Data: []byte("guide")},
+		"NOTICE.tmpl":             {Data: []byte("notice")},
+	}
+	resolver := NewResolver(Source{Kind: SourceOrg, Location: "org", FS: tree}, EmbeddedSource())
+
+	versions := map[string]string{}
+	for _, name := range []string{"docs", "NOTICE", "README.md"} {
+		version, err := resolver.Version(name)
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v", name, err)
+		}
+		if len(version) != len("sha256:")+12 || version[:7] != "sha256:" {
+			t.Errorf("Expected a content hash for %s, got %q", name, version)
+		}
+		versions[name] = version
+	}
+	if versions["docs"] == versions["NOTICE"] {
+		t.Errorf("Expected different templates to have different versions")
+	}
+
+	tree["docs.tmpl/guide.md.tmpl"] = &fstest.MapFile{Data: []byte("changed guide")}
+	if version, _ := resolver.Version("docs"); version == versions["docs"] {
+		t.Errorf("Expected the version of a directory template to change with its files")
+	}
+	if _, err := resolver.Version("missing"); err == nil {
+		t.Errorf("Expected error for a missing template, got nil")
+	}
+}
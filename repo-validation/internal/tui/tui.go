@@ -0,0 +1,510 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/gitrepo"
+	"github.com/LarsArtmann/templates/repo-validation/internal/lock"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// StatusFailing filters the results that have a problem, whatever their status
+const StatusFailing = "failing"
+
+// OtherGroup is the group of requirements added by the policy
+const OtherGroup = "Policy"
+
+// detailsHeight is the number of lines of the details pane
+const detailsHeight = 10
+
+// Filters cycled through with the p and s keys, "" shows everything
+var (
+	priorityFilters = []string{"", config.PriorityMustHave, config.PriorityShouldHave, config.PriorityNiceToHave}
+	statusFilters   = []string{"", StatusFailing, checker.StatusMissing, checker.StatusPresent, checker.StatusWaived, checker.StatusError}
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	groupStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	okStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	failStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	mutedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	addedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	removedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+)
+
+// row is a line of the result list, a group heading or a result
+type row struct {
+	group  string
+	result *checker.ValidationResult
+}
+
+// Model is the dashboard, a list of results grouped by file group with a details pane and a
+// preview of the fix of the selected requirement
+type Model struct {
+	chk     *checker.Checker
+	results []checker.ValidationResult
+	groups  map[string]string
+
+	priority int
+	status   int
+	rows     []row
+	cursor   int
+	offset   int
+
+	// preview holds the lines of the patch of the selected requirement while it is shown
+	preview       []string
+	previewOffset int
+
+	message string
+	width   int
+	height  int
+}
+
+// New creates the dashboard for the results of a checker
+func New(chk *checker.Checker, results []checker.ValidationResult) *Model {
+	m := &Model{chk: chk, groups: map[string]string{}, width: 80, height: 24}
+	for _, group := range config.GetFileGroups(chk.Config) {
+		for _, req := range group.Requirements {
+			m.groups[req.Path] = group.Name
+		}
+	}
+	m.setResults(results)
+	return m
+}
+
+// Run shows the dashboard until the user quits and returns the results as last checked
+func Run(chk *checker.Checker, results []checker.ValidationResult) ([]checker.ValidationResult, error) {
+	final, err := tea.NewProgram(New(chk, results), tea.WithAltScreen()).Run()
+	if err != nil {
+		return nil, err
+	}
+	return final.(*Model).Results(), nil
+}
+
+// Results returns the results as last checked
+func (m *Model) Results() []checker.ValidationResult {
+	return m.results
+}
+
+// Init implements tea.Model
+func (m *Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.scroll()
+	case tea.KeyMsg:
+		if m.preview != nil {
+			return m, m.updatePreview(msg)
+		}
+		return m, m.updateList(msg)
+	}
+	return m, nil
+}
+
+// updateList handles the keys of the result list
+func (m *Model) updateList(msg tea.KeyMsg) tea.Cmd {
+	m.message = ""
+	switch msg.String() {
+	case "q", "ctrl+c", "esc":
+		return tea.Quit
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "p":
+		m.priority = (m.priority + 1) % len(priorityFilters)
+		m.filter()
+	case "s":
+		m.status = (m.status + 1) % len(statusFilters)
+		m.filter()
+	case "r":
+		m.recheck("Checked again")
+	case "enter", "d":
+		m.showPreview()
+	case "f":
+		m.fix()
+	}
+	return nil
+}
+
+// updatePreview handles the keys of the fix preview
+func (m *Model) updatePreview(msg tea.KeyMsg) tea.Cmd {
+	page := max(m.height-3, 1)
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "q", "esc", "enter", "d":
+		m.preview = nil
+	case "up", "k":
+		m.previewOffset--
+	case "down", "j":
+		m.previewOffset++
+	case "pgup", "b":
+		m.previewOffset -= page
+	case "pgdown", " ":
+		m.previewOffset += page
+	case "f":
+		m.preview = nil
+		m.fix()
+	}
+	m.previewOffset = max(min(m.previewOffset, len(m.preview)-page), 0)
+	return nil
+}
+
+// setResults replaces the results and keeps the cursor on the same requirement if possible
+func (m *Model) setResults(results []checker.ValidationResult) {
+	m.results = results
+	m.filter()
+}
+
+// filter rebuilds the rows from the results that match the filters
+func (m *Model) filter() {
+	var selected string
+	if r := m.Selected(); r != nil {
+		selected = r.Requirement.Path
+	}
+
+	// Groups are listed in the order their first requirement is checked
+	var order []string
+	byGroup := map[string][]*checker.ValidationResult{}
+	for i := range m.results {
+		result := &m.results[i]
+		if !m.matches(*result) {
+			continue
+		}
+		group := m.groupOf(result.Requirement.Path)
+		if _, ok := byGroup[group]; !ok {
+			order = append(order, group)
+		}
+		byGroup[group] = append(byGroup[group], result)
+	}
+
+	m.rows = nil
+	m.cursor = -1
+	for _, group := range order {
+		m.rows = append(m.rows, row{group: group})
+		for _, result := range byGroup[group] {
+			if result.Requirement.Path == selected || m.cursor < 0 {
+				m.cursor = len(m.rows)
+			}
+			m.rows = append(m.rows, row{group: group, result: result})
+		}
+	}
+	m.scroll()
+}
+
+// matches reports whether a result passes the priority and status filters
+func (m *Model) matches(result checker.ValidationResult) bool {
+	if priority := priorityFilters[m.priority]; priority != "" && result.Requirement.Priority != priority {
+		return false
+	}
+	switch status := statusFilters[m.status]; status {
+	case "":
+		return true
+	case StatusFailing:
+		return Failing(result)
+	default:
+		return result.Status() == status
+	}
+}
+
+// groupOf returns the file group of a requirement
+func (m *Model) groupOf(path string) string {
+	if group, ok := m.groups[path]; ok {
+		return group
+	}
+	return OtherGroup
+}
+
+// move moves the cursor to the next or previous result, skipping group headings
+func (m *Model) move(delta int) {
+	for i := m.cursor + delta; i >= 0 && i < len(m.rows); i += delta {
+		if m.rows[i].result != nil {
+			m.cursor = i
+			break
+		}
+	}
+	m.scroll()
+}
+
+// scroll keeps the cursor within the visible part of the list
+func (m *Model) scroll() {
+	visible := m.listHeight()
+	if m.cursor >= 0 && m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+visible {
+		m.offset = m.cursor - visible + 1
+	}
+	// Show the heading of the first group when the first result is selected
+	if m.cursor == 1 {
+		m.offset = 0
+	}
+	m.offset = max(min(m.offset, len(m.rows)-visible), 0)
+}
+
+// listHeight is the number of list rows that fit between the header and the details pane
+func (m *Model) listHeight() int {
+	return max(m.height-detailsHeight-4, 1)
+}
+
+// Selected returns the selected result, or nil if no result matches the filters
+func (m *Model) Selected() *checker.ValidationResult {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return nil
+	}
+	return m.rows[m.cursor].result
+}
+
+// showPreview renders the changes fixing the selected requirement would make
+func (m *Model) showPreview() {
+	result := m.Selected()
+	if result == nil {
+		return
+	}
+	if len(m.chk.Fixable([]checker.ValidationResult{*result})) == 0 {
+		m.message = "Nothing to fix for " + result.Requirement.Path
+		return
+	}
+
+	changes, missing, err := m.chk.Preview(*result)
+	if err != nil {
+		m.message = "Error: " + err.Error()
+		return
+	}
+
+	var lines []string
+	if len(missing) > 0 {
+		lines = append(lines, fmt.Sprintf("Template variables not set, shown as placeholders: %s", strings.Join(missing, ", ")), "")
+	}
+	lines = append(lines, strings.Split(strings.TrimRight(checker.Patch(changes), "\n"), "\n")...)
+	m.preview, m.previewOffset = lines, 0
+}
+
+// fix generates the files of the selected requirement and checks the repository again
+func (m *Model) fix() {
+	result := m.Selected()
+	if result == nil {
+		return
+	}
+	if m.chk.Config.ReadOnly() {
+		m.message = "Revisions and archives are read-only, preview the fix with enter"
+		return
+	}
+	if len(m.chk.Fixable([]checker.ValidationResult{*result})) == 0 {
+		m.message = "Nothing to fix for " + result.Requirement.Path
+		return
+	}
+	if _, missing, err := m.chk.Preview(*result); err != nil || len(missing) > 0 {
+		m.message = fmt.Sprintf("Set the template variables %s in the policy file first", strings.Join(missing, ", "))
+		if err != nil {
+			m.message = "Error: " + err.Error()
+		}
+		return
+	}
+
+	created, modified, err := m.chk.FixMissingFiles([]checker.ValidationResult{*result})
+	if err != nil {
+		m.message = "Error: " + err.Error()
+		return
+	}
+	m.recheck(fmt.Sprintf("Fixed %s: %d created, %d modified, undo with repo-validate fix --undo", result.Requirement.Path, len(created), len(modified)))
+}
+
+// recheck checks the repository again
+func (m *Model) recheck(message string) {
+	results, err := m.chk.CheckRepository()
+	if err != nil {
+		m.message = "Error: " + err.Error()
+		return
+	}
+	m.setResults(results)
+	m.message = message
+}
+
+// View implements tea.Model
+func (m *Model) View() string {
+	if m.preview != nil {
+		return m.viewPreview()
+	}
+
+	var b strings.Builder
+
+	passed := 0
+	for _, result := range m.results {
+		if !Failing(result) {
+			passed++
+		}
+	}
+	fmt.Fprintf(&b, "%s  %s  %d/%d passed\n", titleStyle.Render("repo-validate"), m.chk.Config.RepoPath, passed, len(m.results))
+	fmt.Fprintf(&b, "Priority: %s  Status: %s\n", filterName(priorityFilters[m.priority]), filterName(statusFilters[m.status]))
+
+	visible := m.listHeight()
+	for i := m.offset; i < min(m.offset+visible, len(m.rows)); i++ {
+		b.WriteString(m.viewRow(i))
+		b.WriteString("\n")
+	}
+	if len(m.rows) == 0 {
+		b.WriteString(mutedStyle.Render("No requirements match the filters") + "\n")
+	}
+	for i := len(m.rows) - m.offset; i < visible; i++ {
+		b.WriteString("\n")
+	}
+
+	b.WriteString(mutedStyle.Render(strings.Repeat("─", max(m.width, 1))) + "\n")
+	details := strings.Split(m.viewDetails(), "\n")
+	for i := range detailsHeight {
+		if i < len(details) {
+			b.WriteString(truncate(details[i], m.width))
+		}
+		b.WriteString("\n")
+	}
+
+	help := "↑/↓ move  p priority  s status  enter preview fix  f fix  r recheck  q quit"
+	if m.message != "" {
+		help = m.message
+	}
+	b.WriteString(mutedStyle.Render(truncate(help, m.width)))
+
+	return b.String()
+}
+
+// viewRow renders a group heading or a result
+func (m *Model) viewRow(i int) string {
+	r := m.rows[i]
+	if r.result == nil {
+		return groupStyle.Render(r.group)
+	}
+
+	mark, style := "✓", okStyle
+	if Failing(*r.result) {
+		mark, style = "✗", failStyle
+	}
+	line := truncate(fmt.Sprintf("  %s %-32s %-13s %s", mark, r.result.Requirement.Path, r.result.Requirement.Priority, r.result.Status()), m.width)
+	if i == m.cursor {
+		return selectedStyle.Render(line)
+	}
+	return style.Render(line)
+}
+
+// viewDetails renders the details pane of the selected result
+func (m *Model) viewDetails() string {
+	result := m.Selected()
+	if result == nil {
+		return ""
+	}
+	req := result.Requirement
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", titleStyle.Render(req.Path))
+	fmt.Fprintf(&b, "Category: %s  Priority: %s  Status: %s", req.Category, req.Priority, result.Status())
+	if result.Git != "" {
+		fmt.Fprintf(&b, "  Git: %s", result.Git)
+	}
+	b.WriteString("\n")
+	if req.Description != "" {
+		fmt.Fprintf(&b, "%s\n", req.Description)
+	}
+	if name := m.chk.Templates.TemplateFor(req.Path, req.TemplatePath); name != "" {
+		fmt.Fprintf(&b, "Template: %s\n", name)
+	}
+	if w := m.chk.Config.Policy.Waiver(req.Path); w != nil {
+		fmt.Fprintf(&b, "Waived: %s\n", w.Reason)
+	}
+	for _, problem := range Problems(*result) {
+		fmt.Fprintf(&b, "%s\n", failStyle.Render("✗ "+problem))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// viewPreview renders the patch of the selected requirement
+func (m *Model) viewPreview() string {
+	var b strings.Builder
+	result := m.Selected()
+	fmt.Fprintf(&b, "%s\n", titleStyle.Render("Fix "+result.Requirement.Path))
+
+	page := max(m.height-3, 1)
+	for i := m.previewOffset; i < min(m.previewOffset+page, len(m.preview)); i++ {
+		line := truncate(m.preview[i], m.width)
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			line = titleStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			line = addedStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			line = removedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	for i := len(m.preview) - m.previewOffset; i < page; i++ {
+		b.WriteString("\n")
+	}
+
+	b.WriteString(mutedStyle.Render(fmt.Sprintf("↑/↓ scroll  f apply  esc back  (%d lines)", len(m.preview))))
+	return b.String()
+}
+
+// Problems returns why a result fails validation, empty if it passes
+func Problems(result checker.ValidationResult) []string {
+	var problems []string
+	if result.Error != nil {
+		problems = append(problems, "could not be validated: "+result.Error.Error())
+	}
+	switch result.Git {
+	case gitrepo.Ignored:
+		problems = append(problems, "ignored by git, remove it from .gitignore and git add it")
+	case gitrepo.Untracked:
+		problems = append(problems, "not tracked by git, git add it")
+	}
+	if result.Placeholder {
+		problems = append(problems, "placeholder, replace it with the full text")
+	} else if !result.Exists && !result.Waived && result.Error == nil && result.Git == "" {
+		problems = append(problems, "missing")
+	}
+	if result.Outdated {
+		problems = append(problems, "managed block does not match its template")
+	}
+	if len(result.MissingEntries) > 0 {
+		problems = append(problems, "missing required entries: "+strings.Join(result.MissingEntries, ", "))
+	}
+	if result.Drift == lock.DriftBehind || result.Drift == lock.DriftDiverged {
+		problems = append(problems, fmt.Sprintf("generated from an older template (%s), run repo-validate upgrade", result.Drift))
+	}
+	return problems
+}
+
+// Failing reports whether a result fails validation
+func Failing(result checker.ValidationResult) bool {
+	return len(Problems(result)) > 0
+}
+
+// filterName describes a filter value
+func filterName(value string) string {
+	if value == "" {
+		return "all"
+	}
+	return value
+}
+
+// truncate shortens a line to the width of the terminal
+func truncate(line string, width int) string {
+	if width <= 0 || lipgloss.Width(line) <= width {
+		return line
+	}
+	runes := []rune(line)
+	for len(runes) > 0 && lipgloss.Width(string(runes)) > width-1 {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
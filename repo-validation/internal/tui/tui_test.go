@@ -0,0 +1,180 @@
+package tui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LarsArtmann/templates/repo-validation/internal/checker"
+	"github.com/LarsArtmann/templates/repo-validation/internal/config"
+	"github.com/LarsArtmann/templates/repo-validation/internal/gitrepo"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestModel creates a dashboard for a repository containing only a README
+func newTestModel(t *testing.T) (*Model, string) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Test\n"), 0644); err != nil {
+		t.Fatalf("Failed to create README.md: %v", err)
+	}
+
+	chk := checker.NewChecker(&config.Config{RepoPath: dir, CheckDocker: true})
+	results, err := chk.CheckRepository()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	m := New(chk, results)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 60})
+	return m, dir
+}
+
+// press sends a key to the dashboard
+func press(m *Model, key string) {
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	switch key {
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	case "up":
+		msg = tea.KeyMsg{Type: tea.KeyUp}
+	case "down":
+		msg = tea.KeyMsg{Type: tea.KeyDown}
+	}
+	m.Update(msg)
+}
+
+// selectPath moves the cursor to a requirement
+func selectPath(t *testing.T, m *Model, path string) {
+	t.Helper()
+	for range m.rows {
+		press(m, "up")
+	}
+	for range m.rows {
+		if r := m.Selected(); r != nil && r.Requirement.Path == path {
+			return
+		}
+		press(m, "down")
+	}
+	t.Fatalf("Expected %s in the list", path)
+}
+
+func TestGroupsAndFilters(t *testing.T) {
+	m, _ := newTestModel(t)
+
+	view := m.View()
+	for _, want := range []string{"Core", "Docker", "README.md", "Dockerfile", "Priority: all  Status: all"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected the view to contain %q, got:\n%s", want, view)
+		}
+	}
+	if m.rows[0].group != "Core" || m.rows[0].result != nil {
+		t.Errorf("Expected the list to start with the Core heading, got %+v", m.rows[0])
+	}
+
+	t.Run("status", func(t *testing.T) {
+		press(m, "s")
+		if statusFilters[m.status] != StatusFailing {
+			t.Fatalf("Expected the failing filter, got %q", statusFilters[m.status])
+		}
+		for _, r := range m.rows {
+			if r.result != nil && r.result.Requirement.Path == "README.md" {
+				t.Error("Expected README.md to be filtered out")
+			}
+		}
+		for range len(statusFilters) - 1 {
+			press(m, "s")
+		}
+	})
+
+	t.Run("priority", func(t *testing.T) {
+		press(m, "p")
+		for _, r := range m.rows {
+			if r.result != nil && r.result.Requirement.Priority != config.PriorityMustHave {
+				t.Errorf("Expected only must-have requirements, got %s", r.result.Requirement.Path)
+			}
+		}
+		if !strings.Contains(m.View(), "Priority: Must-have") {
+			t.Error("Expected the view to show the priority filter")
+		}
+	})
+}
+
+func TestDetails(t *testing.T) {
+	m, _ := newTestModel(t)
+	selectPath(t, m, "LICENSE.md")
+
+	view := m.View()
+	for _, want := range []string{"Priority: Must-have", "Status: missing", "Template: LICENSE.md.tmpl", "✗ missing"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected the details to contain %q, got:\n%s", want, view)
+		}
+	}
+}
+
+func TestPreviewAndFix(t *testing.T) {
+	m, dir := newTestModel(t)
+	selectPath(t, m, ".gitignore")
+
+	press(m, "enter")
+	if m.preview == nil {
+		t.Fatalf("Expected a preview, got message %q", m.message)
+	}
+	if view := m.View(); !strings.Contains(view, "+++ b/.gitignore") {
+		t.Errorf("Expected the patch of .gitignore, got:\n%s", view)
+	}
+
+	press(m, "esc")
+	if m.preview != nil {
+		t.Error("Expected esc to close the preview")
+	}
+
+	press(m, "f")
+	if _, err := os.Stat(filepath.Join(dir, ".gitignore")); err != nil {
+		t.Fatalf("Expected .gitignore to be created, got %v", err)
+	}
+	if r := m.Selected(); r == nil || r.Requirement.Path != ".gitignore" || !r.Exists {
+		t.Errorf("Expected .gitignore to stay selected and be present, got %+v", r)
+	}
+	if !strings.Contains(m.message, "Fixed .gitignore") {
+		t.Errorf("Expected a message about the fix, got %q", m.message)
+	}
+
+	t.Run("nothing to fix", func(t *testing.T) {
+		selectPath(t, m, "README.md")
+		press(m, "f")
+		if !strings.Contains(m.message, "Nothing to fix") {
+			t.Errorf("Expected nothing to fix, got %q", m.message)
+		}
+	})
+}
+
+func TestProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		result checker.ValidationResult
+		want   []string
+	}{
+		{"present", checker.ValidationResult{Exists: true}, nil},
+		{"missing", checker.ValidationResult{}, []string{"missing"}},
+		{"waived", checker.ValidationResult{Waived: true}, nil},
+		{"untracked", checker.ValidationResult{Git: gitrepo.Untracked}, []string{"not tracked by git, git add it"}},
+		{"error", checker.ValidationResult{Error: errors.New("boom")}, []string{"could not be validated: boom"}},
+		{"entries", checker.ValidationResult{Exists: true, MissingEntries: []string{".env"}}, []string{"missing required entries: .env"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Problems(tt.result)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+			if Failing(tt.result) != (len(tt.want) > 0) {
+				t.Errorf("Expected failing to be %v", len(tt.want) > 0)
+			}
+		})
+	}
+}
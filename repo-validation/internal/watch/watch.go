@@ -0,0 +1,148 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is how long the watcher waits for more events before reporting a batch
+const DefaultDebounce = 200 * time.Millisecond
+
+// skipDirs are directories whose contents are never watched
+var skipDirs = []string{"node_modules", "vendor"}
+
+// gitFiles are the files in .git that change the git status of files, the rest of .git is
+// not reported
+var gitFiles = []string{".git/index", ".git/info/exclude"}
+
+// Watcher reports the files changed below a directory in debounced batches
+type Watcher struct {
+	// Root is the watched directory
+	Root string
+	// Debounce is how long to wait for more events before reporting a batch
+	Debounce time.Duration
+
+	fsw *fsnotify.Watcher
+}
+
+// New creates a watcher for every directory below root
+func New(root string, debounce time.Duration) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{Root: root, Debounce: debounce, fsw: fsw}
+	if err := w.addTree(root); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	return w.fsw.Close()
+}
+
+// Run calls fn with the sorted slash-separated paths, relative to the root, of the files that
+// changed, once no event arrived for the debounce duration. It returns when the context is
+// done, or with the first error of the watcher or fn.
+func (w *Watcher) Run(ctx context.Context, fn func(changed []string) error) error {
+	pending := map[string]bool{}
+	timer := time.NewTimer(w.Debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			rel, err := filepath.Rel(w.Root, event.Name)
+			if err != nil || skip(filepath.ToSlash(rel)) {
+				continue
+			}
+			// Directories created later are watched too, with the files already in them
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.addTree(event.Name); err != nil {
+						return err
+					}
+				}
+			}
+			pending[filepath.ToSlash(rel)] = true
+			timer.Reset(w.Debounce)
+
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+
+		case <-timer.C:
+			changed := make([]string, 0, len(pending))
+			for name := range pending {
+				changed = append(changed, name)
+			}
+			slices.Sort(changed)
+			clear(pending)
+			if err := fn(changed); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// addTree watches a directory and the directories below it
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Directories removed while walking are not watched
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != w.Root && slices.Contains(skipDirs, d.Name()) {
+			return filepath.SkipDir
+		}
+		if err := w.fsw.Add(p); err != nil {
+			return err
+		}
+		// Only the index and exclude files of .git matter
+		if d.Name() == ".git" {
+			if err := w.fsw.Add(filepath.Join(p, "info")); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// skip reports whether a changed file is one of the files in .git that do not matter, such
+// as objects, refs and lock files, or is in a directory that is never watched
+func skip(rel string) bool {
+	if (strings.HasPrefix(rel, ".git/") || rel == ".git") && !slices.Contains(gitFiles, rel) {
+		return true
+	}
+	return slices.ContainsFunc(strings.Split(rel, "/"), func(name string) bool {
+		return slices.Contains(skipDirs, name)
+	})
+}
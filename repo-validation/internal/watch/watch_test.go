@@ -0,0 +1,93 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".git", "objects"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	w, err := New(dir, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	batches := make(chan []string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Run(ctx, func(changed []string) error {
+			batches <- changed
+			return nil
+		})
+	}()
+
+	write := func(name string) {
+		t.Helper()
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte("test content"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	next := func() []string {
+		t.Helper()
+		select {
+		case changed := <-batches:
+			return changed
+		case <-ctx.Done():
+			t.Fatal("Expected a batch of changes before the timeout")
+			return nil
+		}
+	}
+
+	// Events in quick succession are reported together, git internals are not reported
+	write("README.md")
+	write("LICENSE.md")
+	write(".git/objects/ab")
+	if got, want := next(), []string{"LICENSE.md", "README.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// New directories are watched too
+	write(".github/CODEOWNERS")
+	if got := next(); len(got) == 0 || got[0] != ".github" {
+		t.Errorf("Expected the new directory to be reported, got %v", got)
+	}
+	write(".github/SECURITY.md")
+	if got, want := next(), []string{".github/SECURITY.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// Directories that are never watched are skipped when created later, also below new directories
+	write("node_modules/left-pad/lib/index.js")
+	write("docs/vendor/lib/lib.go")
+	write("NOTES.md")
+	if got, want := next(), []string{"NOTES.md", "docs"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	for _, p := range w.fsw.WatchList() {
+		if name := filepath.Base(p); name == "node_modules" || strings.Contains(p, "vendor") || strings.Contains(p, "left-pad") {
+			t.Errorf("Expected %s not to be watched", p)
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error when the context is done, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -15,14 +16,53 @@ import (
 // Version is the current version of the repository validation script
 const Version = "0.1.0"
 
+// subcommands maps subcommand names to their entry points
+var subcommands = map[string]func(args []string) error{
+	"diff":      cmd.RunDiff,
+	"fix":       cmd.RunFix,
+	"history":   cmd.RunHistory,
+	"init":      cmd.RunInit,
+	"mcp":       runMCP,
+	"policy":    cmd.RunPolicy,
+	"scan":      cmd.RunScan,
+	"serve":     cmd.RunServe,
+	"templates": cmd.RunTemplates,
+	"upgrade":   cmd.RunUpgrade,
+}
+
+// runMCP runs the mcp subcommand, which reports the version to clients
+func runMCP(args []string) error {
+	return cmd.RunMCP(args, Version)
+}
+
 func main() {
+	// Dispatch to a subcommand if one is given
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				exitWithError(cmd.Cause(err), cmd.JSONOutput(err))
+			}
+			os.Exit(exitcode.Success)
+		}
+	}
+
 	// Parse command-line flags
 	version := flag.Bool("version", false, "Show version information")
 	dryRun := flag.Bool("dry-run", false, "Only report issues without making changes")
 	fix := flag.Bool("fix", false, "Generate missing files")
+	diff := flag.Bool("diff", false, "With --fix, print the changes as unified diffs instead of writing them")
+	pick := flag.Bool("pick", false, "With --fix, choose the missing files to generate in an interactive form")
+	tuiMode := flag.Bool("tui", false, "Browse the results and fix them in an interactive dashboard")
+	watchMode := flag.Bool("watch", false, "Check again whenever files in the repository change, until interrupted")
+	patchFile := flag.String("patch", "", "With --fix, write the changes to a patch file for git apply instead of writing them")
 	jsonOutput := flag.Bool("json", false, "Output results in JSON format")
 	repoPath := flag.String("path", ".", "Path to the repository to validate")
+	ref := flag.String("ref", "", "Validate a git revision (branch, tag or commit) instead of the working tree, --path may be a bare repository")
+	record := flag.Bool("record", false, "Append the results to the history file")
+	historyFile := flag.String("history-file", "", "With --record, the history file, defaults to $"+cmd.HistoryFileEnv+" or the user config directory")
 	interactive := flag.Bool("interactive", false, "Prompt for missing parameters")
+	var templateDirs cmd.StringList
+	flag.Var(&templateDirs, "template-dir", "Organisation template directory, searched after repository and user overrides (repeatable)")
 
 	// Optional file group flags
 	checkAugment := flag.Bool("augment", false, "Check Augment AI related files (.augment-guidelines, .augmentignore)")
@@ -30,6 +70,7 @@ func main() {
 	checkTypeScript := flag.Bool("typescript", false, "Check TypeScript/JavaScript related files (package.json, tsconfig.json)")
 	checkDevContainer := flag.Bool("devcontainer", false, "Check DevContainer related files (.devcontainer.json)")
 	checkDevEnv := flag.Bool("devenv", false, "Check DevEnv related files (devenv.nix)")
+	checkGitHub := flag.Bool("github", false, "Check GitHub issue and pull request templates (.github/)")
 	checkAll := flag.Bool("all", false, "Check all optional file groups")
 
 	flag.Parse()
@@ -40,13 +81,34 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Resolve the history file results are recorded to
+	if *historyFile != "" && !*record {
+		exitWithError(errors.NewInvalidConfigError("--history-file can only be used together with --record"), *jsonOutput)
+	}
+	var recordTo string
+	if *record {
+		file, err := cmd.HistoryFile(*historyFile)
+		if err != nil {
+			exitWithError(errors.NewPathError(*historyFile, err), *jsonOutput)
+		}
+		recordTo = file
+	}
+
 	// Prepare options for the run function
 	options := []config.ConfigOption{
 		config.WithDryRun(*dryRun),
 		config.WithFix(*fix),
+		config.WithDiff(*diff),
+		config.WithPatchFile(*patchFile),
+		config.WithPick(*pick),
+		config.WithTUI(*tuiMode),
+		config.WithWatch(*watchMode),
 		config.WithJSONOutput(*jsonOutput),
 		config.WithRepoPath(*repoPath),
+		config.WithRef(*ref),
+		config.WithHistoryFile(recordTo),
 		config.WithInteractive(*interactive),
+		config.WithTemplateDirs(append(templateDirs, cmd.TemplateDirsFromEnv()...)...),
 	}
 
 	// If --all is specified, add the all file group option
@@ -69,33 +131,40 @@ func main() {
 		if *checkDevEnv {
 			options = append(options, config.WithFileGroup("devenv", true))
 		}
+		if *checkGitHub {
+			options = append(options, config.WithFileGroup("github", true))
+		}
 	}
 
 	// Run the application with the options
 	if err := cmd.Run(options...); err != nil {
-		// Determine the exit code based on the error type
-		exitCode := exitcode.GeneralError
-
-		switch err.(type) {
-		case *errors.PathError:
-			exitCode = exitcode.PathError
-		case *errors.FileAccessError:
-			exitCode = exitcode.FileAccessError
-		case *errors.InvalidConfigError:
-			exitCode = exitcode.InvalidConfig
-		case *errors.MissingMustHaveFilesError:
-			exitCode = exitcode.MissingMustHaveFiles
-		}
-
-		if *jsonOutput {
-			// Output error in JSON format
-			fmt.Printf("{\"error\": \"%s\", \"code\": %d}\n", err.Error(), exitCode)
-		} else {
-			// Output error in human-readable format with color
-			log.Error("Validation failed", "error", err, "code", exitCode)
-		}
-		os.Exit(exitCode)
+		exitWithError(err, *jsonOutput)
 	}
 }
 
+// exitWithError reports an error and exits with the matching exit code
+func exitWithError(err error, jsonOutput bool) {
+	// Determine the exit code based on the error type
+	exitCode := exitcode.GeneralError
 
+	switch err.(type) {
+	case *errors.PathError:
+		exitCode = exitcode.PathError
+	case *errors.FileAccessError:
+		exitCode = exitcode.FileAccessError
+	case *errors.InvalidConfigError:
+		exitCode = exitcode.InvalidConfig
+	case *errors.MissingMustHaveFilesError:
+		exitCode = exitcode.MissingMustHaveFiles
+	}
+
+	if jsonOutput {
+		// Output error in JSON format
+		message, _ := json.Marshal(err.Error())
+		fmt.Printf("{\"error\": %s, \"code\": %d}\n", message, exitCode)
+	} else {
+		// Output error in human-readable format with color
+		log.Error("Validation failed", "error", err, "code", exitCode)
+	}
+	os.Exit(exitCode)
+}